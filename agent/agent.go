@@ -14,18 +14,21 @@ import (
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery"
+	"github.com/netdata/go.d.plugin/agent/discovery/dyncfg"
 	"github.com/netdata/go.d.plugin/agent/filelock"
 	"github.com/netdata/go.d.plugin/agent/filestatus"
 	"github.com/netdata/go.d.plugin/agent/functions"
 	"github.com/netdata/go.d.plugin/agent/jobmgr"
 	"github.com/netdata/go.d.plugin/agent/module"
 	"github.com/netdata/go.d.plugin/agent/netdataapi"
+	"github.com/netdata/go.d.plugin/agent/promexporter"
 	"github.com/netdata/go.d.plugin/agent/safewriter"
 	"github.com/netdata/go.d.plugin/agent/vnodes"
 	"github.com/netdata/go.d.plugin/logger"
 	"github.com/netdata/go.d.plugin/pkg/multipath"
 
 	"github.com/mattn/go-isatty"
+	"gopkg.in/yaml.v2"
 )
 
 var isTerminal = isatty.IsTerminal(os.Stdout.Fd())
@@ -38,10 +41,13 @@ type Config struct {
 	ModulesSDConfPath []string
 	VnodesConfDir     []string
 	StateFile         string
+	ModulesStateFile  string
+	DyncfgDir         string
 	LockDir           string
 	ModuleRegistry    module.Registry
 	RunModule         string
 	MinUpdateEvery    int
+	PrometheusAddr    string
 }
 
 // Agent represents orchestrator.
@@ -54,18 +60,23 @@ type Agent struct {
 	ModulesSDConfPath []string
 	VnodesConfDir     multipath.MultiPath
 	StateFile         string
+	ModulesStateFile  string
+	DyncfgDir         string
 	LockDir           string
 	RunModule         string
 	MinUpdateEvery    int
+	PrometheusAddr    string
 	ModuleRegistry    module.Registry
 	Out               io.Writer
 
-	api *netdataapi.API
+	api      *netdataapi.API
+	exporter *promexporter.Exporter
+	reloadCh chan struct{}
 }
 
 // New creates a new Agent.
 func New(cfg Config) *Agent {
-	return &Agent{
+	a := &Agent{
 		Logger: logger.New().With(
 			slog.String("component", "agent"),
 		),
@@ -75,18 +86,35 @@ func New(cfg Config) *Agent {
 		ModulesSDConfPath: cfg.ModulesSDConfPath,
 		VnodesConfDir:     cfg.VnodesConfDir,
 		StateFile:         cfg.StateFile,
+		ModulesStateFile:  cfg.ModulesStateFile,
+		DyncfgDir:         cfg.DyncfgDir,
 		LockDir:           cfg.LockDir,
 		RunModule:         cfg.RunModule,
 		MinUpdateEvery:    cfg.MinUpdateEvery,
+		PrometheusAddr:    cfg.PrometheusAddr,
 		ModuleRegistry:    module.DefaultRegistry,
 		Out:               safewriter.Stdout,
 		api:               netdataapi.New(safewriter.Stdout),
+		reloadCh:          make(chan struct{}, 1),
 	}
+
+	if a.PrometheusAddr != "" {
+		// standalone mode: the plugins.d output is consumed by the exporter instead of Netdata
+		a.exporter = promexporter.New()
+		a.Out = a.exporter
+		a.api = netdataapi.New(a.exporter)
+	}
+
+	return a
 }
 
 // Run starts the Agent.
 func (a *Agent) Run() {
-	go a.keepAlive()
+	if a.exporter != nil {
+		go a.serveExporter()
+	} else {
+		go a.keepAlive()
+	}
 	serve(a)
 }
 
@@ -95,50 +123,37 @@ func serve(a *Agent) {
 	signal.Notify(ch, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 	var wg sync.WaitGroup
 
-	var exit bool
-	var reload bool
+	ctx, cancel := context.WithCancel(context.Background())
 
-	for {
-		ctx, cancel := context.WithCancel(context.Background())
-		ctx = context.WithValue(ctx, "reload", reload)
+	wg.Add(1)
+	go func() { defer wg.Done(); a.run(ctx) }()
 
-		wg.Add(1)
-		go func() { defer wg.Done(); a.run(ctx) }()
-
-		switch sig := <-ch; sig {
-		case syscall.SIGHUP:
-			a.Infof("received %s signal (%d). Restarting running instance", sig, sig)
-		default:
-			a.Infof("received %s signal (%d). Terminating...", sig, sig)
-			module.DontObsoleteCharts()
-			exit = true
+	for sig := range ch {
+		if sig == syscall.SIGHUP {
+			a.Infof("received %s signal (%d). Reloading the configuration", sig, sig)
+			a.triggerReload()
+			continue
 		}
+		a.Infof("received %s signal (%d). Terminating...", sig, sig)
+		module.DontObsoleteCharts()
+		break
+	}
 
-		cancel()
-
-		func() {
-			timeout := time.Second * 10
-			t := time.NewTimer(timeout)
-			defer t.Stop()
-			done := make(chan struct{})
+	cancel()
 
-			go func() { wg.Wait(); close(done) }()
+	timeout := time.Second * 10
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	done := make(chan struct{})
 
-			select {
-			case <-t.C:
-				a.Errorf("stopping all goroutines timed out after %s. Exiting...", timeout)
-				os.Exit(0)
-			case <-done:
-			}
-		}()
+	go func() { wg.Wait(); close(done) }()
 
-		if exit {
-			os.Exit(0)
-		}
-
-		reload = true
-		time.Sleep(time.Second)
+	select {
+	case <-t.C:
+		a.Errorf("stopping all goroutines timed out after %s. Exiting...", timeout)
+	case <-done:
 	}
+	os.Exit(0)
 }
 
 func (a *Agent) run(ctx context.Context) {
@@ -179,26 +194,39 @@ func (a *Agent) run(ctx context.Context) {
 	}
 
 	functionsManager := functions.NewManager()
+	functionsManager.Out = a.Out
 
 	jobsManager := jobmgr.NewManager()
 	jobsManager.PluginName = a.Name
 	jobsManager.Out = a.Out
 	jobsManager.Modules = enabledModules
-
-	// TODO: API will be changed in https://github.com/netdata/netdata/pull/16702
-	//if logger.Level.Enabled(slog.LevelDebug) {
-	//	dyncfgDiscovery, _ := dyncfg.NewDiscovery(dyncfg.Config{
-	//		Plugin:               a.Name,
-	//		API:                  netdataapi.New(a.Out),
-	//		Modules:              enabledModules,
-	//		ModuleConfigDefaults: discCfg.Registry,
-	//		Functions:            functionsManager,
-	//	})
-	//
-	//	discoveryManager.Add(dyncfgDiscovery)
-	//
-	//	jobsManager.Dyncfg = dyncfgDiscovery
-	//}
+	jobsManager.TickSpread = cfg.TickSpread
+	jobsManager.ConfDir = a.ModulesConfDir
+	jobsManager.FunctionRegistry = functionsManager
+
+	reload := reloadTargets{jobs: jobsManager, disc: discoveryManager}
+
+	// dyncfg functions are called by Netdata, they are not available in a terminal and in the standalone mode
+	if !isTerminal && a.exporter == nil {
+		pluginConfig, _ := yaml.Marshal(cfg)
+		dyncfgDiscovery, err := dyncfg.NewDiscovery(dyncfg.Config{
+			Plugin:               a.Name,
+			API:                  netdataapi.New(a.Out),
+			Modules:              enabledModules,
+			ModuleConfigDefaults: discCfg.Registry,
+			Functions:            functionsManager,
+			PluginConfig:         pluginConfig,
+			PluginConfigSchema:   pluginConfigSchema,
+			Store:                a.dyncfgStore(),
+		})
+		if err != nil {
+			a.Errorf("dyncfg discovery: %v", err)
+		} else {
+			discoveryManager.Add(dyncfgDiscovery)
+			jobsManager.Dyncfg = dyncfgDiscovery
+			reload.dyncfg = dyncfgDiscovery
+		}
+	}
 
 	if reg := a.setupVnodeRegistry(); reg == nil || reg.Len() == 0 {
 		vnodes.Disabled = true
@@ -221,6 +249,12 @@ func (a *Agent) run(ctx context.Context) {
 		}
 	}
 
+	var stateManager *filestatus.StateManager
+	if !isTerminal && a.ModulesStateFile != "" {
+		stateManager = filestatus.NewStateManager(a.ModulesStateFile)
+		jobsManager.StateStore = stateManager
+	}
+
 	in := make(chan []*confgroup.Group)
 	var wg sync.WaitGroup
 
@@ -238,8 +272,30 @@ func (a *Agent) run(ctx context.Context) {
 		go func() { defer wg.Done(); statusSaveManager.Run(ctx) }()
 	}
 
-	wg.Wait()
-	<-ctx.Done()
+	if stateManager != nil {
+		wg.Add(1)
+		go func() { defer wg.Done(); stateManager.Run(ctx) }()
+	}
+
+	wg.Add(1)
+	go func() { defer wg.Done(); a.watchPluginConfig(ctx) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-a.reloadCh:
+			enabledModules = a.reload(enabledModules, reload)
+		}
+	}
+}
+
+func (a *Agent) serveExporter() {
+	if err := a.exporter.Serve(context.Background(), a.PrometheusAddr); err != nil {
+		a.Errorf("prometheus exporter: %v. Terminating...", err)
+		os.Exit(1)
+	}
 }
 
 func (a *Agent) keepAlive() {
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/netdata/go.d.plugin/agent/hostinfo"
 	"github.com/netdata/go.d.plugin/agent/module"
@@ -33,12 +34,34 @@ func (c Config) FullName() string        { return fullName(c.Name(), c.Module())
 func (c Config) UpdateEvery() int        { v, _ := c.get("update_every").(int); return v }
 func (c Config) AutoDetectionRetry() int { v, _ := c.get("autodetection_retry").(int); return v }
 func (c Config) Priority() int           { v, _ := c.get("priority").(int); return v }
+func (c Config) Schedule() string        { v, _ := c.get("schedule").(string); return v }
 func (c Config) Labels() map[any]any     { v, _ := c.get("labels").(map[any]any); return v }
 func (c Config) Hash() uint64            { return calcHash(c) }
 func (c Config) Source() string          { v, _ := c.get("__source__").(string); return v }
 func (c Config) Provider() string        { v, _ := c.get("__provider__").(string); return v }
 func (c Config) Vnode() string           { v, _ := c.get("vnode").(string); return v }
 
+// CollectTimeout returns the data collection timeout, zero if it is not set. The value is a number of seconds
+// (e.g. 5, 2.5) or a duration string (e.g. "500ms", "5s").
+func (c Config) CollectTimeout() (time.Duration, error) {
+	switch v := c.get("collect_timeout").(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("collect_timeout: invalid duration '%s'", v)
+		}
+		return d, nil
+	default:
+		return 0, fmt.Errorf("collect_timeout: expected seconds or duration, got '%v'", v)
+	}
+}
+
 func (c Config) SetName(v string)     { c.set("name", v) }
 func (c Config) SetModule(v string)   { c.set("module", v) }
 func (c Config) SetSource(v string)   { c.set("__source__", v) }
@@ -4,6 +4,7 @@ package confgroup
 
 import (
 	"testing"
+	"time"
 
 	"github.com/netdata/go.d.plugin/agent/module"
 
@@ -119,6 +120,53 @@ func TestConfig_Priority(t *testing.T) {
 	}
 }
 
+func TestConfig_CollectTimeout(t *testing.T) {
+	tests := map[string]struct {
+		cfg      Config
+		expected time.Duration
+		wantErr  bool
+	}{
+		"int":              {cfg: Config{"collect_timeout": 1}, expected: time.Second},
+		"float":            {cfg: Config{"collect_timeout": 2.5}, expected: time.Millisecond * 2500},
+		"duration":         {cfg: Config{"collect_timeout": "500ms"}, expected: time.Millisecond * 500},
+		"invalid duration": {cfg: Config{"collect_timeout": "1"}, wantErr: true},
+		"not a number":     {cfg: Config{"collect_timeout": true}, wantErr: true},
+		"not set":          {cfg: Config{}, expected: 0},
+		"nil cfg":          {expected: 0},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := test.cfg.CollectTimeout()
+
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, v)
+		})
+	}
+}
+
+func TestConfig_Schedule(t *testing.T) {
+	tests := map[string]struct {
+		cfg      Config
+		expected interface{}
+	}{
+		"string":     {cfg: Config{"schedule": "5 * * * *"}, expected: "5 * * * *"},
+		"not string": {cfg: Config{"schedule": 1}, expected: ""},
+		"not set":    {cfg: Config{}, expected: ""},
+		"nil cfg":    {expected: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.cfg.Schedule())
+		})
+	}
+}
+
 func TestConfig_Hash(t *testing.T) {
 	tests := map[string]struct {
 		one, two Config
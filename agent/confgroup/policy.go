@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package confgroup
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+// JobPolicy holds the job options that are handled by the job manager and are not passed to the module.
+type JobPolicy struct {
+	RetryPolicy   module.RetryPolicy   `yaml:"retry_policy"`
+	ChartRules    module.ChartRules    `yaml:"chart_rules"`
+	DerivedCharts module.DerivedCharts `yaml:"derived_charts"`
+	Limits        module.Limits        `yaml:"limits"`
+	ObsoleteAfter int                  `yaml:"obsolete_after"`
+	HealthCharts  bool                 `yaml:"health_charts"`
+}
+
+// Init validates the policy and prepares the chart rules and the derived charts for use.
+func (p *JobPolicy) Init() error {
+	if err := p.RetryPolicy.Validate(); err != nil {
+		return err
+	}
+	if err := p.ChartRules.Init(); err != nil {
+		return err
+	}
+	if err := p.DerivedCharts.Init(); err != nil {
+		return err
+	}
+	if err := p.Limits.Validate(); err != nil {
+		return err
+	}
+	if p.ObsoleteAfter < 0 {
+		return fmt.Errorf("obsolete_after must be >= 0, got %d", p.ObsoleteAfter)
+	}
+	return nil
+}
+
+// IntJobKeys are the job keys with integer values.
+var IntJobKeys = []string{"update_every", "autodetection_retry", "priority", "obsolete_after"}
+
+// jobKeys are the job keys read by the Config getters and the JobPolicy keys.
+var jobKeys = func() map[string]bool {
+	keys := map[string]bool{
+		"module":              true,
+		"update_every":        true,
+		"autodetection_retry": true,
+		"priority":            true,
+		"labels":              true,
+		"vnode":               true,
+		"collect_timeout":     true,
+		"schedule":            true,
+	}
+	typ := reflect.TypeOf(JobPolicy{})
+	for i := 0; i < typ.NumField(); i++ {
+		tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+		keys[tag] = true
+	}
+	return keys
+}()
+
+// IsJobKey returns whether the key is a job option rather than a module configuration option.
+func IsJobKey(key string) bool {
+	return jobKeys[key]
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package confgroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsJobKey(t *testing.T) {
+	tests := map[string]struct {
+		key      string
+		expected bool
+	}{
+		"getter key":    {key: "update_every", expected: true},
+		"policy key":    {key: "retry_policy", expected: true},
+		"health charts": {key: "health_charts", expected: true},
+		"name":          {key: "name", expected: false},
+		"module key":    {key: "url", expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsJobKey(test.key))
+		})
+	}
+}
+
+func TestJobPolicy_Init(t *testing.T) {
+	tests := map[string]struct {
+		policy  JobPolicy
+		wantErr bool
+	}{
+		"default":                 {policy: JobPolicy{}},
+		"obsolete_after positive": {policy: JobPolicy{ObsoleteAfter: 60}},
+		"obsolete_after negative": {policy: JobPolicy{ObsoleteAfter: -1}, wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.wantErr {
+				assert.Error(t, test.policy.Init())
+			} else {
+				assert.NoError(t, test.policy.Init())
+			}
+		})
+	}
+}
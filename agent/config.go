@@ -8,11 +8,43 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// pluginConfigSchema is the plugin configuration JSON schema, used to validate the configuration changed via dyncfg.
+const pluginConfigSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "go.d plugin configuration schema.",
+  "type": "object",
+  "properties": {
+    "enabled": {
+      "type": "boolean"
+    },
+    "default_run": {
+      "type": "boolean"
+    },
+    "max_procs": {
+      "type": "integer",
+      "minimum": 0
+    },
+    "tick_spread": {
+      "type": "boolean"
+    },
+    "modules": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "boolean"
+      }
+    }
+  },
+  "additionalProperties": {
+    "type": "boolean"
+  }
+}`
+
 func defaultConfig() config {
 	return config{
 		Enabled:    true,
 		DefaultRun: true,
 		MaxProcs:   0,
+		TickSpread: false,
 		Modules:    nil,
 	}
 }
@@ -21,12 +53,13 @@ type config struct {
 	Enabled    bool            `yaml:"enabled"`
 	DefaultRun bool            `yaml:"default_run"`
 	MaxProcs   int             `yaml:"max_procs"`
+	TickSpread bool            `yaml:"tick_spread"`
 	Modules    map[string]bool `yaml:"modules"`
 }
 
 func (c *config) String() string {
-	return fmt.Sprintf("enabled '%v', default_run '%v', max_procs '%d'",
-		c.Enabled, c.DefaultRun, c.MaxProcs)
+	return fmt.Sprintf("enabled '%v', default_run '%v', max_procs '%d', tick_spread '%v'",
+		c.Enabled, c.DefaultRun, c.MaxProcs, c.TickSpread)
 }
 
 func (c *config) isExplicitlyEnabled(moduleName string) bool {
@@ -60,7 +93,7 @@ func (c *config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	for key, value := range m {
 		switch key {
-		case "enabled", "default_run", "max_procs", "modules":
+		case "enabled", "default_run", "max_procs", "tick_spread", "modules":
 			continue
 		}
 		var b bool
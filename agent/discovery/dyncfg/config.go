@@ -3,6 +3,8 @@
 package dyncfg
 
 import (
+	"errors"
+
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/functions"
 	"github.com/netdata/go.d.plugin/agent/module"
@@ -14,6 +16,9 @@ type Config struct {
 	Functions            FunctionRegistry
 	Modules              module.Registry
 	ModuleConfigDefaults confgroup.Registry
+	PluginConfig         []byte // the plugin configuration (YAML)
+	PluginConfigSchema   string // the plugin configuration JSON schema
+	Store                *Store // persists configuration changes, optional
 }
 
 type NetdataDyncfgAPI interface {
@@ -31,5 +36,14 @@ type FunctionRegistry interface {
 }
 
 func validateConfig(cfg Config) error {
+	if cfg.API == nil {
+		return errors.New("dyncfg API not set")
+	}
+	if cfg.Functions == nil {
+		return errors.New("functions registry not set")
+	}
+	if len(cfg.Modules) == 0 {
+		return errors.New("no modules")
+	}
 	return nil
 }
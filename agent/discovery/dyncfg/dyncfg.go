@@ -5,6 +5,7 @@ package dyncfg
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -13,6 +14,8 @@ import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/functions"
 	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/secrets"
+	"github.com/netdata/go.d.plugin/agent/validate"
 	"github.com/netdata/go.d.plugin/logger"
 
 	"gopkg.in/yaml.v2"
@@ -20,11 +23,37 @@ import (
 
 const dynCfg = "dyncfg"
 
+const moduleConfigSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "go.d module defaults schema.",
+  "type": "object",
+  "properties": {
+    "update_every": {
+      "type": "integer",
+      "minimum": 0
+    },
+    "autodetection_retry": {
+      "type": "integer",
+      "minimum": 0
+    },
+    "priority": {
+      "type": "integer",
+      "minimum": 0
+    }
+  },
+  "additionalProperties": false
+}`
+
 func NewDiscovery(cfg Config) (*Discovery, error) {
 	if err := validateConfig(cfg); err != nil {
 		return nil, err
 	}
 
+	defaults := confgroup.Registry{}
+	for name, def := range cfg.ModuleConfigDefaults {
+		defaults.Register(name, def)
+	}
+
 	mgr := &Discovery{
 		Logger: logger.New().With(
 			slog.String("component", "discovery dyncfg"),
@@ -32,9 +61,14 @@ func NewDiscovery(cfg Config) (*Discovery, error) {
 		Plugin:               cfg.Plugin,
 		API:                  cfg.API,
 		Modules:              cfg.Modules,
-		ModuleConfigDefaults: nil,
+		ModuleConfigDefaults: defaults,
+		Store:                cfg.Store,
+		pluginConfig:         cfg.PluginConfig,
+		pluginConfigSchema:   cfg.PluginConfigSchema,
+		validator:            validate.New(cfg.Modules),
 		mux:                  &sync.Mutex{},
 		configs:              make(map[string]confgroup.Config),
+		userConfigs:          make(map[string]confgroup.Config),
 	}
 
 	mgr.registerFunctions(cfg.Functions)
@@ -49,11 +83,18 @@ type Discovery struct {
 	API                  NetdataDyncfgAPI
 	Modules              module.Registry
 	ModuleConfigDefaults confgroup.Registry
+	Store                *Store
 
 	in chan<- []*confgroup.Group
 
-	mux     *sync.Mutex
-	configs map[string]confgroup.Config
+	pluginConfig       []byte
+	pluginConfigSchema string
+	validator          *validate.Validator
+
+	mux         *sync.Mutex
+	runCtx      context.Context             // set while Run is running
+	configs     map[string]confgroup.Config // registered by the job manager
+	userConfigs map[string]confgroup.Config // created via dyncfg, as provided by the user (module defaults not applied)
 }
 
 func (d *Discovery) String() string {
@@ -70,22 +111,105 @@ func (d *Discovery) Run(ctx context.Context, in chan<- []*confgroup.Group) {
 
 	d.in = in
 
-	if reload, ok := ctx.Value("reload").(bool); ok && reload {
-		_ = d.API.DynCfgReset()
-	}
-
 	_ = d.API.DynCfgEnable(d.Plugin)
 
-	for k := range d.Modules {
+	d.mux.Lock()
+	d.runCtx = ctx
+	modules := d.Modules
+	d.mux.Unlock()
+
+	for k := range modules {
 		_ = d.API.DyncCfgRegisterModule(k)
 	}
 
+	d.restoreJobs(ctx, modules)
+
 	<-ctx.Done()
+
+	d.mux.Lock()
+	d.runCtx = nil
+	d.mux.Unlock()
+}
+
+// SetModules replaces the enabled modules. The newly enabled modules get the given defaults (unless they are set
+// via dyncfg), they are registered with Netdata and their jobs created via dyncfg are restored.
+// There is no way to unregister a module, the calls of the removed modules functions are rejected.
+func (d *Discovery) SetModules(modules module.Registry, defaults confgroup.Registry) {
+	added := module.Registry{}
+
+	d.mux.Lock()
+	for name, creator := range modules {
+		if _, ok := d.Modules[name]; ok {
+			continue
+		}
+		added[name] = creator
+		if _, ok := d.ModuleConfigDefaults.Lookup(name); !ok {
+			if def, ok := defaults.Lookup(name); ok {
+				d.ModuleConfigDefaults.Register(name, def)
+			}
+		}
+	}
+	d.Modules = modules
+	d.validator = validate.New(modules)
+	ctx := d.runCtx
+	d.mux.Unlock()
+
+	// not running yet, Run registers the modules
+	if ctx == nil || len(added) == 0 {
+		return
+	}
+
+	for name := range added {
+		_ = d.API.DyncCfgRegisterModule(name)
+	}
+
+	d.restoreJobs(ctx, added)
+}
+
+func (d *Discovery) lookupModule(name string) (module.Creator, bool) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	creator, ok := d.Modules[name]
+	return creator, ok
+}
+
+// restoreJobs sends the jobs of the given modules created via dyncfg before the restart (or before the modules were disabled).
+func (d *Discovery) restoreJobs(ctx context.Context, modules module.Registry) {
+	cfgs, err := d.Store.Jobs()
+	if err != nil {
+		d.Warningf("couldn't load persisted jobs: %v", err)
+	}
+
+	var groups []*confgroup.Group
+	for _, cfg := range cfgs {
+		if _, ok := modules[cfg.Module()]; !ok {
+			if _, ok := d.lookupModule(cfg.Module()); !ok {
+				d.Infof("skipping persisted job %s[%s]: module is not enabled", cfg.Module(), cfg.Name())
+			}
+			continue
+		}
+		d.mux.Lock()
+		d.userConfigs[cfg.Module()+"_"+cfg.Name()] = cfg
+		d.mux.Unlock()
+		groups = append(groups, d.newJobGroup(cfg))
+	}
+
+	if len(groups) == 0 {
+		return
+	}
+
+	d.Infof("restoring %d persisted jobs", len(groups))
+
+	select {
+	case <-ctx.Done():
+	case d.in <- groups:
+	}
 }
 
 func (d *Discovery) registerFunctions(r FunctionRegistry) {
 	r.Register("get_plugin_config", d.getPluginConfig)
-	r.Register("get_plugin_config_schema", d.getModuleConfigSchema)
+	r.Register("get_plugin_config_schema", d.getPluginConfigSchema)
 	r.Register("set_plugin_config", d.setPluginConfig)
 
 	r.Register("get_module_config", d.getModuleConfig)
@@ -98,13 +222,161 @@ func (d *Discovery) registerFunctions(r FunctionRegistry) {
 	r.Register("delete_job", d.deleteJobName)
 }
 
-func (d *Discovery) getPluginConfig(fn functions.Function)       { d.notImplemented(fn) }
-func (d *Discovery) getPluginConfigSchema(fn functions.Function) { d.notImplemented(fn) }
-func (d *Discovery) setPluginConfig(fn functions.Function)       { d.notImplemented(fn) }
+func (d *Discovery) getPluginConfig(fn functions.Function) {
+	if err := d.verifyFn(fn, 0); err != nil {
+		d.apiReject(fn, err.Error())
+		return
+	}
+
+	d.mux.Lock()
+	bs := d.pluginConfig
+	d.mux.Unlock()
+
+	d.apiSuccessYAML(fn, string(bs))
+}
+
+func (d *Discovery) getPluginConfigSchema(fn functions.Function) {
+	if err := d.verifyFn(fn, 0); err != nil {
+		d.apiReject(fn, err.Error())
+		return
+	}
+
+	if d.pluginConfigSchema == "" {
+		d.notImplemented(fn)
+		return
+	}
+
+	d.apiSuccessJSON(fn, d.pluginConfigSchema)
+}
+
+func (d *Discovery) setPluginConfig(fn functions.Function) {
+	if err := d.verifyFn(fn, 0); err != nil {
+		d.apiReject(fn, err.Error())
+		return
+	}
+
+	var cfg map[string]any
+	if err := yaml.Unmarshal(fn.Payload, &cfg); err != nil {
+		d.apiReject(fn, jsonErrorf("invalid plugin configuration: %v", err))
+		return
+	}
+
+	if d.pluginConfigSchema != "" {
+		errs, err := validate.ValidateSchema(d.pluginConfigSchema, cfg)
+		if err != nil {
+			d.apiReject(fn, jsonErrorf("couldn't parse plugin configuration schema: %v", err))
+			return
+		}
+		if len(errs) > 0 {
+			d.apiReject(fn, jsonErrorf("invalid plugin configuration: %s", strings.Join(errs, "; ")))
+			return
+		}
+	}
 
-func (d *Discovery) getModuleConfig(fn functions.Function)       { d.notImplemented(fn) }
-func (d *Discovery) getModuleConfigSchema(fn functions.Function) { d.notImplemented(fn) }
-func (d *Discovery) setModuleConfig(fn functions.Function)       { d.notImplemented(fn) }
+	if err := d.Store.SavePluginConfig(fn.Payload); err != nil {
+		d.apiReject(fn, jsonErrorf("couldn't persist plugin configuration: %v", err))
+		return
+	}
+
+	d.mux.Lock()
+	d.pluginConfig = fn.Payload
+	d.mux.Unlock()
+
+	d.Info("plugin configuration changed, it will be applied after the plugin restart")
+
+	d.apiSuccessJSON(fn, "")
+}
+
+func (d *Discovery) getModuleConfig(fn functions.Function) {
+	if err := d.verifyFn(fn, 1); err != nil {
+		d.apiReject(fn, err.Error())
+		return
+	}
+
+	modName := fn.Args[0]
+
+	if _, ok := d.lookupModule(modName); !ok {
+		d.apiReject(fn, jsonErrorf("module %s is not registered", modName))
+		return
+	}
+
+	d.mux.Lock()
+	def, _ := d.ModuleConfigDefaults.Lookup(modName)
+	d.mux.Unlock()
+
+	bs, err := yaml.Marshal(def)
+	if err != nil {
+		d.apiReject(fn, jsonErrorf("%v", err))
+		return
+	}
+
+	d.apiSuccessYAML(fn, string(bs))
+}
+
+func (d *Discovery) getModuleConfigSchema(fn functions.Function) {
+	if err := d.verifyFn(fn, 1); err != nil {
+		d.apiReject(fn, err.Error())
+		return
+	}
+
+	if _, ok := d.lookupModule(fn.Args[0]); !ok {
+		d.apiReject(fn, jsonErrorf("module %s is not registered", fn.Args[0]))
+		return
+	}
+
+	d.apiSuccessJSON(fn, moduleConfigSchema)
+}
+
+// setModuleConfig changes the module defaults. The jobs created via dyncfg are restarted with the new defaults,
+// other jobs get them on the next plugin restart.
+func (d *Discovery) setModuleConfig(fn functions.Function) {
+	if err := d.verifyFn(fn, 1); err != nil {
+		d.apiReject(fn, err.Error())
+		return
+	}
+
+	modName := fn.Args[0]
+
+	if _, ok := d.lookupModule(modName); !ok {
+		d.apiReject(fn, jsonErrorf("module %s is not registered", modName))
+		return
+	}
+
+	d.mux.Lock()
+	def, _ := d.ModuleConfigDefaults.Lookup(modName)
+	d.mux.Unlock()
+
+	if err := yaml.UnmarshalStrict(fn.Payload, &def); err != nil {
+		d.apiReject(fn, jsonErrorf("invalid module configuration: %v", err))
+		return
+	}
+	if def.UpdateEvery < 0 || def.AutoDetectionRetry < 0 || def.Priority < 0 {
+		d.apiReject(fn, jsonErrorf("invalid module configuration: negative values are not allowed"))
+		return
+	}
+
+	if err := d.Store.SaveModuleDefaults(modName, def); err != nil {
+		d.apiReject(fn, jsonErrorf("couldn't persist module configuration: %v", err))
+		return
+	}
+
+	var groups []*confgroup.Group
+
+	d.mux.Lock()
+	d.ModuleConfigDefaults.Register(modName, def)
+	for _, cfg := range d.userConfigs {
+		if cfg.Module() == modName {
+			groups = append(groups, d.newJobGroupLocked(cfg))
+		}
+	}
+	d.mux.Unlock()
+
+	if len(groups) > 0 {
+		d.in <- groups
+	}
+
+	d.apiSuccessJSON(fn, "")
+}
 
 func (d *Discovery) getJobConfig(fn functions.Function) {
 	if err := d.verifyFn(fn, 2); err != nil {
@@ -131,7 +403,7 @@ func (d *Discovery) getJobConfigSchema(fn functions.Function) {
 
 	name := fn.Args[0]
 
-	v, ok := d.Modules[name]
+	v, ok := d.lookupModule(name)
 	if !ok {
 		msg := jsonErrorf("module %s is not registered", name)
 		d.apiReject(fn, msg)
@@ -147,29 +419,59 @@ func (d *Discovery) setJobConfig(fn functions.Function) {
 		return
 	}
 
-	var cfg confgroup.Config
-	if err := yaml.NewDecoder(bytes.NewBuffer(fn.Payload)).Decode(&cfg); err != nil {
-		d.apiReject(fn, err.Error())
+	modName, jobName := fn.Args[0], fn.Args[1]
+
+	if _, ok := d.lookupModule(modName); !ok {
+		d.apiReject(fn, jsonErrorf("module %s is not registered", modName))
+		return
+	}
+	if cfg, ok := d.getConfig(modName + "_" + jobName); ok && cfg.Provider() != dynCfg {
+		d.apiReject(fn, jsonErrorf("module '%s' job '%s': can't change non Dyncfg job", modName, jobName))
 		return
 	}
 
-	modName, jobName := fn.Args[0], fn.Args[1]
-	def, _ := d.ModuleConfigDefaults.Lookup(modName)
-	src := source(modName, jobName)
+	var payload confgroup.Config
+	if err := yaml.NewDecoder(bytes.NewBuffer(fn.Payload)).Decode(&payload); err != nil {
+		d.apiReject(fn, jsonErrorf("%v", err))
+		return
+	}
 
-	cfg.SetProvider(dynCfg)
-	cfg.SetSource(src)
+	// the payload can be a config returned by get_job_config, it contains internal keys
+	cfg := confgroup.Config{}
+	for k, v := range payload {
+		if !strings.HasPrefix(k, "__") {
+			cfg[k] = v
+		}
+	}
 	cfg.SetModule(modName)
 	cfg.SetName(jobName)
-	cfg.Apply(def)
 
-	d.in <- []*confgroup.Group{
-		{
-			Configs: []confgroup.Config{cfg},
-			Source:  src,
-		},
+	// the functions callers must not be able to read the host files, run commands or read the environment
+	if refs := secrets.ConfigReferences(cfg); len(refs) > 0 {
+		d.apiReject(fn, jsonErrorf("invalid job configuration: secret references are not allowed in dyncfg jobs (%s)", strings.Join(refs, ", ")))
+		return
 	}
 
+	group := d.newJobGroup(cfg)
+	d.mux.Lock()
+	validator := d.validator
+	d.mux.Unlock()
+	if errs := validator.ValidateJob(group.Configs[0]); len(errs) > 0 {
+		d.apiReject(fn, jsonErrorf("invalid job configuration: %s", strings.Join(errs, "; ")))
+		return
+	}
+
+	if err := d.Store.SaveJob(cfg); err != nil {
+		d.apiReject(fn, jsonErrorf("couldn't persist job configuration: %v", err))
+		return
+	}
+
+	d.mux.Lock()
+	d.userConfigs[modName+"_"+jobName] = cfg
+	d.mux.Unlock()
+
+	d.in <- []*confgroup.Group{group}
+
 	d.apiSuccessJSON(fn, "")
 }
 
@@ -191,6 +493,15 @@ func (d *Discovery) deleteJobName(fn functions.Function) {
 		return
 	}
 
+	if err := d.Store.RemoveJob(modName, jobName); err != nil {
+		d.apiReject(fn, jsonErrorf("couldn't remove persisted job configuration: %v", err))
+		return
+	}
+
+	d.mux.Lock()
+	delete(d.userConfigs, modName+"_"+jobName)
+	d.mux.Unlock()
+
 	d.in <- []*confgroup.Group{
 		{
 			Configs: []confgroup.Config{},
@@ -201,16 +512,59 @@ func (d *Discovery) deleteJobName(fn functions.Function) {
 	d.apiSuccessJSON(fn, "")
 }
 
+func (d *Discovery) newJobGroup(userCfg confgroup.Config) *confgroup.Group {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return d.newJobGroupLocked(userCfg)
+}
+
+// newJobGroupLocked creates a job config group from the user provided config, d.mux must be held.
+func (d *Discovery) newJobGroupLocked(userCfg confgroup.Config) *confgroup.Group {
+	modName, jobName := userCfg.Module(), userCfg.Name()
+	def, _ := d.ModuleConfigDefaults.Lookup(modName)
+	src := source(modName, jobName)
+
+	cfg := make(confgroup.Config, len(userCfg)+2)
+	for k, v := range userCfg {
+		cfg[k] = v
+	}
+	cfg.SetProvider(dynCfg)
+	cfg.SetSource(src)
+	cfg.Apply(def)
+
+	return &confgroup.Group{
+		Configs: []confgroup.Config{cfg},
+		Source:  src,
+	}
+}
+
 func (d *Discovery) apiSuccessJSON(fn functions.Function, payload string) {
-	_ = d.API.FunctionResultSuccess(fn.UID, "application/json", payload)
+	if d.canRespond(fn) {
+		_ = d.API.FunctionResultSuccess(fn.UID, "application/json", payload)
+	}
 }
 
 func (d *Discovery) apiSuccessYAML(fn functions.Function, payload string) {
-	_ = d.API.FunctionResultSuccess(fn.UID, "application/x-yaml", payload)
+	if d.canRespond(fn) {
+		_ = d.API.FunctionResultSuccess(fn.UID, "application/x-yaml", payload)
+	}
 }
 
 func (d *Discovery) apiReject(fn functions.Function, msg string) {
-	_ = d.API.FunctionResultReject(fn.UID, "application/json", msg)
+	if d.canRespond(fn) {
+		_ = d.API.FunctionResultReject(fn.UID, "application/json", msg)
+	}
+}
+
+// canRespond reports whether the function result can be sent, the functions manager responds to the timed out
+// and cancelled calls.
+func (d *Discovery) canRespond(fn functions.Function) bool {
+	if err := fn.Context().Err(); err != nil {
+		d.Debugf("not responding to '%s' (transaction '%s'): %v", fn.Name, fn.UID, err)
+		return false
+	}
+	return fn.TryRespond()
 }
 
 func (d *Discovery) notImplemented(fn functions.Function) {
@@ -237,7 +591,9 @@ func jsonErrorf(format string, a ...any) string {
 	msg := fmt.Sprintf(format, a...)
 	msg = strings.ReplaceAll(msg, "\n", " ")
 
-	return fmt.Sprintf(`{ "error": "%s" }`+"\n", msg)
+	bs, _ := json.Marshal(msg)
+
+	return fmt.Sprintf(`{ "error": %s }`+"\n", bs)
 }
 
 func source(modName, jobName string) string {
@@ -130,6 +130,222 @@ func TestDiscovery_UpdateStatus(t *testing.T) {
 
 }
 
+func TestDiscovery_JobConfigFunctions(t *testing.T) {
+	var mock mockApi
+	store := NewStore(t.TempDir())
+	d := prepareDiscovery(t, &mock, store)
+	in := make(chan []*confgroup.Group, 10)
+	d.in = in
+
+	// create
+	d.setJobConfig(prepareFunction("set_job_config", "update_every: 5\n", "module1", "job1"))
+	require.Equal(t, 1, mock.callsFunctionResultSuccess, mock.lastResult)
+
+	groups := <-in
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Configs, 1)
+	cfg := groups[0].Configs[0]
+	assert.Equal(t, "dyncfg/module1/job1", groups[0].Source)
+	assert.Equal(t, dynCfg, cfg.Provider())
+	assert.Equal(t, 5, cfg.UpdateEvery())
+	assert.Equal(t, 10, cfg.AutoDetectionRetry())
+
+	stored, err := store.Jobs()
+	require.NoError(t, err)
+	assert.Equal(t, []confgroup.Config{{"module": "module1", "name": "job1", "update_every": 5}}, stored)
+
+	// invalid config is rejected
+	d.setJobConfig(prepareFunction("set_job_config", "unknown_key: 1\n", "module1", "job2"))
+	assert.Equal(t, 1, mock.callsFunctionResultReject)
+	assert.Contains(t, mock.lastResult, "unknown key 'unknown_key'")
+
+	// secret references are rejected
+	d.setJobConfig(prepareFunction("set_job_config", "labels:\n  token: ${cmd:/usr/bin/id}\n", "module1", "job2"))
+	assert.Equal(t, 2, mock.callsFunctionResultReject)
+	assert.Contains(t, mock.lastResult, "secret references are not allowed")
+
+	// not registered module is rejected
+	d.setJobConfig(prepareFunction("set_job_config", "update_every: 5\n", "module3", "job1"))
+	assert.Equal(t, 3, mock.callsFunctionResultReject)
+
+	// non dyncfg job can't be changed, they are registered with the hash in the name
+	stock := prepareConfig("__provider__", "file reader", "module", "module1", "name", "stock")
+	d.Register(stock)
+	d.setJobConfig(prepareFunction("set_job_config", "update_every: 5\n", "module1", stock.NameWithHash()))
+	assert.Equal(t, 4, mock.callsFunctionResultReject)
+
+	// get
+	d.Register(cfg)
+	d.getJobConfig(prepareFunction("get_job_config", "", "module1", "job1"))
+	assert.Equal(t, 2, mock.callsFunctionResultSuccess)
+	assert.Contains(t, mock.lastResult, "update_every: 5")
+
+	// delete
+	d.deleteJobName(prepareFunction("delete_job", "", "module1", "job1"))
+	assert.Equal(t, 3, mock.callsFunctionResultSuccess)
+
+	groups = <-in
+	require.Len(t, groups, 1)
+	assert.Equal(t, "dyncfg/module1/job1", groups[0].Source)
+	assert.Empty(t, groups[0].Configs)
+
+	stored, err = store.Jobs()
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+func TestDiscovery_getJobConfig_RedactsSecrets(t *testing.T) {
+	t.Setenv("TEST_DYNCFG_PASSWORD", "dyncfg-secret-password")
+	var mock mockApi
+	d := prepareDiscovery(t, &mock, nil)
+
+	cfg := prepareConfig(
+		"__provider__", "file reader",
+		"module", "module1",
+		"name", "stock",
+		"password", "${env:TEST_DYNCFG_PASSWORD}",
+	)
+	d.Register(cfg)
+
+	d.getJobConfig(prepareFunction("get_job_config", "", "module1", cfg.NameWithHash()))
+
+	assert.Equal(t, 1, mock.callsFunctionResultSuccess, mock.lastResult)
+	assert.Contains(t, mock.lastResult, "password: ${env:TEST_DYNCFG_PASSWORD}")
+	assert.NotContains(t, mock.lastResult, "dyncfg-secret-password")
+}
+
+func TestDiscovery_ModuleConfigFunctions(t *testing.T) {
+	var mock mockApi
+	store := NewStore(t.TempDir())
+	d := prepareDiscovery(t, &mock, store)
+	in := make(chan []*confgroup.Group, 10)
+	d.in = in
+
+	d.setJobConfig(prepareFunction("set_job_config", "name: job1\n", "module1", "job1"))
+	<-in
+
+	d.getModuleConfig(prepareFunction("get_module_config", "", "module1"))
+	assert.Equal(t, "update_every: 1\nautodetection_retry: 10\npriority: 70000\n", mock.lastResult)
+
+	// the dyncfg jobs are re-created with the new defaults
+	d.setModuleConfig(prepareFunction("set_module_config", "autodetection_retry: 30\n", "module1"))
+	assert.Equal(t, 0, mock.callsFunctionResultReject, mock.lastResult)
+
+	groups := <-in
+	require.Len(t, groups, 1)
+	assert.Equal(t, 30, groups[0].Configs[0].AutoDetectionRetry())
+	assert.Equal(t, 1, groups[0].Configs[0].UpdateEvery())
+
+	defaults, err := store.ModuleDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]confgroup.Default{"module1": {UpdateEvery: 1, AutoDetectionRetry: 30, Priority: 70000}}, defaults)
+
+	d.setModuleConfig(prepareFunction("set_module_config", "unknown_key: 1\n", "module1"))
+	assert.Equal(t, 1, mock.callsFunctionResultReject)
+
+	d.setModuleConfig(prepareFunction("set_module_config", "update_every: -1\n", "module1"))
+	assert.Equal(t, 2, mock.callsFunctionResultReject)
+}
+
+func TestDiscovery_PluginConfigFunctions(t *testing.T) {
+	var mock mockApi
+	store := NewStore(t.TempDir())
+	d := prepareDiscovery(t, &mock, store)
+
+	d.getPluginConfig(prepareFunction("get_plugin_config", ""))
+	assert.Equal(t, "enabled: true\n", mock.lastResult)
+
+	d.getPluginConfigSchema(prepareFunction("get_plugin_config_schema", ""))
+	assert.Equal(t, d.pluginConfigSchema, mock.lastResult)
+
+	d.setPluginConfig(prepareFunction("set_plugin_config", "enabled: maybe\n"))
+	assert.Equal(t, 1, mock.callsFunctionResultReject)
+
+	d.setPluginConfig(prepareFunction("set_plugin_config", "enabled: false\n"))
+	assert.Equal(t, 1, mock.callsFunctionResultReject, mock.lastResult)
+
+	bs, err := store.PluginConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "enabled: false\n", string(bs))
+
+	d.getPluginConfig(prepareFunction("get_plugin_config", ""))
+	assert.Equal(t, "enabled: false\n", mock.lastResult)
+}
+
+func TestDiscovery_Run_RestoresJobs(t *testing.T) {
+	store := NewStore(t.TempDir())
+	require.NoError(t, store.SaveJob(prepareConfig("module", "module1", "name", "job1")))
+	require.NoError(t, store.SaveJob(prepareConfig("module", "not_enabled", "name", "job1")))
+
+	var mock mockApi
+	d := prepareDiscovery(t, &mock, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan []*confgroup.Group)
+	go d.Run(ctx, in)
+
+	select {
+	case groups := <-in:
+		require.Len(t, groups, 1)
+		assert.Equal(t, "dyncfg/module1/job1", groups[0].Source)
+		assert.Equal(t, dynCfg, groups[0].Configs[0].Provider())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for restored jobs")
+	}
+}
+
+func TestDiscovery_SetModules(t *testing.T) {
+	store := NewStore(t.TempDir())
+	require.NoError(t, store.SaveJob(prepareConfig("module", "module1", "name", "job1")))
+	require.NoError(t, store.SaveJob(prepareConfig("module", "module3", "name", "job1")))
+
+	var mock mockApi
+	d := prepareDiscovery(t, &mock, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan []*confgroup.Group)
+	go d.Run(ctx, in)
+
+	select {
+	case groups := <-in:
+		require.Len(t, groups, 1)
+		assert.Equal(t, "dyncfg/module1/job1", groups[0].Source)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for restored jobs")
+	}
+	assert.Equal(t, 2, mock.callsDyncCfgRegisterModule)
+
+	modules := module.Registry{
+		"module1": module.Creator{Create: func() module.Module { return &module.MockModule{} }},
+		"module3": module.Creator{Create: func() module.Module { return &module.MockModule{} }},
+	}
+	defaults := confgroup.Registry{
+		"module1": confgroup.Default{UpdateEvery: 5},
+		"module3": confgroup.Default{UpdateEvery: 3},
+	}
+	go d.SetModules(modules, defaults)
+
+	select {
+	case groups := <-in:
+		require.Len(t, groups, 1)
+		assert.Equal(t, "dyncfg/module3/job1", groups[0].Source)
+		assert.Equal(t, 3, groups[0].Configs[0].UpdateEvery())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the enabled module restored jobs")
+	}
+	assert.Equal(t, 3, mock.callsDyncCfgRegisterModule)
+
+	def, _ := d.ModuleConfigDefaults.Lookup("module1")
+	assert.Equal(t, 1, def.UpdateEvery, "the existing module defaults are kept")
+
+	d.getJobConfigSchema(prepareFunction("get_job_config_schema", "", "module2"))
+	assert.Equal(t, 1, mock.callsFunctionResultReject, "the removed module is not registered")
+}
+
 func TestDiscovery_Run(t *testing.T) {
 	tests := map[string]struct {
 		wantApiStats *mockApi
@@ -190,6 +406,8 @@ type mockApi struct {
 	callsFunctionResultReject  int
 
 	callsRegister int
+
+	lastResult string
 }
 
 func (m *mockApi) Register(string, func(functions.Function)) {
@@ -220,16 +438,42 @@ func (m *mockApi) DynCfgReportJobStatus(_, _, _, _ string) error {
 	return nil
 }
 
-func (m *mockApi) FunctionResultSuccess(_, _, _ string) error {
+func (m *mockApi) FunctionResultSuccess(_, _, payload string) error {
 	m.callsFunctionResultSuccess++
+	m.lastResult = payload
 	return nil
 }
 
-func (m *mockApi) FunctionResultReject(_, _, _ string) error {
+func (m *mockApi) FunctionResultReject(_, _, payload string) error {
 	m.callsFunctionResultReject++
+	m.lastResult = payload
 	return nil
 }
 
+func prepareDiscovery(t *testing.T, mock *mockApi, store *Store) *Discovery {
+	d, err := NewDiscovery(Config{
+		Plugin:    "test",
+		API:       mock,
+		Functions: mock,
+		Modules: module.Registry{
+			"module1": module.Creator{Create: func() module.Module { return &module.MockModule{} }},
+			"module2": module.Creator{Create: func() module.Module { return &module.MockModule{} }},
+		},
+		ModuleConfigDefaults: confgroup.Registry{
+			"module1": confgroup.Default{UpdateEvery: 1, AutoDetectionRetry: 10, Priority: 70000},
+		},
+		PluginConfig:       []byte("enabled: true\n"),
+		PluginConfigSchema: `{"type": "object", "properties": {"enabled": {"type": "boolean"}}}`,
+		Store:              store,
+	})
+	require.NoError(t, err)
+	return d
+}
+
+func prepareFunction(name, payload string, args ...string) functions.Function {
+	return functions.Function{UID: "uid", Name: name, Args: args, Payload: []byte(payload)}
+}
+
 func prepareConfig(values ...string) confgroup.Config {
 	cfg := confgroup.Config{}
 	for i := 1; i < len(values); i += 2 {
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package dyncfg
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	storePluginFile = "plugin.conf"
+	storeModulesDir = "modules"
+	storeJobsDir    = "jobs"
+	storeFileExt    = ".conf"
+)
+
+// NewStore creates a new Store. Nothing is persisted if the dir is empty.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Store persists configuration changes made via dyncfg functions. Layout:
+//
+//	<dir>/plugin.conf                 - the plugin configuration
+//	<dir>/modules/<module>.conf       - the module defaults
+//	<dir>/jobs/<module>/<job>.conf    - the jobs configurations
+type Store struct {
+	dir string
+}
+
+func (s *Store) enabled() bool {
+	return s != nil && s.dir != ""
+}
+
+// PluginConfig returns the persisted plugin configuration, nil if there is none.
+func (s *Store) PluginConfig() ([]byte, error) {
+	if !s.enabled() {
+		return nil, nil
+	}
+	bs, err := os.ReadFile(filepath.Join(s.dir, storePluginFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return bs, err
+}
+
+// SavePluginConfig persists the plugin configuration.
+func (s *Store) SavePluginConfig(bs []byte) error {
+	if !s.enabled() {
+		return nil
+	}
+	return writeFileAtomic(filepath.Join(s.dir, storePluginFile), bs)
+}
+
+// ModuleDefaults returns the persisted modules defaults.
+func (s *Store) ModuleDefaults() (map[string]confgroup.Default, error) {
+	defaults := make(map[string]confgroup.Default)
+	if !s.enabled() {
+		return defaults, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, storeModulesDir, "*"+storeFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range files {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var def confgroup.Default
+		if err := yaml.Unmarshal(bs, &def); err != nil {
+			return nil, fmt.Errorf("'%s': %v", path, err)
+		}
+		defaults[unescapeName(strings.TrimSuffix(filepath.Base(path), storeFileExt))] = def
+	}
+
+	return defaults, nil
+}
+
+// SaveModuleDefaults persists the module defaults.
+func (s *Store) SaveModuleDefaults(moduleName string, def confgroup.Default) error {
+	if !s.enabled() {
+		return nil
+	}
+	bs, err := yaml.Marshal(def)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, storeModulesDir, escapeName(moduleName)+storeFileExt), bs)
+}
+
+// Jobs returns the persisted jobs configurations.
+func (s *Store) Jobs() ([]confgroup.Config, error) {
+	if !s.enabled() {
+		return nil, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, storeJobsDir, "*", "*"+storeFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var cfgs []confgroup.Config
+	for _, path := range files {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var cfg confgroup.Config
+		if err := yaml.Unmarshal(bs, &cfg); err != nil {
+			return nil, fmt.Errorf("'%s': %v", path, err)
+		}
+		if cfg == nil {
+			continue
+		}
+		cfg.SetModule(unescapeName(filepath.Base(filepath.Dir(path))))
+		cfg.SetName(unescapeName(strings.TrimSuffix(filepath.Base(path), storeFileExt)))
+		cfgs = append(cfgs, cfg)
+	}
+
+	return cfgs, nil
+}
+
+// SaveJob persists the job configuration.
+func (s *Store) SaveJob(cfg confgroup.Config) error {
+	if !s.enabled() {
+		return nil
+	}
+	bs, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.jobPath(cfg.Module(), cfg.Name()), bs)
+}
+
+// RemoveJob removes the persisted job configuration.
+func (s *Store) RemoveJob(moduleName, jobName string) error {
+	if !s.enabled() {
+		return nil
+	}
+	err := os.Remove(s.jobPath(moduleName, jobName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) jobPath(moduleName, jobName string) string {
+	return filepath.Join(s.dir, storeJobsDir, escapeName(moduleName), escapeName(jobName)+storeFileExt)
+}
+
+// writeFileAtomic writes the data to a temporary file and renames it,
+// the file is either fully written or not changed at all.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// escapeName makes a module or job name safe to use as a file name.
+func escapeName(name string) string {
+	return url.PathEscape(name)
+}
+
+func unescapeName(name string) string {
+	if v, err := url.PathUnescape(name); err == nil {
+		return v
+	}
+	return name
+}
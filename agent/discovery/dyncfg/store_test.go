@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package dyncfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Jobs(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	require.NoError(t, s.SaveJob(prepareConfig("module", "module1", "name", "job1", "url", "http://127.0.0.1")))
+	require.NoError(t, s.SaveJob(prepareConfig("module", "module1", "name", "job/2")))
+	require.NoError(t, s.SaveJob(prepareConfig("module", "module2", "name", "job1")))
+
+	cfgs, err := s.Jobs()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []confgroup.Config{
+		prepareConfig("module", "module1", "name", "job1", "url", "http://127.0.0.1"),
+		prepareConfig("module", "module1", "name", "job/2"),
+		prepareConfig("module", "module2", "name", "job1"),
+	}, cfgs)
+
+	require.NoError(t, s.RemoveJob("module1", "job/2"))
+	require.NoError(t, s.RemoveJob("module1", "not_exist"))
+
+	cfgs, err = s.Jobs()
+	require.NoError(t, err)
+	assert.Len(t, cfgs, 2)
+}
+
+func TestStore_ModuleDefaults(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	def := confgroup.Default{UpdateEvery: 5, AutoDetectionRetry: 10, Priority: 1000}
+	require.NoError(t, s.SaveModuleDefaults("module1", def))
+
+	defaults, err := s.ModuleDefaults()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]confgroup.Default{"module1": def}, defaults)
+}
+
+func TestStore_PluginConfig(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	bs, err := s.PluginConfig()
+	require.NoError(t, err)
+	assert.Nil(t, bs)
+
+	require.NoError(t, s.SavePluginConfig([]byte("enabled: yes\n")))
+
+	bs, err = s.PluginConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "enabled: yes\n", string(bs))
+
+	// no temporary files left
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestStore_Disabled(t *testing.T) {
+	for name, s := range map[string]*Store{"nil store": nil, "empty dir": NewStore("")} {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, s.SaveJob(prepareConfig("module", "module1", "name", "job1")))
+			assert.NoError(t, s.RemoveJob("module1", "job1"))
+			assert.NoError(t, s.SaveModuleDefaults("module1", confgroup.Default{}))
+			assert.NoError(t, s.SavePluginConfig([]byte("enabled: yes")))
+
+			cfgs, err := s.Jobs()
+			assert.NoError(t, err)
+			assert.Empty(t, cfgs)
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "file.conf")
+
+	require.NoError(t, writeFileAtomic(path, []byte("first")))
+	require.NoError(t, writeFileAtomic(path, []byte("second")))
+
+	bs, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(bs))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -21,6 +21,12 @@ const (
 	sdFormat
 )
 
+// Parse parses a static or sd format configuration file and applies the registry defaults to the jobs configurations.
+// It returns a nil group if the file is empty or the module is not in the registry.
+func Parse(reg confgroup.Registry, path string) (*confgroup.Group, error) {
+	return parse(reg, path)
+}
+
 func parse(req confgroup.Registry, path string) (*confgroup.Group, error) {
 	bs, err := os.ReadFile(path)
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
@@ -30,6 +31,8 @@ func NewManager(cfg Config) (*Manager, error) {
 		discoverers: make([]discoverer, 0),
 		mux:         &sync.RWMutex{},
 		cache:       newCache(),
+		registry:    cfg.Registry,
+		groups:      make(map[string]*confgroup.Group),
 	}
 
 	if err := mgr.registerDiscoverers(cfg); err != nil {
@@ -50,10 +53,18 @@ type Manager struct {
 	sendEvery   time.Duration
 	mux         *sync.RWMutex
 	cache       *cache
+
+	// the discoverers built from the Config (file, dummy), they are restarted on Reload
+	static     []discoverer
+	registry   confgroup.Registry
+	groups     map[string]*confgroup.Group // the last discovered group of every source
+	reloadMux  sync.Mutex
+	runCtx     context.Context
+	stopStatic func()
 }
 
 func (m *Manager) String() string {
-	return fmt.Sprintf("discovery manager: %v", m.discoverers)
+	return fmt.Sprintf("discovery manager: %v", append(slices.Clone(m.static), m.discoverers...))
 }
 
 func (m *Manager) Add(d discoverer) {
@@ -74,6 +85,11 @@ func (m *Manager) Run(ctx context.Context, in chan<- []*confgroup.Group) {
 		}(d)
 	}
 
+	m.reloadMux.Lock()
+	m.runCtx = ctx
+	m.runStatic()
+	m.reloadMux.Unlock()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -82,33 +98,124 @@ func (m *Manager) Run(ctx context.Context, in chan<- []*confgroup.Group) {
 
 	wg.Wait()
 	<-ctx.Done()
+
+	m.reloadMux.Lock()
+	m.stopStatic()
+	m.runCtx, m.stopStatic = nil, nil
+	m.reloadMux.Unlock()
+}
+
+// Reload replaces the file and dummy discoverers with the ones built from the new config and restarts them.
+// The already discovered configs of the modules that are not in the new config registry are removed,
+// the restarted discoverers rediscover the rest (unchanged configs don't affect the running jobs).
+func (m *Manager) Reload(cfg Config) error {
+	static, err := newStaticDiscoverers(cfg)
+	if err != nil {
+		return fmt.Errorf("discovery manager reload: %v", err)
+	}
+
+	m.reloadMux.Lock()
+	defer m.reloadMux.Unlock()
+
+	if m.stopStatic != nil {
+		m.stopStatic()
+	}
+
+	m.mux.Lock()
+	m.registry = cfg.Registry
+	var removed bool
+	for source, group := range m.groups {
+		if filtered, ok := filterGroup(group, m.registry); ok {
+			if len(filtered.Configs) == 0 {
+				delete(m.groups, source)
+			} else {
+				m.groups[source] = filtered
+			}
+			m.cache.update([]*confgroup.Group{filtered})
+			removed = true
+		}
+	}
+	if removed {
+		m.triggerSend()
+	}
+	m.mux.Unlock()
+
+	m.static = static
+	m.Infof("reloaded discoverers: %v", m.static)
+
+	if m.runCtx != nil {
+		m.runStatic()
+	}
+	return nil
+}
+
+// runStatic starts the static discoverers, m.reloadMux must be held.
+func (m *Manager) runStatic() {
+	ctx, cancel := context.WithCancel(m.runCtx)
+
+	var wg sync.WaitGroup
+	for _, d := range m.static {
+		wg.Add(1)
+		go func(d discoverer) {
+			defer wg.Done()
+			m.runDiscoverer(ctx, d)
+		}(d)
+	}
+
+	m.stopStatic = func() { cancel(); wg.Wait() }
 }
 
 func (m *Manager) registerDiscoverers(cfg Config) error {
+	static, err := newStaticDiscoverers(cfg)
+	if err != nil {
+		return err
+	}
+	if len(static) == 0 {
+		return errors.New("zero registered discoverers")
+	}
+	m.static = static
+
+	m.Infof("registered discoverers: %v", m.static)
+	return nil
+}
+
+func newStaticDiscoverers(cfg Config) ([]discoverer, error) {
+	var ds []discoverer
+
 	if len(cfg.File.Read) > 0 || len(cfg.File.Watch) > 0 {
 		cfg.File.Registry = cfg.Registry
 		d, err := file.NewDiscovery(cfg.File)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		m.Add(d)
+		ds = append(ds, d)
 	}
 
 	if len(cfg.Dummy.Names) > 0 {
 		cfg.Dummy.Registry = cfg.Registry
 		d, err := dummy.NewDiscovery(cfg.Dummy)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		m.Add(d)
+		ds = append(ds, d)
 	}
 
-	if len(m.discoverers) == 0 {
-		return errors.New("zero registered discoverers")
-	}
+	return ds, nil
+}
 
-	m.Infof("registered discoverers: %v", m.discoverers)
-	return nil
+// filterGroup returns a copy of the group without the configs of the modules that are not in the registry.
+// It returns false if there is nothing to remove.
+func filterGroup(group *confgroup.Group, reg confgroup.Registry) (*confgroup.Group, bool) {
+	var cfgs []confgroup.Config
+	for _, cfg := range group.Configs {
+		if _, ok := reg.Lookup(cfg.Module()); ok {
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	if len(cfgs) == len(group.Configs) {
+		return group, false
+	}
+	return &confgroup.Group{Source: group.Source, Configs: cfgs}, true
 }
 
 func (m *Manager) runDiscoverer(ctx context.Context, d discoverer) {
@@ -127,6 +234,15 @@ func (m *Manager) runDiscoverer(ctx context.Context, d discoverer) {
 				m.mux.Lock()
 				defer m.mux.Unlock()
 
+				for _, group := range groups {
+					switch {
+					case group == nil:
+					case len(group.Configs) == 0:
+						delete(m.groups, group.Source)
+					default:
+						m.groups[group.Source] = group
+					}
+				}
 				m.cache.update(groups)
 				m.triggerSend()
 			}()
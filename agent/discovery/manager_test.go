@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
 	"github.com/netdata/go.d.plugin/agent/discovery/file"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -126,6 +127,45 @@ func TestManager_Run(t *testing.T) {
 	}
 }
 
+func TestManager_Reload(t *testing.T) {
+	mgr, err := NewManager(Config{
+		Registry: confgroup.Registry{"module1": confgroup.Default{}, "module2": confgroup.Default{}},
+		Dummy:    dummy.Config{Names: []string{"module1", "module2"}},
+	})
+	require.NoError(t, err)
+	mgr.sendEvery = time.Millisecond * 100
+
+	in := make(chan []*confgroup.Group)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mgr.Run(ctx, in)
+
+	receive := func(wantSources int) map[string]int {
+		got := make(map[string]int)
+		timeout := time.After(time.Second * 5)
+		for len(got) < wantSources {
+			select {
+			case groups := <-in:
+				for _, group := range groups {
+					got[group.Source] = len(group.Configs)
+				}
+			case <-timeout:
+				return got
+			}
+		}
+		return got
+	}
+
+	assert.Equal(t, map[string]int{"module1": 1, "module2": 1}, receive(2))
+
+	require.NoError(t, mgr.Reload(Config{
+		Registry: confgroup.Registry{"module1": confgroup.Default{}, "module3": confgroup.Default{}},
+		Dummy:    dummy.Config{Names: []string{"module1", "module3"}},
+	}))
+
+	assert.Equal(t, map[string]int{"module1": 1, "module2": 0, "module3": 1}, receive(3))
+}
+
 func prepareMockDiscoverer(source string, groups, configs int) mockDiscoverer {
 	d := mockDiscoverer{}
 
@@ -149,6 +189,7 @@ func prepareManager(discoverers ...discoverer) *Manager {
 		discoverers: discoverers,
 		cache:       newCache(),
 		mux:         &sync.RWMutex{},
+		groups:      make(map[string]*confgroup.Group),
 	}
 	return mgr
 }
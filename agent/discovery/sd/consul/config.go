@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package consul
+
+import "github.com/netdata/go.d.plugin/pkg/web"
+
+type Config struct {
+	Tags string `yaml:"tags"`
+	// HTTP is the Consul HTTP API configuration. Default URL is 'http://127.0.0.1:8500'.
+	web.HTTP `yaml:",inline"`
+	ACLToken string `yaml:"acl_token"`
+	// Datacenter is the datacenter to query. Default is the datacenter of the queried agent.
+	Datacenter string `yaml:"datacenter"`
+	// Services are the names of the services to discover. Default is all the catalog services.
+	Services []string `yaml:"services"`
+	// WaitTime is the blocking queries maximum wait time. Default is 5m.
+	WaitTime web.Duration `yaml:"wait_time"`
+}
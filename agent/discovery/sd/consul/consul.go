@@ -0,0 +1,396 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+	"github.com/netdata/go.d.plugin/pkg/web"
+
+	"github.com/ilyam8/hashstructure"
+)
+
+type targetGroup struct {
+	source  string
+	targets []model.Target
+}
+
+func (g *targetGroup) Provider() string        { return "sd:consul:service" }
+func (g *targetGroup) Source() string          { return fmt.Sprintf("%s(%s)", g.Provider(), g.source) }
+func (g *targetGroup) Targets() []model.Target { return g.targets }
+
+// Target is a Consul service instance.
+type Target struct {
+	model.Base `hash:"ignore"`
+
+	hash uint64
+	tuid string
+
+	Address        string
+	Service        string
+	ServiceID      string
+	ServiceTags    []string
+	ServiceMeta    map[string]any
+	ServiceAddress string
+	ServicePort    string
+	Node           string
+	NodeAddress    string
+	Datacenter     string
+	Health         string // the worst status of the instance checks: 'passing', 'warning' or 'critical'
+}
+
+func (t *Target) Hash() uint64 { return t.hash }
+func (t *Target) TUID() string { return t.tuid }
+
+func NewDiscoverer(cfg Config) (*Discoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if cfg.URL == "" {
+		cfg.URL = "http://127.0.0.1:8500"
+	}
+	if _, err := web.NewHTTPRequest(cfg.Request); err != nil {
+		return nil, fmt.Errorf("create http request: %v", err)
+	}
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout.Duration = time.Second * 10
+	}
+	if cfg.WaitTime.Duration == 0 {
+		cfg.WaitTime.Duration = time.Minute * 5
+	}
+
+	// a blocking query response is delayed up to 'wait + wait/16'
+	client := cfg.Client
+	client.Timeout.Duration += cfg.WaitTime.Duration + cfg.WaitTime.Duration/16
+
+	httpClient, err := web.NewHTTPClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("create http client: %v", err)
+	}
+
+	d := &Discoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd consul"),
+			slog.String("url", cfg.URL),
+		),
+		request:    cfg.Request,
+		aclToken:   cfg.ACLToken,
+		datacenter: cfg.Datacenter,
+		waitTime:   cfg.WaitTime.Duration,
+		retryEvery: time.Second * 30,
+		httpClient: httpClient,
+		services:   make(map[string]bool),
+		watchers:   make(map[string]*serviceWatcher),
+	}
+	for _, name := range cfg.Services {
+		d.services[name] = true
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+type (
+	Discoverer struct {
+		*logger.Logger
+		model.Base
+
+		request    web.Request
+		aclToken   string
+		datacenter string
+		waitTime   time.Duration
+		retryEvery time.Duration
+		httpClient *http.Client
+
+		services map[string]bool // the services to discover, all if empty
+		watchers map[string]*serviceWatcher
+	}
+	serviceWatcher struct {
+		cancel context.CancelFunc
+		done   chan struct{}
+	}
+)
+
+func (d *Discoverer) String() string {
+	return "sd consul"
+}
+
+// Discover watches the catalog services using blocking queries and runs a watcher for every service.
+// A service watcher sends the service healthy and unhealthy instances as a target group.
+// The deregistered services watchers are stopped and the services empty target groups are sent.
+func (d *Discoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	d.Info("instance is started")
+	defer d.Info("instance is stopped")
+
+	defer func() {
+		for name := range d.watchers {
+			d.stopWatcher(name)
+		}
+	}()
+
+	var index uint64
+	for {
+		var services map[string][]string
+		newIndex, err := d.query(ctx, "/v1/catalog/services", index, &services)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.Warningf("%v, will retry in %s", err, d.retryEvery)
+			index = 0
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d.retryEvery):
+			}
+			continue
+		}
+		if newIndex == index {
+			continue
+		}
+		index = newIndex
+
+		d.syncWatchers(ctx, in, services)
+	}
+}
+
+func (d *Discoverer) syncWatchers(ctx context.Context, in chan<- []model.TargetGroup, services map[string][]string) {
+	for name := range services {
+		if _, ok := d.watchers[name]; ok || (len(d.services) > 0 && !d.services[name]) {
+			continue
+		}
+
+		wctx, cancel := context.WithCancel(ctx)
+		w := &serviceWatcher{cancel: cancel, done: make(chan struct{})}
+		d.watchers[name] = w
+
+		go func(name string) { defer close(w.done); d.watchService(wctx, in, name) }(name)
+	}
+
+	for name := range d.watchers {
+		if _, ok := services[name]; ok {
+			continue
+		}
+		d.stopWatcher(name)
+		send(ctx, in, &targetGroup{source: d.groupSource(name)})
+	}
+}
+
+func (d *Discoverer) stopWatcher(name string) {
+	w := d.watchers[name]
+	w.cancel()
+	<-w.done
+	delete(d.watchers, name)
+}
+
+func (d *Discoverer) watchService(ctx context.Context, in chan<- []model.TargetGroup, name string) {
+	var index, lastHash uint64
+	var sent bool
+	for {
+		var entries []serviceEntry
+		newIndex, err := d.query(ctx, "/v1/health/service/"+name, index, &entries)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.Warningf("service '%s': %v, will retry in %s", name, err, d.retryEvery)
+			index = 0
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d.retryEvery):
+			}
+			continue
+		}
+		if newIndex == index {
+			continue
+		}
+		index = newIndex
+
+		tgg := d.buildTargetGroup(name, entries)
+
+		// the index also changes on the unrelated catalog changes, the unchanged group is not sent
+		var hashes []uint64
+		for _, tgt := range tgg.Targets() {
+			hashes = append(hashes, tgt.Hash())
+		}
+		hash, _ := calcHash(hashes)
+		if sent && hash == lastHash {
+			continue
+		}
+		sent, lastHash = true, hash
+
+		send(ctx, in, tgg)
+	}
+}
+
+type serviceEntry struct {
+	Node struct {
+		Node       string
+		Address    string
+		Datacenter string
+	}
+	Service struct {
+		ID      string
+		Service string
+		Tags    []string
+		Address string
+		Port    int
+		Meta    map[string]string
+	}
+	Checks []struct {
+		Status string
+	}
+}
+
+func (d *Discoverer) buildTargetGroup(name string, entries []serviceEntry) model.TargetGroup {
+	tgg := &targetGroup{source: d.groupSource(name)}
+
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+
+		tgt := &Target{
+			tuid:           fmt.Sprintf("%s_%s", entry.Node.Node, entry.Service.ID),
+			Address:        host,
+			Service:        entry.Service.Service,
+			ServiceID:      entry.Service.ID,
+			ServiceTags:    entry.Service.Tags,
+			ServiceMeta:    mapAny(entry.Service.Meta),
+			ServiceAddress: entry.Service.Address,
+			Node:           entry.Node.Node,
+			NodeAddress:    entry.Node.Address,
+			Datacenter:     entry.Node.Datacenter,
+			Health:         "passing",
+		}
+		if entry.Service.Port != 0 {
+			tgt.ServicePort = strconv.Itoa(entry.Service.Port)
+			tgt.Address = net.JoinHostPort(host, tgt.ServicePort)
+		}
+		for _, check := range entry.Checks {
+			tgt.Health = worseHealth(tgt.Health, check.Status)
+		}
+
+		hash, err := calcHash(tgt)
+		if err != nil {
+			continue
+		}
+		tgt.hash = hash
+		tgt.Tags().Merge(d.Tags())
+
+		tgg.targets = append(tgg.targets, tgt)
+	}
+
+	return tgg
+}
+
+func (d *Discoverer) groupSource(service string) string {
+	return fmt.Sprintf("%s/%s", d.request.URL, service)
+}
+
+// query makes a (blocking if the index is not zero) query and returns the response 'X-Consul-Index'.
+func (d *Discoverer) query(ctx context.Context, urlPath string, index uint64, dst any) (uint64, error) {
+	req, err := web.NewHTTPRequest(d.request)
+	if err != nil {
+		return 0, fmt.Errorf("create http request: %v", err)
+	}
+
+	req.URL.Path = path.Join("/", req.URL.Path, urlPath)
+	q := req.URL.Query()
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", fmt.Sprintf("%ds", int(d.waitTime.Seconds())))
+	}
+	if d.datacenter != "" {
+		q.Set("dc", d.datacenter)
+	}
+	req.URL.RawQuery = q.Encode()
+	if d.aclToken != "" {
+		req.Header.Set("X-Consul-Token", d.aclToken)
+	}
+
+	resp, err := d.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("query '%s': %v", urlPath, err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("query '%s': returned HTTP status code: %d", urlPath, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return 0, fmt.Errorf("query '%s': decode response: %v", urlPath, err)
+	}
+
+	newIndex, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil || newIndex == 0 {
+		return 0, fmt.Errorf("query '%s': missing or invalid 'X-Consul-Index' header", urlPath)
+	}
+	// the index going backwards means the raft state was reset, the next query should not block
+	if newIndex < index {
+		return 0, nil
+	}
+	return newIndex, nil
+}
+
+func worseHealth(a, b string) string {
+	rank := func(s string) int {
+		switch s {
+		case "passing":
+			return 0
+		case "warning":
+			return 1
+		}
+		return 2 // 'critical' and 'maintenance'
+	}
+	if rank(b) > rank(a) {
+		if b == "maintenance" {
+			return "critical"
+		}
+		return b
+	}
+	return a
+}
+
+func send(ctx context.Context, in chan<- []model.TargetGroup, tggs ...model.TargetGroup) {
+	if len(tggs) == 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case in <- tggs:
+	}
+}
+
+func mapAny(src map[string]string) map[string]any {
+	if src == nil {
+		return nil
+	}
+	m := make(map[string]any, len(src))
+	for k, v := range src {
+		m[k] = v
+	}
+	return m
+}
+
+func calcHash(obj any) (uint64, error) {
+	return hashstructure.Hash(obj, nil)
+}
@@ -0,0 +1,381 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/pkg/web"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiscoverer(t *testing.T) {
+	tests := map[string]struct {
+		cfg         Config
+		wantURL     string
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		"default config": {
+			cfg:         Config{Tags: "consul"},
+			wantURL:     "http://127.0.0.1:8500",
+			wantTimeout: time.Second*10 + time.Minute*5 + time.Minute*5/16,
+		},
+		"custom config": {
+			cfg: Config{
+				Tags: "consul",
+				HTTP: web.HTTP{
+					Request: web.Request{URL: "http://consul:8500"},
+					Client:  web.Client{Timeout: web.Duration{Duration: time.Second}},
+				},
+				WaitTime: web.Duration{Duration: time.Second * 16},
+			},
+			wantURL:     "http://consul:8500",
+			wantTimeout: time.Second * 18,
+		},
+		"invalid tags": {
+			cfg:     Config{Tags: "-"},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := NewDiscoverer(test.cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantURL, d.request.URL)
+				assert.Equal(t, test.wantTimeout, d.httpClient.Timeout)
+			}
+		})
+	}
+}
+
+func TestDiscoverer_Discover(t *testing.T) {
+	consul := newFakeConsul(t)
+	consul.register(webEntry1)
+	consul.register(webEntry2)
+	consul.register(redisEntry)
+	consul.register(mysqlEntry)
+
+	d, err := NewDiscoverer(Config{
+		Tags:     "consul",
+		HTTP:     web.HTTP{Request: web.Request{URL: consul.srv.URL}},
+		ACLToken: "secret",
+		Services: []string{"web", "redis"},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []model.TargetGroup)
+	done := make(chan struct{})
+	go func() { defer close(done); d.Discover(ctx, in) }()
+	defer func() { cancel(); <-done }()
+
+	receive := func() []model.TargetGroup {
+		select {
+		case tggs := <-in:
+			return tggs
+		case <-time.After(time.Second * 5):
+			t.Fatal("discovery timed out")
+			return nil
+		}
+	}
+
+	webGroup := &targetGroup{source: consul.srv.URL + "/web", targets: []model.Target{
+		withHash(&Target{
+			tuid:        "node1_web-1",
+			Address:     "10.0.0.1:80",
+			Service:     "web",
+			ServiceID:   "web-1",
+			ServiceTags: []string{"nginx", "prod"},
+			ServiceMeta: map[string]any{"version": "1.25"},
+			ServicePort: "80",
+			Node:        "node1",
+			NodeAddress: "10.0.0.1",
+			Datacenter:  "dc1",
+			Health:      "passing",
+		}),
+		withHash(&Target{
+			tuid:           "node2_web-2",
+			Address:        "172.17.0.2:8080",
+			Service:        "web",
+			ServiceID:      "web-2",
+			ServiceTags:    []string{"nginx"},
+			ServiceAddress: "172.17.0.2",
+			ServicePort:    "8080",
+			Node:           "node2",
+			NodeAddress:    "10.0.0.2",
+			Datacenter:     "dc1",
+			Health:         "warning",
+		}),
+	}}
+	redisGroup := &targetGroup{source: consul.srv.URL + "/redis", targets: []model.Target{
+		withHash(&Target{
+			tuid:        "node1_redis",
+			Address:     "10.0.0.1:6379",
+			Service:     "redis",
+			ServiceID:   "redis",
+			ServicePort: "6379",
+			Node:        "node1",
+			NodeAddress: "10.0.0.1",
+			Datacenter:  "dc1",
+			Health:      "critical",
+		}),
+	}}
+
+	assert.ElementsMatch(t, []model.TargetGroup{webGroup, redisGroup}, append(receive(), receive()...))
+	assert.Equal(t, "sd:consul:service("+consul.srv.URL+"/web)", webGroup.Source())
+
+	consul.deregister("redis")
+
+	// both the redis watcher and the catalog watcher may notice the deregistration
+	for {
+		tggs := receive()
+		require.Len(t, tggs, 1)
+		require.Equal(t, redisGroup.Source(), tggs[0].Source())
+		if len(tggs[0].Targets()) == 0 {
+			break
+		}
+	}
+}
+
+func TestDiscoverer_watchService_UnchangedEntries(t *testing.T) {
+	var requests atomic.Int64
+	indexes := []string{"10", "11"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if int(n) > len(indexes) {
+			<-r.Context().Done()
+			return
+		}
+		// the index changes on an unrelated catalog change, the service entries are the same
+		w.Header().Set("X-Consul-Index", indexes[n-1])
+		_ = json.NewEncoder(w).Encode([]serviceEntry{webEntry1})
+	}))
+	defer srv.Close()
+
+	d, err := NewDiscoverer(Config{Tags: "consul", HTTP: web.HTTP{Request: web.Request{URL: srv.URL}}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []model.TargetGroup)
+	done := make(chan struct{})
+	go func() { defer close(done); d.watchService(ctx, in, "web") }()
+	defer func() { cancel(); <-done }()
+
+	select {
+	case tggs := <-in:
+		require.Len(t, tggs, 1)
+		assert.Len(t, tggs[0].Targets(), 1)
+	case <-time.After(time.Second * 5):
+		t.Fatal("discovery timed out")
+	}
+
+	// the third (blocking) request means the second response was handled
+	require.Eventually(t, func() bool { return requests.Load() > int64(len(indexes)) }, time.Second*5, time.Millisecond*10)
+
+	select {
+	case tggs := <-in:
+		t.Errorf("unexpected target group sent: %v", tggs)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestDiscoverer_query(t *testing.T) {
+	tests := map[string]struct {
+		index         uint64
+		responseIndex string
+		wantIndex     uint64
+		wantErr       bool
+	}{
+		"first query":         {index: 0, responseIndex: "5", wantIndex: 5},
+		"index increased":     {index: 5, responseIndex: "7", wantIndex: 7},
+		"index unchanged":     {index: 7, responseIndex: "7", wantIndex: 7},
+		"index reset":         {index: 10, responseIndex: "3", wantIndex: 0},
+		"zero index":          {index: 5, responseIndex: "0", wantErr: true},
+		"missing index":       {index: 5, responseIndex: "", wantErr: true},
+		"invalid index value": {index: 5, responseIndex: "index", wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.index > 0 {
+					assert.Equal(t, strconv.FormatUint(test.index, 10), r.URL.Query().Get("index"))
+				} else {
+					assert.False(t, r.URL.Query().Has("index"))
+				}
+				if test.responseIndex != "" {
+					w.Header().Set("X-Consul-Index", test.responseIndex)
+				}
+				_, _ = w.Write([]byte("{}"))
+			}))
+			defer srv.Close()
+
+			d, err := NewDiscoverer(Config{Tags: "consul", HTTP: web.HTTP{Request: web.Request{URL: srv.URL}}})
+			require.NoError(t, err)
+
+			var services map[string][]string
+			index, err := d.query(context.Background(), "/v1/catalog/services", test.index, &services)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantIndex, index)
+			}
+		})
+	}
+}
+
+var (
+	webEntry1 = newServiceEntry("node1", "10.0.0.1", "web-1", "web", "", 80, []string{"nginx", "prod"},
+		map[string]string{"version": "1.25"}, "passing", "passing")
+	webEntry2 = newServiceEntry("node2", "10.0.0.2", "web-2", "web", "172.17.0.2", 8080, []string{"nginx"},
+		nil, "passing", "warning")
+	redisEntry = newServiceEntry("node1", "10.0.0.1", "redis", "redis", "", 6379, nil,
+		nil, "passing", "maintenance")
+	mysqlEntry = newServiceEntry("node2", "10.0.0.2", "mysql", "mysql", "", 3306, nil,
+		nil, "passing")
+)
+
+func newServiceEntry(node, nodeAddr, id, name, addr string, port int, tags []string, meta map[string]string, checks ...string) serviceEntry {
+	var e serviceEntry
+	e.Node.Node, e.Node.Address, e.Node.Datacenter = node, nodeAddr, "dc1"
+	e.Service.ID, e.Service.Service, e.Service.Address, e.Service.Port = id, name, addr, port
+	e.Service.Tags, e.Service.Meta = tags, meta
+	for _, status := range checks {
+		e.Checks = append(e.Checks, struct{ Status string }{Status: status})
+	}
+	return e
+}
+
+func withHash(tgt *Target) *Target {
+	tgt.hash, _ = calcHash(tgt)
+	tags, _ := model.ParseTags("consul")
+	tgt.Tags().Merge(tags)
+	return tgt
+}
+
+func TestWorseHealth(t *testing.T) {
+	tests := map[string]struct {
+		a, b     string
+		expected string
+	}{
+		"passing and passing":     {a: "passing", b: "passing", expected: "passing"},
+		"passing and warning":     {a: "passing", b: "warning", expected: "warning"},
+		"critical and warning":    {a: "critical", b: "warning", expected: "critical"},
+		"warning and maintenance": {a: "warning", b: "maintenance", expected: "critical"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, worseHealth(test.a, test.b))
+		})
+	}
+}
+
+// fakeConsul is a minimal Consul HTTP API (catalog services and service health) supporting blocking queries.
+// Every registration change increments the index.
+type fakeConsul struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mux      sync.Mutex
+	index    uint64
+	services map[string][]serviceEntry
+	changed  chan struct{}
+}
+
+func newFakeConsul(t *testing.T) *fakeConsul {
+	c := &fakeConsul{
+		t:        t,
+		index:    1,
+		services: make(map[string][]serviceEntry),
+		changed:  make(chan struct{}),
+	}
+	c.srv = httptest.NewServer(c)
+	t.Cleanup(c.srv.Close)
+	return c
+}
+
+func (c *fakeConsul) register(e serviceEntry) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.services[e.Service.Service] = append(c.services[e.Service.Service], e)
+	c.notify()
+}
+
+func (c *fakeConsul) deregister(name string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.services, name)
+	c.notify()
+}
+
+func (c *fakeConsul) notify() {
+	c.index++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(c.t, "secret", r.Header.Get("X-Consul-Token"))
+
+	if index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); index > 0 {
+		c.mux.Lock()
+		current, changed := c.index, c.changed
+		c.mux.Unlock()
+
+		if index >= current {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-changed:
+			}
+		}
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var resp any
+	switch path := r.URL.Path; {
+	case path == "/v1/catalog/services":
+		services := make(map[string][]string)
+		for name := range c.services {
+			services[name] = []string{}
+		}
+		resp = services
+	case strings.HasPrefix(path, "/v1/health/service/"):
+		entries := c.services[strings.TrimPrefix(path, "/v1/health/service/")]
+		if entries == nil {
+			entries = []serviceEntry{}
+		}
+		resp = entries
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
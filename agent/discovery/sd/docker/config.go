@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+import "time"
+
+type Config struct {
+	Tags string `yaml:"tags"`
+	// Address is the Docker (or Podman) API address, e.g. 'unix:///run/podman/podman.sock'.
+	// Default is the DOCKER_HOST environment variable or 'unix:///var/run/docker.sock'.
+	Address string        `yaml:"address"`
+	Timeout time.Duration `yaml:"timeout"`
+}
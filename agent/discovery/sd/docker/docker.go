@@ -0,0 +1,356 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	docker "github.com/docker/docker/client"
+	"github.com/ilyam8/hashstructure"
+)
+
+type targetGroup struct {
+	source  string
+	targets []model.Target
+}
+
+func (g *targetGroup) Provider() string        { return "sd:docker:container" }
+func (g *targetGroup) Source() string          { return fmt.Sprintf("%s(%s)", g.Provider(), g.source) }
+func (g *targetGroup) Targets() []model.Target { return g.targets }
+
+// Target is a container exposed port, or a container if it doesn't expose ports.
+type Target struct {
+	model.Base `hash:"ignore"`
+
+	hash uint64
+	tuid string
+
+	Address      string
+	ID           string
+	Name         string
+	Image        string
+	Labels       map[string]any
+	NetworkMode  string
+	Networks     map[string]any // network name => container IP address
+	IPAddress    string
+	Port         string
+	PublicPort   string
+	PortProtocol string
+}
+
+func (t *Target) Hash() uint64 { return t.hash }
+func (t *Target) TUID() string { return t.tuid }
+
+func NewDiscoverer(cfg Config) (*Discoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("DOCKER_HOST")
+	}
+	if cfg.Address == "" {
+		cfg.Address = docker.DefaultDockerHost
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second * 5
+	}
+
+	d := &Discoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd docker"),
+		),
+		address:    cfg.Address,
+		timeout:    cfg.Timeout,
+		retryEvery: time.Second * 30,
+		newClient: func(address string) (dockerClient, error) {
+			return docker.NewClientWithOpts(docker.WithHost(address))
+		},
+		containers: make(map[string]bool),
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+type (
+	Discoverer struct {
+		*logger.Logger
+		model.Base
+
+		address    string
+		timeout    time.Duration
+		retryEvery time.Duration
+
+		newClient func(address string) (dockerClient, error)
+		client    dockerClient
+
+		containers map[string]bool // the IDs of the containers with a sent target group
+	}
+	dockerClient interface {
+		NegotiateAPIVersion(context.Context)
+		ContainerList(context.Context, types.ContainerListOptions) ([]types.Container, error)
+		Events(context.Context, types.EventsOptions) (<-chan events.Message, <-chan error)
+		Close() error
+	}
+)
+
+func (d *Discoverer) String() string {
+	return "sd docker"
+}
+
+// Discover sends a target group for every running container, then watches the container events
+// and sends the started containers target groups and the stopped containers empty target groups.
+// The containers are resynced after the connection to the API is lost.
+func (d *Discoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	d.Info("instance is started")
+	defer d.Info("instance is stopped")
+
+	for {
+		if err := d.watch(ctx, in); err != nil {
+			d.Warningf("%v, will retry in %s", err, d.retryEvery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.retryEvery):
+		}
+	}
+}
+
+func (d *Discoverer) watch(ctx context.Context, in chan<- []model.TargetGroup) error {
+	client, err := d.newClient(d.address)
+	if err != nil {
+		return fmt.Errorf("create docker client: %v", err)
+	}
+	d.client = client
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	negCtx, negCancel := context.WithTimeout(ctx, d.timeout)
+	client.NegotiateAPIVersion(negCtx)
+	negCancel()
+
+	// subscribe before listing the containers to not miss the events in between
+	msgCh, errCh := client.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType))),
+	})
+
+	if err := d.sync(ctx, in); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("docker events: %v", err)
+		case msg := <-msgCh:
+			if err := d.handleEvent(ctx, in, msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sync sends the target groups of the running containers and the empty target groups of the gone containers.
+func (d *Discoverer) sync(ctx context.Context, in chan<- []model.TargetGroup) error {
+	containers, err := d.listContainers(ctx, filters.NewArgs())
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var tggs []model.TargetGroup
+
+	for _, cntr := range containers {
+		seen[cntr.ID] = true
+		d.containers[cntr.ID] = true
+		tggs = append(tggs, d.buildTargetGroup(cntr))
+	}
+	for id := range d.containers {
+		if !seen[id] {
+			delete(d.containers, id)
+			tggs = append(tggs, &targetGroup{source: id})
+		}
+	}
+
+	send(ctx, in, tggs...)
+	return nil
+}
+
+func (d *Discoverer) handleEvent(ctx context.Context, in chan<- []model.TargetGroup, msg events.Message) error {
+	id := msg.Actor.ID
+	if id == "" {
+		return nil
+	}
+
+	switch msg.Action {
+	case "start", "unpause", "rename", "connect", "disconnect":
+		containers, err := d.listContainers(ctx, filters.NewArgs(filters.Arg("id", id)))
+		if err != nil {
+			return err
+		}
+		if len(containers) == 0 {
+			d.removeContainer(ctx, in, id)
+			return nil
+		}
+		d.containers[id] = true
+		send(ctx, in, d.buildTargetGroup(containers[0]))
+	case "die", "stop", "pause", "destroy":
+		d.removeContainer(ctx, in, id)
+	}
+	return nil
+}
+
+func (d *Discoverer) removeContainer(ctx context.Context, in chan<- []model.TargetGroup, id string) {
+	if !d.containers[id] {
+		return
+	}
+	delete(d.containers, id)
+	send(ctx, in, &targetGroup{source: id})
+}
+
+func (d *Discoverer) listContainers(ctx context.Context, args filters.Args) ([]types.Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	args.Add("status", "running")
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("list containers: %v", err)
+	}
+	return containers, nil
+}
+
+func (d *Discoverer) buildTargetGroup(cntr types.Container) model.TargetGroup {
+	tgg := &targetGroup{source: cntr.ID}
+
+	networks := make(map[string]any)
+	var ip string
+	if cntr.NetworkSettings != nil {
+		var names []string
+		for name, nw := range cntr.NetworkSettings.Networks {
+			if nw != nil && nw.IPAddress != "" {
+				networks[name] = nw.IPAddress
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			ip = networks[names[0]].(string)
+		}
+	}
+	if cntr.HostConfig.NetworkMode == "host" {
+		ip = "127.0.0.1"
+	}
+	if ip == "" {
+		return tgg
+	}
+
+	newTarget := func(port types.Port) *Target {
+		tgt := &Target{
+			Address:     ip,
+			ID:          cntr.ID,
+			Name:        containerName(cntr),
+			Image:       cntr.Image,
+			Labels:      mapAny(cntr.Labels),
+			NetworkMode: cntr.HostConfig.NetworkMode,
+			Networks:    networks,
+			IPAddress:   ip,
+		}
+		tgt.tuid = tgt.Name
+		if port.PrivatePort != 0 {
+			tgt.Port = strconv.Itoa(int(port.PrivatePort))
+			tgt.PortProtocol = port.Type
+			tgt.Address = net.JoinHostPort(ip, tgt.Port)
+			tgt.tuid = fmt.Sprintf("%s_%s_%s", tgt.Name, port.Type, tgt.Port)
+			if port.PublicPort != 0 {
+				tgt.PublicPort = strconv.Itoa(int(port.PublicPort))
+			}
+		}
+		return tgt
+	}
+
+	var targets []*Target
+	seen := make(map[string]bool)
+	for _, port := range cntr.Ports {
+		// a published port is listed for every host IP (e.g. 0.0.0.0 and ::)
+		key := fmt.Sprintf("%s/%d", port.Type, port.PrivatePort)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, newTarget(port))
+	}
+	if len(targets) == 0 {
+		targets = append(targets, newTarget(types.Port{}))
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].tuid < targets[j].tuid })
+
+	for _, tgt := range targets {
+		hash, err := calcHash(tgt)
+		if err != nil {
+			continue
+		}
+		tgt.hash = hash
+		tgt.Tags().Merge(d.Tags())
+		tgg.targets = append(tgg.targets, tgt)
+	}
+
+	return tgg
+}
+
+func containerName(cntr types.Container) string {
+	if len(cntr.Names) == 0 {
+		return cntr.ID
+	}
+	return strings.TrimPrefix(cntr.Names[0], "/")
+}
+
+func send(ctx context.Context, in chan<- []model.TargetGroup, tggs ...model.TargetGroup) {
+	if len(tggs) == 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case in <- tggs:
+	}
+}
+
+func mapAny(src map[string]string) map[string]any {
+	if src == nil {
+		return nil
+	}
+	m := make(map[string]any, len(src))
+	for k, v := range src {
+		m[k] = v
+	}
+	return m
+}
+
+func calcHash(obj any) (uint64, error) {
+	return hashstructure.Hash(obj, nil)
+}
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiscoverer(t *testing.T) {
+	tests := map[string]struct {
+		cfg         Config
+		wantAddress string
+		wantErr     bool
+	}{
+		"default address": {
+			cfg:         Config{Tags: "docker"},
+			wantAddress: "unix:///var/run/docker.sock",
+		},
+		"podman address": {
+			cfg:         Config{Tags: "docker", Address: "unix:///run/podman/podman.sock"},
+			wantAddress: "unix:///run/podman/podman.sock",
+		},
+		"invalid tags": {
+			cfg:     Config{Tags: "-"},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("DOCKER_HOST", "")
+
+			d, err := NewDiscoverer(test.cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantAddress, d.address)
+			}
+		})
+	}
+}
+
+func TestDiscoverer_Discover(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addContainer(nginxContainer)
+	api.addContainer(redisContainer)
+
+	d, err := NewDiscoverer(Config{Tags: "docker", Address: "unix://" + api.path})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []model.TargetGroup)
+	done := make(chan struct{})
+	go func() { defer close(done); d.Discover(ctx, in) }()
+	defer func() { cancel(); <-done }()
+
+	receive := func() []model.TargetGroup {
+		select {
+		case tggs := <-in:
+			return tggs
+		case <-time.After(time.Second * 5):
+			t.Fatal("discovery timed out")
+			return nil
+		}
+	}
+
+	assert.Equal(t, []model.TargetGroup{
+		&targetGroup{source: "nginx-id", targets: []model.Target{
+			withHash(&Target{
+				tuid:         "nginx_tcp_443",
+				Address:      "172.17.0.2:443",
+				ID:           "nginx-id",
+				Name:         "nginx",
+				Image:        "nginx:latest",
+				Labels:       map[string]any{"app": "web"},
+				NetworkMode:  "bridge",
+				Networks:     map[string]any{"bridge": "172.17.0.2"},
+				IPAddress:    "172.17.0.2",
+				Port:         "443",
+				PortProtocol: "tcp",
+			}),
+			withHash(&Target{
+				tuid:         "nginx_tcp_80",
+				Address:      "172.17.0.2:80",
+				ID:           "nginx-id",
+				Name:         "nginx",
+				Image:        "nginx:latest",
+				Labels:       map[string]any{"app": "web"},
+				NetworkMode:  "bridge",
+				Networks:     map[string]any{"bridge": "172.17.0.2"},
+				IPAddress:    "172.17.0.2",
+				Port:         "80",
+				PublicPort:   "8080",
+				PortProtocol: "tcp",
+			}),
+		}},
+		&targetGroup{source: "redis-id", targets: []model.Target{
+			withHash(&Target{
+				tuid:        "redis",
+				Address:     "127.0.0.1",
+				ID:          "redis-id",
+				Name:        "redis",
+				Image:       "redis:7",
+				NetworkMode: "host",
+				Networks:    map[string]any{},
+				IPAddress:   "127.0.0.1",
+			}),
+		}},
+	}, receive())
+
+	api.addContainer(mysqlContainer)
+	api.sendEvent("start", "mysql-id")
+
+	assert.Equal(t, []model.TargetGroup{
+		&targetGroup{source: "mysql-id", targets: []model.Target{
+			withHash(&Target{
+				tuid:         "mysql_tcp_3306",
+				Address:      "10.0.0.5:3306",
+				ID:           "mysql-id",
+				Name:         "mysql",
+				Image:        "mysql:8",
+				NetworkMode:  "backend",
+				Networks:     map[string]any{"backend": "10.0.0.5", "frontend": "10.0.1.5"},
+				IPAddress:    "10.0.0.5",
+				Port:         "3306",
+				PortProtocol: "tcp",
+			}),
+		}},
+	}, receive())
+
+	api.removeContainer("nginx-id")
+	api.sendEvent("die", "nginx-id")
+
+	assert.Equal(t, []model.TargetGroup{&targetGroup{source: "nginx-id"}}, receive())
+
+	assert.Equal(t, "sd:docker:container(nginx-id)", (&targetGroup{source: "nginx-id"}).Source())
+}
+
+var (
+	nginxContainer = types.Container{
+		ID:     "nginx-id",
+		Names:  []string{"/nginx"},
+		Image:  "nginx:latest",
+		Labels: map[string]string{"app": "web"},
+		State:  "running",
+		Ports: []types.Port{
+			{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			{IP: "::", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			{PrivatePort: 443, Type: "tcp"},
+		},
+		HostConfig: struct {
+			NetworkMode string `json:",omitempty"`
+		}{NetworkMode: "bridge"},
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"bridge": {IPAddress: "172.17.0.2"}},
+		},
+	}
+	redisContainer = types.Container{
+		ID:    "redis-id",
+		Names: []string{"/redis"},
+		Image: "redis:7",
+		State: "running",
+		HostConfig: struct {
+			NetworkMode string `json:",omitempty"`
+		}{NetworkMode: "host"},
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"host": {}},
+		},
+	}
+	mysqlContainer = types.Container{
+		ID:    "mysql-id",
+		Names: []string{"/mysql"},
+		Image: "mysql:8",
+		State: "running",
+		Ports: []types.Port{{PrivatePort: 3306, Type: "tcp"}},
+		HostConfig: struct {
+			NetworkMode string `json:",omitempty"`
+		}{NetworkMode: "backend"},
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"backend":  {IPAddress: "10.0.0.5"},
+				"frontend": {IPAddress: "10.0.1.5"},
+			},
+		},
+	}
+)
+
+func withHash(tgt *Target) *Target {
+	tgt.hash, _ = calcHash(tgt)
+	tags, _ := model.ParseTags("docker")
+	tgt.Tags().Merge(tags)
+	return tgt
+}
+
+// fakeAPI is a minimal Docker API (ping, containers list and events) served on a unix socket.
+type fakeAPI struct {
+	path string
+
+	mux        sync.Mutex
+	containers []types.Container
+	events     chan events.Message
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	api := &fakeAPI{
+		path:   filepath.Join(t.TempDir(), "docker.sock"),
+		events: make(chan events.Message, 10),
+	}
+
+	ln, err := net.Listen("unix", api.path)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: api}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { close(api.events); _ = srv.Close() })
+
+	return api
+}
+
+func (api *fakeAPI) addContainer(cntr types.Container) {
+	api.mux.Lock()
+	defer api.mux.Unlock()
+	api.containers = append(api.containers, cntr)
+}
+
+func (api *fakeAPI) removeContainer(id string) {
+	api.mux.Lock()
+	defer api.mux.Unlock()
+	for i, cntr := range api.containers {
+		if cntr.ID == id {
+			api.containers = append(api.containers[:i], api.containers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (api *fakeAPI) sendEvent(action, id string) {
+	api.events <- events.Message{Type: events.ContainerEventType, Action: action, Actor: events.Actor{ID: id}}
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch path := r.URL.Path; {
+	case path == "/_ping":
+		w.Header().Set("API-Version", "1.41")
+		_, _ = w.Write([]byte("OK"))
+	case strings.HasSuffix(path, "/containers/json"):
+		args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.mux.Lock()
+		containers := []types.Container{}
+		for _, cntr := range api.containers {
+			if !args.Contains("id") || args.ExactMatch("id", cntr.ID) {
+				containers = append(containers, cntr)
+			}
+		}
+		api.mux.Unlock()
+		_ = json.NewEncoder(w).Encode(containers)
+	case strings.HasSuffix(path, "/events"):
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		enc := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case msg, ok := <-api.events:
+				if !ok {
+					return
+				}
+				_ = enc.Encode(msg)
+				w.(http.Flusher).Flush()
+			}
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -3,5 +3,18 @@
 package hostsocket
 
 type NetworkSocketConfig struct {
-	Tags string
+	Tags string `yaml:"tags"`
+	// ProcRoot is the /proc filesystem path. If set, the listeners are discovered reading it
+	// instead of running the 'local-listeners' binary. It is also used if the binary is not found.
+	ProcRoot string `yaml:"proc_root"`
+}
+
+type UnixSocketConfig struct {
+	Tags     string `yaml:"tags"`
+	ProcRoot string `yaml:"proc_root"` // default is '/proc'
+}
+
+type ProcessConfig struct {
+	Tags     string `yaml:"tags"`
+	ProcRoot string `yaml:"proc_root"` // default is '/proc'
 }
@@ -65,13 +65,24 @@ func NewNetSocketDiscoverer(cfg NetworkSocketConfig) (*NetDiscoverer, error) {
 			slog.String("component", "discovery sd hostsocket"),
 		),
 		interval: time.Second * 60,
-		ll: &localListenersExec{
-			binPath: filepath.Join(dir, "local-listeners"),
-			timeout: time.Second * 5,
-		},
 	}
 	d.Tags().Merge(tags)
 
+	binPath := filepath.Join(dir, "local-listeners")
+
+	switch _, err := os.Stat(binPath); {
+	case cfg.ProcRoot != "":
+		d.ll = &procListeners{root: cfg.ProcRoot}
+	case err != nil:
+		d.Infof("'%s' not found, will read '%s' to discover the listeners", binPath, defaultProcRoot)
+		d.ll = &procListeners{root: defaultProcRoot}
+	default:
+		d.ll = &localListenersExec{
+			binPath: binPath,
+			timeout: time.Second * 5,
+		}
+	}
+
 	return d, nil
 }
 
@@ -195,11 +206,10 @@ func (e *localListenersExec) discover(ctx context.Context) ([]byte, error) {
 }
 
 func extractComm(s string) string {
-	i := strings.IndexByte(s, ' ')
-	if i <= 0 {
-		return ""
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
 	}
-	_, comm := filepath.Split(s[:i])
+	_, comm := filepath.Split(s)
 	return comm
 }
 
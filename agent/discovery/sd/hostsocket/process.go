@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+)
+
+type processTargetGroup struct {
+	provider string
+	source   string
+	targets  []model.Target
+}
+
+func (g *processTargetGroup) Provider() string        { return g.provider }
+func (g *processTargetGroup) Source() string          { return g.source }
+func (g *processTargetGroup) Targets() []model.Target { return g.targets }
+
+// ProcessTarget is a running process. The processes with the same attributes (e.g. workers) are a single target.
+type ProcessTarget struct {
+	model.Base
+
+	hash uint64
+
+	Comm    string
+	Cmdline string
+	Exe     string
+	User    string
+	Cgroup  string
+}
+
+func (t *ProcessTarget) TUID() string { return t.tuid() }
+func (t *ProcessTarget) Hash() uint64 { return t.hash }
+func (t *ProcessTarget) tuid() string {
+	return fmt.Sprintf("process_%s_%d", t.Comm, t.hash)
+}
+
+func NewProcessDiscoverer(cfg ProcessConfig) (*ProcessDiscoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if cfg.ProcRoot == "" {
+		cfg.ProcRoot = defaultProcRoot
+	}
+
+	d := &ProcessDiscoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd hostsocket process"),
+		),
+		interval:   time.Second * 60,
+		procRoot:   cfg.ProcRoot,
+		lookupUser: lookupUser,
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+type ProcessDiscoverer struct {
+	*logger.Logger
+	model.Base
+
+	interval   time.Duration
+	procRoot   string
+	lookupUser func(uid string) string
+}
+
+func (d *ProcessDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	if err := d.discoverProcesses(ctx, in); err != nil {
+		d.Error(err)
+		return
+	}
+
+	tk := time.NewTicker(d.interval)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if err := d.discoverProcesses(ctx, in); err != nil {
+				d.Error(err)
+				return
+			}
+		}
+	}
+}
+
+func (d *ProcessDiscoverer) discoverProcesses(ctx context.Context, in chan<- []model.TargetGroup) error {
+	dirs, err := os.ReadDir(d.procRoot)
+	if err != nil {
+		return err
+	}
+
+	users := make(map[string]string)
+	seen := make(map[uint64]bool)
+	var tgts []model.Target
+
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if _, err := strconv.Atoi(dir.Name()); err != nil || !dir.IsDir() {
+			continue
+		}
+
+		tgt, ok := d.readProcess(filepath.Join(d.procRoot, dir.Name()), users)
+		if !ok {
+			continue
+		}
+
+		hash, err := calcHash(tgt)
+		if err != nil || seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		tgt.hash = hash
+		tgt.Tags().Merge(d.Tags())
+
+		tgts = append(tgts, tgt)
+	}
+
+	tgg := &processTargetGroup{
+		provider: "hostsocket",
+		source:   "process",
+		targets:  tgts,
+	}
+
+	select {
+	case <-ctx.Done():
+	case in <- []model.TargetGroup{tgg}:
+	}
+	return nil
+}
+
+func (d *ProcessDiscoverer) readProcess(pidDir string, users map[string]string) (*ProcessTarget, bool) {
+	comm, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+	if err != nil {
+		// the process is gone
+		return nil, false
+	}
+
+	if isKernelThread(pidDir) {
+		return nil, false
+	}
+
+	tgt := &ProcessTarget{
+		Comm:    strings.TrimSpace(string(comm)),
+		Cmdline: readCmdline(pidDir),
+		Cgroup:  readCgroup(pidDir),
+	}
+
+	// reading the executable link of other users processes requires privileges, the process is kept without it
+	if exe, err := os.Readlink(filepath.Join(pidDir, "exe")); err == nil {
+		tgt.Exe = strings.TrimSuffix(exe, " (deleted)")
+	}
+
+	if uid := readUID(pidDir); uid != "" {
+		name, ok := users[uid]
+		if !ok {
+			name = d.lookupUser(uid)
+			users[uid] = name
+		}
+		tgt.User = name
+	}
+
+	return tgt, true
+}
+
+// pfKthread is the kernel thread flag of the process flags (include/linux/sched.h).
+const pfKthread = 0x00200000
+
+// isKernelThread checks the PF_KTHREAD flag of /proc/<pid>/stat, if it can't be read
+// the process is a kernel thread if it has an empty command line.
+func isKernelThread(pidDir string) bool {
+	if flags, ok := readFlags(pidDir); ok {
+		return flags&pfKthread != 0
+	}
+	bs, err := os.ReadFile(filepath.Join(pidDir, "cmdline"))
+	return err == nil && len(bs) == 0
+}
+
+// readFlags returns the process flags, the 9th field of /proc/<pid>/stat:
+//
+//	pid (comm) state ppid pgrp session tty_nr tpgid flags ...
+func readFlags(pidDir string) (uint64, bool) {
+	bs, err := os.ReadFile(filepath.Join(pidDir, "stat"))
+	if err != nil {
+		return 0, false
+	}
+	// comm can contain spaces and parentheses
+	i := bytes.LastIndexByte(bs, ')')
+	if i == -1 {
+		return 0, false
+	}
+	fields := strings.Fields(string(bs[i+1:]))
+	if len(fields) < 7 {
+		return 0, false
+	}
+	flags, err := strconv.ParseUint(fields[6], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return flags, true
+}
+
+// readUID returns the process real user ID from the "Uid:" line of /proc/<pid>/status.
+func readUID(pidDir string) string {
+	f, err := os.Open(filepath.Join(pidDir, "status"))
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "Uid:"); ok {
+			if fields := strings.Fields(v); len(fields) > 0 {
+				return fields[0]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// readCgroup returns the process cgroup path, the cgroup v2 (unified hierarchy) one if available.
+func readCgroup(pidDir string) string {
+	bs, err := os.ReadFile(filepath.Join(pidDir, "cgroup"))
+	if err != nil {
+		return ""
+	}
+
+	var cgroup string
+	for _, line := range strings.Split(strings.TrimSpace(string(bs)), "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			return parts[2]
+		}
+		if cgroup == "" {
+			cgroup = parts[2]
+		}
+	}
+	return cgroup
+}
+
+func lookupUser(uid string) string {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return uid
+	}
+	return u.Username
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessDiscoverer_Discover(t *testing.T) {
+	d, err := NewProcessDiscoverer(ProcessConfig{Tags: "hostsocket process", ProcRoot: "testdata/proc"})
+	require.NoError(t, err)
+
+	users := map[string]string{"0": "root", "201": "netdata"}
+	d.lookupUser = func(uid string) string {
+		if name, ok := users[uid]; ok {
+			return name
+		}
+		return uid
+	}
+
+	withHash := func(tgt *ProcessTarget) *ProcessTarget {
+		tgt.hash, _ = calcHash(tgt)
+		tags, _ := model.ParseTags("hostsocket process")
+		tgt.Tags().Merge(tags)
+		return tgt
+	}
+
+	expected := []model.TargetGroup{&processTargetGroup{
+		provider: "hostsocket",
+		source:   "process",
+		targets: []model.Target{
+			withHash(&ProcessTarget{
+				Comm:    "netdata",
+				Cmdline: "/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D",
+				Exe:     "/opt/netdata/usr/sbin/netdata",
+				User:    "netdata",
+				Cgroup:  "/system.slice/netdata.service",
+			}),
+			withHash(&ProcessTarget{
+				Comm:    "nginx",
+				Cmdline: "nginx",
+				Exe:     "/usr/sbin/nginx",
+				User:    "root",
+				Cgroup:  "/docker/abc",
+			}),
+			withHash(&ProcessTarget{
+				Comm:    "chronyd",
+				Cmdline: "/usr/sbin/chronyd",
+				Exe:     "/usr/sbin/chronyd",
+				User:    "998",
+				Cgroup:  "/system.slice/chronyd.service",
+			}),
+			withHash(&ProcessTarget{
+				Comm:    "sshd",
+				Cmdline: "/usr/sbin/sshd -D",
+				User:    "root",
+				Cgroup:  "/system.slice/ssh.service",
+			}),
+		},
+	}}
+
+	assert.Equal(t, expected, discoverOnce(t, d))
+}
+
+func Test_readCgroup(t *testing.T) {
+	tests := map[string]struct {
+		pid      string
+		expected string
+	}{
+		"unified hierarchy":        {pid: "100", expected: "/system.slice/netdata.service"},
+		"hybrid hierarchy":         {pid: "200", expected: "/docker/abc"},
+		"root cgroup":              {pid: "400", expected: "/"},
+		"not existing process dir": {pid: "500", expected: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, readCgroup("testdata/proc/"+test.pid))
+		})
+	}
+}
+
+func Test_isKernelThread(t *testing.T) {
+	tests := map[string]struct {
+		pid      string
+		expected bool
+	}{
+		"PF_KTHREAD flag":                      {pid: "400", expected: true},
+		"no stat, empty command line":          {pid: "401", expected: true},
+		"empty command line, no PF_KTHREAD":    {pid: "200", expected: false},
+		"no stat, command line, no executable": {pid: "600", expected: false},
+		"user process":                         {pid: "100", expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, isKernelThread("testdata/proc/"+test.pid))
+		})
+	}
+}
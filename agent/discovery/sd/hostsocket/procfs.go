@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const defaultProcRoot = "/proc"
+
+const (
+	tcpStateListen = "0A"
+	udpStateClose  = "07" // unconnected (bound) UDP socket
+
+	unixFlagAcceptConn = 0x10000 // __SO_ACCEPTCON, a listening socket
+)
+
+// procListeners discovers the listening sockets reading the /proc filesystem.
+// Its output has the same format as the 'local-listeners' output: "Protocol|Address|Port|Cmdline".
+type procListeners struct {
+	root string
+}
+
+type procSocket struct {
+	protocol string
+	address  string
+	port     string
+	inode    string
+}
+
+func (p *procListeners) discover(ctx context.Context) ([]byte, error) {
+	var sockets []procSocket
+
+	for _, v := range []struct{ file, protocol, state string }{
+		{file: "tcp", protocol: "TCP", state: tcpStateListen},
+		{file: "tcp6", protocol: "TCP6", state: tcpStateListen},
+		{file: "udp", protocol: "UDP", state: udpStateClose},
+		{file: "udp6", protocol: "UDP6", state: udpStateClose},
+	} {
+		socks, err := p.readNetFile(v.file, v.protocol, v.state)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		sockets = append(sockets, socks...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	procs := p.socketProcesses(ctx)
+
+	var buf bytes.Buffer
+	seen := make(map[string]bool)
+	for _, sock := range sockets {
+		line := fmt.Sprintf("%s|%s|%s|%s", sock.protocol, sock.address, sock.port, procs[sock.inode])
+		if !seen[line] {
+			seen[line] = true
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// readNetFile reads /proc/net/{tcp,tcp6,udp,udp6}:
+//
+//	sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+//	0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 21512 ...
+func (p *procListeners) readNetFile(name, protocol, state string) ([]procSocket, error) {
+	f, err := os.Open(filepath.Join(p.root, "net", name))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var sockets []procSocket
+
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+
+		addr, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %v", f.Name(), err)
+		}
+		if port == 0 {
+			continue
+		}
+
+		sockets = append(sockets, procSocket{
+			protocol: protocol,
+			address:  addr,
+			port:     strconv.Itoa(port),
+			inode:    fields[9],
+		})
+	}
+
+	return sockets, sc.Err()
+}
+
+// parseHexAddress parses "0100007F:1F90", the address is in the host byte order (little endian) 32-bit words.
+func parseHexAddress(s string) (string, int, error) {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address '%s'", s)
+	}
+
+	bs, err := hex.DecodeString(host)
+	if err != nil || (len(bs) != net.IPv4len && len(bs) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address '%s'", s)
+	}
+	for i := 0; i < len(bs); i += 4 {
+		bs[i], bs[i+1], bs[i+2], bs[i+3] = bs[i+3], bs[i+2], bs[i+1], bs[i]
+	}
+
+	n, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port '%s'", s)
+	}
+
+	return net.IP(bs).String(), int(n), nil
+}
+
+// socketProcesses maps the socket inodes to the command lines of the processes having the sockets open.
+func (p *procListeners) socketProcesses(ctx context.Context) map[string]string {
+	procs := make(map[string]string)
+
+	dirs, err := os.ReadDir(p.root)
+	if err != nil {
+		return procs
+	}
+
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := strconv.Atoi(dir.Name()); err != nil || !dir.IsDir() {
+			continue
+		}
+
+		pidDir := filepath.Join(p.root, dir.Name())
+
+		fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+		if err != nil {
+			// the process is gone or not permitted
+			continue
+		}
+
+		var cmdline string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			if _, ok := procs[inode]; ok {
+				continue
+			}
+			if cmdline == "" {
+				cmdline = readCmdline(pidDir)
+			}
+			procs[inode] = cmdline
+		}
+	}
+
+	return procs
+}
+
+// readCmdline returns the process command line, or the process name if the command line is empty (e.g. kernel threads).
+func readCmdline(pidDir string) string {
+	bs, err := os.ReadFile(filepath.Join(pidDir, "cmdline"))
+	if err == nil {
+		if s := strings.TrimSpace(string(bytes.ReplaceAll(bytes.TrimRight(bs, "\x00"), []byte{0}, []byte{' '}))); s != "" {
+			return s
+		}
+	}
+	bs, err = os.ReadFile(filepath.Join(pidDir, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bs))
+}
+
+// readUnixSockets reads the listening sockets from /proc/net/unix:
+//
+//	Num       RefCount Protocol Flags    Type St Inode Path
+//	0000000000000000: 00000002 00000000 00010000 0001 01 21433 /run/docker.sock
+func (p *procListeners) readUnixSockets(ctx context.Context) ([]*UnixSocketTarget, error) {
+	f, err := os.Open(filepath.Join(p.root, "net", "unix"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	type unixSocket struct{ path, typ, inode string }
+	var sockets []unixSocket
+
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&unixFlagAcceptConn == 0 {
+			continue
+		}
+		sockets = append(sockets, unixSocket{
+			path:  strings.Join(fields[7:], " "),
+			typ:   unixSocketType(fields[4]),
+			inode: fields[6],
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	procs := p.socketProcesses(ctx)
+
+	var tgts []*UnixSocketTarget
+	seen := make(map[string]bool)
+	for _, sock := range sockets {
+		if seen[sock.path] {
+			continue
+		}
+		seen[sock.path] = true
+
+		cmdline := procs[sock.inode]
+		tgts = append(tgts, &UnixSocketTarget{
+			Path:    sock.path,
+			Type:    sock.typ,
+			Comm:    extractComm(cmdline),
+			Cmdline: cmdline,
+		})
+	}
+
+	return tgts, nil
+}
+
+func unixSocketType(s string) string {
+	switch s {
+	case "0001":
+		return "stream"
+	case "0002":
+		return "dgram"
+	case "0005":
+		return "seqpacket"
+	}
+	return s
+}
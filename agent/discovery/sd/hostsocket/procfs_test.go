@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcListeners_discover(t *testing.T) {
+	p := &procListeners{root: "testdata/proc"}
+
+	bs, err := p.discover(context.Background())
+	require.NoError(t, err)
+
+	expected := `TCP|127.0.0.1|8080|/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D
+TCP|0.0.0.0|80|nginx
+TCP6|::1|8080|/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D
+UDP|127.0.0.1|8125|/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D
+`
+	assert.Equal(t, expected, string(bs))
+}
+
+func TestNetSocketDiscoverer_Discover_ProcRoot(t *testing.T) {
+	d, err := NewNetSocketDiscoverer(NetworkSocketConfig{Tags: "hostsocket net", ProcRoot: "testdata/proc"})
+	require.NoError(t, err)
+
+	tggs := discoverOnce(t, d)
+
+	require.Len(t, tggs, 1)
+	var comms []string
+	for _, tgt := range tggs[0].Targets() {
+		comms = append(comms, tgt.(*NetSocketTarget).Comm)
+	}
+	assert.Equal(t, []string{"netdata", "nginx", "netdata", "netdata"}, comms)
+}
+
+func TestUnixDiscoverer_Discover(t *testing.T) {
+	d, err := NewUnixSocketDiscoverer(UnixSocketConfig{Tags: "hostsocket unix", ProcRoot: "testdata/proc"})
+	require.NoError(t, err)
+
+	withHash := func(tgt *UnixSocketTarget) *UnixSocketTarget {
+		tgt.hash, _ = calcHash(tgt)
+		tags, _ := model.ParseTags("hostsocket unix")
+		tgt.Tags().Merge(tags)
+		return tgt
+	}
+
+	expected := []model.TargetGroup{&unixSocketTargetGroup{
+		provider: "hostsocket",
+		source:   "unix",
+		targets: []model.Target{
+			withHash(&UnixSocketTarget{
+				Path:    "/run/nginx.sock",
+				Type:    "stream",
+				Comm:    "nginx",
+				Cmdline: "nginx",
+			}),
+			withHash(&UnixSocketTarget{
+				Path:    "@netdata-abstract",
+				Type:    "stream",
+				Comm:    "netdata",
+				Cmdline: "/opt/netdata/usr/sbin/netdata -P /run/netdata/netdata.pid -D",
+			}),
+		},
+	}}
+
+	assert.Equal(t, expected, discoverOnce(t, d))
+}
+
+func Test_parseHexAddress(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		wantAddr string
+		wantPort int
+		wantErr  bool
+	}{
+		"ipv4":          {input: "0100007F:1F90", wantAddr: "127.0.0.1", wantPort: 8080},
+		"ipv4 any":      {input: "00000000:0050", wantAddr: "0.0.0.0", wantPort: 80},
+		"ipv6 loopback": {input: "00000000000000000000000001000000:1F90", wantAddr: "::1", wantPort: 8080},
+		"ipv6":          {input: "B80D0120000000000000000001000000:0035", wantAddr: "2001:db8::1", wantPort: 53},
+		"no port":       {input: "0100007F", wantErr: true},
+		"invalid host":  {input: "0100007:1F90", wantErr: true},
+		"invalid port":  {input: "0100007F:XYZ", wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr, port, err := parseHexAddress(test.input)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantAddr, addr)
+				assert.Equal(t, test.wantPort, port)
+			}
+		})
+	}
+}
+
+func discoverOnce(t *testing.T, d model.Discoverer) []model.TargetGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []model.TargetGroup)
+	done := make(chan struct{})
+	go func() { defer close(done); d.Discover(ctx, in) }()
+	defer func() { cancel(); <-done }()
+
+	select {
+	case tggs := <-in:
+		return tggs
+	case <-time.After(time.Second * 5):
+		t.Fatal("discovery timed out")
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package hostsocket
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+)
+
+type unixSocketTargetGroup struct {
+	provider string
+	source   string
+	targets  []model.Target
+}
+
+func (g *unixSocketTargetGroup) Provider() string        { return g.provider }
+func (g *unixSocketTargetGroup) Source() string          { return g.source }
+func (g *unixSocketTargetGroup) Targets() []model.Target { return g.targets }
+
+type UnixSocketTarget struct {
+	model.Base
+
+	hash uint64
+
+	Path    string
+	Type    string
+	Comm    string
+	Cmdline string
+}
+
+func (t *UnixSocketTarget) TUID() string { return t.tuid() }
+func (t *UnixSocketTarget) Hash() uint64 { return t.hash }
+func (t *UnixSocketTarget) tuid() string {
+	return fmt.Sprintf("unix_%s_%d", t.Comm, t.hash)
+}
+
+func NewUnixSocketDiscoverer(cfg UnixSocketConfig) (*UnixDiscoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if cfg.ProcRoot == "" {
+		cfg.ProcRoot = defaultProcRoot
+	}
+
+	d := &UnixDiscoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd hostsocket unix"),
+		),
+		interval: time.Second * 60,
+		proc:     &procListeners{root: cfg.ProcRoot},
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+type UnixDiscoverer struct {
+	*logger.Logger
+	model.Base
+
+	interval time.Duration
+	proc     *procListeners
+}
+
+func (d *UnixDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	if err := d.discoverUnixSockets(ctx, in); err != nil {
+		d.Error(err)
+		return
+	}
+
+	tk := time.NewTicker(d.interval)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if err := d.discoverUnixSockets(ctx, in); err != nil {
+				d.Error(err)
+				return
+			}
+		}
+	}
+}
+
+func (d *UnixDiscoverer) discoverUnixSockets(ctx context.Context, in chan<- []model.TargetGroup) error {
+	socks, err := d.proc.readUnixSockets(ctx)
+	if err != nil {
+		return err
+	}
+
+	var tgts []model.Target
+	for _, tgt := range socks {
+		hash, err := calcHash(tgt)
+		if err != nil {
+			continue
+		}
+		tgt.hash = hash
+		tgt.Tags().Merge(d.Tags())
+
+		tgts = append(tgts, tgt)
+	}
+
+	tgg := &unixSocketTargetGroup{
+		provider: "hostsocket",
+		source:   "unix",
+		targets:  tgts,
+	}
+
+	select {
+	case <-ctx.Done():
+	case in <- []model.TargetGroup{tgg}:
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/agent/secrets"
 	"github.com/netdata/go.d.plugin/logger"
 
 	"gopkg.in/yaml.v2"
@@ -42,6 +43,7 @@ type (
 	composeRuleConf struct {
 		sr   selector
 		tmpl *template.Template
+		refs map[string]bool // the secret references written in the template
 	}
 )
 
@@ -67,6 +69,12 @@ func (c *configComposer) compose(tgt model.Target) []confgroup.Config {
 			if c.buf.Len() == 0 {
 				continue
 			}
+			// the target values (e.g. container labels) are not trusted, they must not reference secrets
+			if ref, ok := conf.injectedReference(c.buf.String()); ok {
+				c.Warningf("rule[%d]->config[%d]: target '%s' values contain a secret reference '%s', skipping the config",
+					i+1, j+1, tgt.TUID(), ref)
+				continue
+			}
 
 			var cfg confgroup.Config
 
@@ -114,6 +122,11 @@ func newComposeRules(cfg []ComposeRuleConfig) ([]*composeRule, error) {
 			}
 			conf.tmpl = tmpl
 
+			conf.refs = make(map[string]bool)
+			for _, ref := range secrets.References(confCfg.Template) {
+				conf.refs[ref] = true
+			}
+
 			rule.conf = append(rule.conf, &conf)
 		}
 
@@ -122,3 +135,13 @@ func newComposeRules(cfg []ComposeRuleConfig) ([]*composeRule, error) {
 
 	return rules, nil
 }
+
+// injectedReference returns the secret reference in the rendered config that is not in the template.
+func (c *composeRuleConf) injectedReference(rendered string) (string, bool) {
+	for _, ref := range secrets.References(rendered) {
+		if !c.refs[ref] {
+			return ref, true
+		}
+	}
+	return "", false
+}
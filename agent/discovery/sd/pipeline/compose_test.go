@@ -39,6 +39,12 @@ func TestConfigComposer_compose(t *testing.T) {
     - selector: "bar6"
       template: |
         name: {{ .Name }}-6
+- selector: "rule4"
+  config:
+    - selector: "bar7"
+      template: |
+        name: {{ .Name }}-7
+        password: ${env:PASSWORD}
 `
 	tests := map[string]struct {
 		target      model.Target
@@ -72,6 +78,16 @@ func TestConfigComposer_compose(t *testing.T) {
 				{"name": "mock-6"},
 			},
 		},
+		"secret reference in template": {
+			target: newMockTarget("mock", "rule4 bar7"),
+			wantConfigs: []confgroup.Config{
+				{"name": "mock-7", "password": "${env:PASSWORD}"},
+			},
+		},
+		"secret reference in target values": {
+			target:      newMockTarget("${cmd:/usr/bin/id}", "rule4 bar7"),
+			wantConfigs: nil,
+		},
 	}
 
 	for name, test := range tests {
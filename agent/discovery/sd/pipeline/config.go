@@ -5,9 +5,12 @@ package pipeline
 import (
 	"errors"
 	"fmt"
-	"github.com/netdata/go.d.plugin/agent/discovery/sd/hostsocket"
 
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/consul"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/docker"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/hostsocket"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/kubernetes"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/promsd"
 )
 
 type Config struct {
@@ -21,9 +24,15 @@ type (
 	DiscoveryConfig struct {
 		K8s        []kubernetes.Config `yaml:"k8s"`
 		HostSocket HostSocketConfig    `yaml:"hostsocket"`
+		Docker     []docker.Config     `yaml:"docker"`
+		HTTPSD     []promsd.HTTPConfig `yaml:"http_sd"`
+		FileSD     []promsd.FileConfig `yaml:"file_sd"`
+		Consul     []consul.Config     `yaml:"consul"`
 	}
 	HostSocketConfig struct {
-		Net *hostsocket.NetworkSocketConfig `yaml:"net"`
+		Net     *hostsocket.NetworkSocketConfig `yaml:"net"`
+		Unix    *hostsocket.UnixSocketConfig    `yaml:"unix"`
+		Process *hostsocket.ProcessConfig       `yaml:"process"`
 	}
 )
 
@@ -50,8 +59,10 @@ func validateConfig(cfg Config) error {
 	if cfg.Name != "" {
 		return errors.New("'name' not set")
 	}
-	if len(cfg.Discovery.K8s) == 0 {
-		return errors.New("'discovery->k8s' not set")
+	if len(cfg.Discovery.K8s) == 0 && cfg.Discovery.HostSocket.Net == nil && cfg.Discovery.HostSocket.Unix == nil &&
+		cfg.Discovery.HostSocket.Process == nil && len(cfg.Discovery.Docker) == 0 &&
+		len(cfg.Discovery.HTTPSD) == 0 && len(cfg.Discovery.FileSD) == 0 && len(cfg.Discovery.Consul) == 0 {
+		return errors.New("'discovery' not set, need at least one of 'k8s', 'hostsocket', 'docker', 'http_sd', 'file_sd' or 'consul'")
 	}
 	if err := validateClassifyConfig(cfg.Classify); err != nil {
 		return fmt.Errorf("tag rules: %v", err)
@@ -8,9 +8,12 @@ import (
 	"time"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/consul"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/docker"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/hostsocket"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/kubernetes"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/promsd"
 	"github.com/netdata/go.d.plugin/logger"
 )
 
@@ -71,6 +74,54 @@ func (p *Pipeline) registerDiscoverers(conf Config) error {
 		p.discoverers = append(p.discoverers, td)
 	}
 
+	if conf.Discovery.HostSocket.Unix != nil {
+		td, err := hostsocket.NewUnixSocketDiscoverer(*conf.Discovery.HostSocket.Unix)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+
+	if conf.Discovery.HostSocket.Process != nil {
+		td, err := hostsocket.NewProcessDiscoverer(*conf.Discovery.HostSocket.Process)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+
+	for _, cfg := range conf.Discovery.Docker {
+		td, err := docker.NewDiscoverer(cfg)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+
+	for _, cfg := range conf.Discovery.HTTPSD {
+		td, err := promsd.NewHTTPDiscoverer(cfg)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+
+	for _, cfg := range conf.Discovery.FileSD {
+		td, err := promsd.NewFileDiscoverer(cfg)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+
+	for _, cfg := range conf.Discovery.Consul {
+		td, err := consul.NewDiscoverer(cfg)
+		if err != nil {
+			return err
+		}
+		p.discoverers = append(p.discoverers, td)
+	}
+
 	return nil
 }
 
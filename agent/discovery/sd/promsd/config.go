@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promsd
+
+import "github.com/netdata/go.d.plugin/pkg/web"
+
+// HTTPConfig is the Prometheus 'http_sd_configs' like discoverer configuration.
+type HTTPConfig struct {
+	Tags     string `yaml:"tags"`
+	web.HTTP `yaml:",inline"`
+	// RefreshInterval is the endpoint polling interval. Default is 60s.
+	RefreshInterval web.Duration `yaml:"refresh_interval"`
+}
+
+// FileConfig is the Prometheus 'file_sd_configs' like discoverer configuration.
+type FileConfig struct {
+	Tags string `yaml:"tags"`
+	// Files are the paths (glob patterns are allowed) of the JSON (*.json) or YAML (*.yml, *.yaml) files.
+	Files []string `yaml:"files"`
+	// RefreshInterval is the files re-read interval, the files are also watched for changes. Default is 5m.
+	RefreshInterval web.Duration `yaml:"refresh_interval"`
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promsd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v2"
+)
+
+func NewFileDiscoverer(cfg FileConfig) (*FileDiscoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if len(cfg.Files) == 0 {
+		return nil, errors.New("'files' not set")
+	}
+	for _, pattern := range cfg.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad file pattern '%s': %v", pattern, err)
+		}
+		if !isSupportedFile(pattern) {
+			return nil, fmt.Errorf("bad file pattern '%s': only *.json, *.yml and *.yaml files are supported", pattern)
+		}
+	}
+	if cfg.RefreshInterval.Duration == 0 {
+		cfg.RefreshInterval.Duration = time.Minute * 5
+	}
+
+	d := &FileDiscoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd promsd file"),
+		),
+		patterns: cfg.Files,
+		interval: cfg.RefreshInterval.Duration,
+		cache:    make(map[string]time.Time),
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+// FileDiscoverer reads Prometheus file SD files. Every file targets are a target group.
+// The files are re-read on change and every refresh interval.
+type FileDiscoverer struct {
+	*logger.Logger
+	model.Base
+
+	patterns []string
+	interval time.Duration
+	watcher  *fsnotify.Watcher
+	cache    map[string]time.Time // file path => modification time
+}
+
+func (d *FileDiscoverer) String() string {
+	return "sd promsd file"
+}
+
+func (d *FileDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	d.Info("instance is started")
+	defer d.Info("instance is stopped")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		d.Errorf("fsnotify watcher initialization: %v", err)
+		return
+	}
+	d.watcher = watcher
+	defer d.stopWatcher()
+
+	d.watchDirs()
+	d.refresh(ctx, in)
+
+	tk := time.NewTicker(d.interval)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			d.refresh(ctx, in)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Name == "" || event.Op == fsnotify.Chmod || !d.fileMatches(event.Name) {
+				continue
+			}
+			d.refresh(ctx, in)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			d.Warningf("watch: %v", err)
+		}
+	}
+}
+
+func (d *FileDiscoverer) refresh(ctx context.Context, in chan<- []model.TargetGroup) {
+	var tggs []model.TargetGroup
+	seen := make(map[string]bool)
+
+	for _, file := range d.listFiles() {
+		fi, err := os.Stat(file)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+
+		seen[file] = true
+		if v, ok := d.cache[file]; ok && v.Equal(fi.ModTime()) {
+			continue
+		}
+		d.cache[file] = fi.ModTime()
+
+		groups, err := readFile(file)
+		if err != nil {
+			// keep the previously discovered targets
+			d.Warningf("read '%s': %v", file, err)
+			continue
+		}
+
+		meta := map[string]string{"__meta_filepath": file}
+
+		tggs = append(tggs, &targetGroup{
+			provider: "sd:promsd:file",
+			source:   file,
+			targets:  buildTargets(groups, meta, d.Tags()),
+		})
+	}
+
+	for file := range d.cache {
+		if !seen[file] {
+			delete(d.cache, file)
+			tggs = append(tggs, &targetGroup{provider: "sd:promsd:file", source: file})
+		}
+	}
+
+	send(ctx, in, tggs...)
+}
+
+func (d *FileDiscoverer) listFiles() []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range d.patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, file := range matches {
+			if !seen[file] && isSupportedFile(file) {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+func (d *FileDiscoverer) fileMatches(file string) bool {
+	for _, pattern := range d.patterns {
+		if ok, _ := filepath.Match(pattern, file); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *FileDiscoverer) watchDirs() {
+	seen := make(map[string]bool)
+	for _, pattern := range d.patterns {
+		dir := filepath.Dir(pattern)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if err := d.watcher.Add(dir); err != nil {
+			d.Warningf("start watching '%s': %v", dir, err)
+		}
+	}
+}
+
+func (d *FileDiscoverer) stopWatcher() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// closing the watcher deadlocks unless all events and errors are drained.
+	go func() {
+		for {
+			select {
+			case <-d.watcher.Errors:
+			case <-d.watcher.Events:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	_ = d.watcher.Close()
+}
+
+func readFile(path string) ([]staticConfig, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []staticConfig
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(bs, &groups)
+	default:
+		err = yaml.Unmarshal(bs, &groups)
+	}
+	return groups, err
+}
+
+func isSupportedFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".json", ".yml", ".yaml":
+		return true
+	}
+	return false
+}
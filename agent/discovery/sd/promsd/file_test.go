@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promsd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileDiscoverer(t *testing.T) {
+	tests := map[string]struct {
+		cfg     FileConfig
+		wantErr bool
+	}{
+		"valid config": {
+			cfg: FileConfig{Tags: "promsd", Files: []string{"/etc/targets/*.json", "/etc/targets/*.yml"}},
+		},
+		"files not set": {
+			cfg:     FileConfig{Tags: "promsd"},
+			wantErr: true,
+		},
+		"bad pattern": {
+			cfg:     FileConfig{Tags: "promsd", Files: []string{"/etc/targets/[.json"}},
+			wantErr: true,
+		},
+		"unsupported file extension": {
+			cfg:     FileConfig{Tags: "promsd", Files: []string{"/etc/targets/*"}},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFileDiscoverer(test.cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFileDiscoverer_Discover(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "node.json")
+	yamlFile := filepath.Join(dir, "nginx.yml")
+
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[{"targets": ["10.0.0.1:9100"], "labels": {"job": "node"}}]`), 0644))
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+- targets: ["10.0.0.3:9113"]
+  labels:
+    job: nginx
+`), 0644))
+
+	d, err := NewFileDiscoverer(FileConfig{
+		Tags:  "promsd",
+		Files: []string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yml")},
+	})
+	require.NoError(t, err)
+
+	in, stop := startDiscoverer(d)
+	defer stop()
+
+	tggs := receive(t, in)
+	assert.ElementsMatch(t, []model.TargetGroup{
+		&targetGroup{provider: "sd:promsd:file", source: jsonFile, targets: []model.Target{
+			newTestTarget("10.0.0.1:9100", map[string]string{"job": "node"}, map[string]string{"__meta_filepath": jsonFile}),
+		}},
+		&targetGroup{provider: "sd:promsd:file", source: yamlFile, targets: []model.Target{
+			newTestTarget("10.0.0.3:9113", map[string]string{"job": "nginx"}, map[string]string{"__meta_filepath": yamlFile}),
+		}},
+	}, tggs)
+
+	require.NoError(t, os.Remove(yamlFile))
+
+	assert.Equal(t, []model.TargetGroup{&targetGroup{provider: "sd:promsd:file", source: yamlFile}}, receive(t, in))
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promsd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/logger"
+	"github.com/netdata/go.d.plugin/pkg/web"
+)
+
+func NewHTTPDiscoverer(cfg HTTPConfig) (*HTTPDiscoverer, error) {
+	tags, err := model.ParseTags(cfg.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parse tags: %v", err)
+	}
+
+	if cfg.URL == "" {
+		return nil, errors.New("'url' not set")
+	}
+	if _, err := web.NewHTTPRequest(cfg.Request); err != nil {
+		return nil, fmt.Errorf("create http request: %v", err)
+	}
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout.Duration = time.Second * 10
+	}
+	if cfg.RefreshInterval.Duration == 0 {
+		cfg.RefreshInterval.Duration = time.Second * 60
+	}
+
+	client, err := web.NewHTTPClient(cfg.Client)
+	if err != nil {
+		return nil, fmt.Errorf("create http client: %v", err)
+	}
+
+	d := &HTTPDiscoverer{
+		Logger: logger.New().With(
+			slog.String("component", "discovery sd promsd http"),
+			slog.String("url", cfg.URL),
+		),
+		request:  cfg.Request,
+		interval: cfg.RefreshInterval.Duration,
+		client:   client,
+	}
+	d.Tags().Merge(tags)
+
+	return d, nil
+}
+
+// HTTPDiscoverer polls a Prometheus HTTP SD endpoint. All the endpoint targets are a single target group.
+// The previously discovered targets are kept if the endpoint is not available.
+type HTTPDiscoverer struct {
+	*logger.Logger
+	model.Base
+
+	request  web.Request
+	interval time.Duration
+	client   *http.Client
+}
+
+func (d *HTTPDiscoverer) String() string {
+	return "sd promsd http"
+}
+
+func (d *HTTPDiscoverer) Discover(ctx context.Context, in chan<- []model.TargetGroup) {
+	d.Info("instance is started")
+	defer d.Info("instance is stopped")
+
+	d.refresh(ctx, in)
+
+	tk := time.NewTicker(d.interval)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			d.refresh(ctx, in)
+		}
+	}
+}
+
+func (d *HTTPDiscoverer) refresh(ctx context.Context, in chan<- []model.TargetGroup) {
+	groups, err := d.fetch(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.Warning(err)
+		}
+		return
+	}
+
+	meta := map[string]string{"__meta_url": d.request.URL}
+
+	send(ctx, in, &targetGroup{
+		provider: "sd:promsd:http",
+		source:   d.request.URL,
+		targets:  buildTargets(groups, meta, d.Tags()),
+	})
+}
+
+func (d *HTTPDiscoverer) fetch(ctx context.Context) ([]staticConfig, error) {
+	req, err := web.NewHTTPRequest(d.request)
+	if err != nil {
+		return nil, fmt.Errorf("create http request: %v", err)
+	}
+	req.Header.Set("X-Prometheus-Refresh-Interval-Seconds", strconv.Itoa(int(d.interval.Seconds())))
+
+	resp, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("fetch targets: %v", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch targets: '%s' returned HTTP status code: %d", req.URL, resp.StatusCode)
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "application/json" {
+		return nil, fmt.Errorf("fetch targets: unexpected content type '%s'", resp.Header.Get("Content-Type"))
+	}
+
+	var groups []staticConfig
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return nil, fmt.Errorf("decode targets: %v", err)
+	}
+	return groups, nil
+}
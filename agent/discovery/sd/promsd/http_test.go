@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promsd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+	"github.com/netdata/go.d.plugin/pkg/web"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPDiscoverer(t *testing.T) {
+	tests := map[string]struct {
+		cfg     HTTPConfig
+		wantErr bool
+	}{
+		"valid config": {
+			cfg: HTTPConfig{Tags: "promsd", HTTP: web.HTTP{Request: web.Request{URL: "http://127.0.0.1:8000/targets"}}},
+		},
+		"url not set": {
+			cfg:     HTTPConfig{Tags: "promsd"},
+			wantErr: true,
+		},
+		"invalid tags": {
+			cfg:     HTTPConfig{Tags: "-", HTTP: web.HTTP{Request: web.Request{URL: "http://127.0.0.1:8000/targets"}}},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := NewHTTPDiscoverer(test.cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, time.Minute, d.interval)
+				assert.Equal(t, time.Second*10, d.client.Timeout)
+			}
+		})
+	}
+}
+
+func TestHTTPDiscoverer_Discover(t *testing.T) {
+	var mux sync.Mutex
+	body, status := `[
+  {"targets": ["10.0.0.1:9100", "10.0.0.2:9100"], "labels": {"job": "node"}},
+  {"targets": ["10.0.0.3:9113"], "labels": {"job": "nginx", "env": "prod"}}
+]`, http.StatusOK
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		defer mux.Unlock()
+		assert.Equal(t, "1", r.Header.Get("X-Prometheus-Refresh-Interval-Seconds"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	d, err := NewHTTPDiscoverer(HTTPConfig{
+		Tags:            "promsd",
+		HTTP:            web.HTTP{Request: web.Request{URL: srv.URL}},
+		RefreshInterval: web.Duration{Duration: time.Second},
+	})
+	require.NoError(t, err)
+
+	in, stop := startDiscoverer(d)
+	defer stop()
+
+	meta := map[string]string{"__meta_url": srv.URL}
+	expected := []model.TargetGroup{&targetGroup{
+		provider: "sd:promsd:http",
+		source:   srv.URL,
+		targets: []model.Target{
+			newTestTarget("10.0.0.1:9100", map[string]string{"job": "node"}, meta),
+			newTestTarget("10.0.0.2:9100", map[string]string{"job": "node"}, meta),
+			newTestTarget("10.0.0.3:9113", map[string]string{"job": "nginx", "env": "prod"}, meta),
+		},
+	}}
+	assert.Equal(t, expected, receive(t, in))
+	assert.Equal(t, "sd:promsd:http("+srv.URL+")", expected[0].Source())
+
+	// the targets are kept if the endpoint fails
+	mux.Lock()
+	body, status = "", http.StatusInternalServerError
+	mux.Unlock()
+
+	select {
+	case tggs := <-in:
+		t.Fatalf("unexpected target groups on the endpoint failure: %v", tggs)
+	case <-time.After(time.Millisecond * 1500):
+	}
+
+	mux.Lock()
+	body, status = "[]", http.StatusOK
+	mux.Unlock()
+
+	assert.Equal(t, []model.TargetGroup{&targetGroup{provider: "sd:promsd:http", source: srv.URL}}, receive(t, in))
+}
+
+func newTestTarget(addr string, labels, meta map[string]string) *Target {
+	tgt := &Target{Address: addr, Labels: map[string]any{addressLabel: addr}}
+	for k, v := range labels {
+		tgt.Labels[k] = v
+	}
+	for k, v := range meta {
+		tgt.Labels[k] = v
+	}
+	tgt.hash, _ = calcHash(tgt)
+	tags, _ := model.ParseTags("promsd")
+	tgt.Tags().Merge(tags)
+	return tgt
+}
+
+func startDiscoverer(d model.Discoverer) (chan []model.TargetGroup, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []model.TargetGroup)
+	done := make(chan struct{})
+	go func() { defer close(done); d.Discover(ctx, in) }()
+	return in, func() { cancel(); <-done }
+}
+
+func receive(t *testing.T, in chan []model.TargetGroup) []model.TargetGroup {
+	select {
+	case tggs := <-in:
+		return tggs
+	case <-time.After(time.Second * 5):
+		t.Fatal("discovery timed out")
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promsd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/sd/model"
+
+	"github.com/ilyam8/hashstructure"
+)
+
+const addressLabel = "__address__"
+
+type targetGroup struct {
+	provider string
+	source   string
+	targets  []model.Target
+}
+
+func (g *targetGroup) Provider() string        { return g.provider }
+func (g *targetGroup) Source() string          { return fmt.Sprintf("%s(%s)", g.provider, g.source) }
+func (g *targetGroup) Targets() []model.Target { return g.targets }
+
+// Target is a Prometheus service discovery target.
+// Labels contain the target group labels, the target address ('__address__') and the discoverer meta labels.
+type Target struct {
+	model.Base `hash:"ignore"`
+
+	hash uint64
+
+	Address string
+	Labels  map[string]any
+}
+
+func (t *Target) Hash() uint64 { return t.hash }
+func (t *Target) TUID() string { return fmt.Sprintf("promsd_%s_%d", t.Address, t.hash) }
+
+// staticConfig is a Prometheus target group: the 'http_sd' and 'file_sd' format.
+type staticConfig struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+// buildTargets converts the Prometheus target groups to targets, meta labels are added to every target.
+func buildTargets(groups []staticConfig, meta map[string]string, tags model.Tags) []model.Target {
+	var tgts []model.Target
+	seen := make(map[uint64]bool)
+
+	for _, group := range groups {
+		for _, addr := range group.Targets {
+			if addr == "" {
+				continue
+			}
+
+			tgt := &Target{
+				Address: addr,
+				Labels:  make(map[string]any, len(group.Labels)+len(meta)+1),
+			}
+			for k, v := range group.Labels {
+				tgt.Labels[k] = v
+			}
+			for k, v := range meta {
+				tgt.Labels[k] = v
+			}
+			tgt.Labels[addressLabel] = addr
+
+			hash, err := calcHash(tgt)
+			if err != nil || seen[hash] {
+				continue
+			}
+			seen[hash] = true
+			tgt.hash = hash
+			tgt.Tags().Merge(tags)
+
+			tgts = append(tgts, tgt)
+		}
+	}
+
+	return tgts
+}
+
+func send(ctx context.Context, in chan<- []model.TargetGroup, tggs ...model.TargetGroup) {
+	if len(tggs) == 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case in <- tggs:
+	}
+}
+
+func calcHash(obj any) (uint64, error) {
+	return hashstructure.Hash(obj, nil)
+}
@@ -4,10 +4,12 @@ package sd
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/pipeline"
+	"github.com/netdata/go.d.plugin/agent/secrets"
 	"github.com/netdata/go.d.plugin/logger"
 
 	"gopkg.in/yaml.v2"
@@ -71,6 +73,10 @@ func (d *ServiceDiscovery) addPipeline(ctx context.Context, cf ConfigFile, in ch
 		d.Error(err)
 		return
 	}
+	if err := resolveDiscoverySecrets(cf.Data, &cfg); err != nil {
+		d.Errorf("%s: %v", cf.Source, err)
+		return
+	}
 
 	pl, err := d.sdFactory.create(cfg)
 	if err != nil {
@@ -92,6 +98,28 @@ func (d *ServiceDiscovery) addPipeline(ctx context.Context, cf ConfigFile, in ch
 	d.pipelines[cf.Source] = stop
 }
 
+// resolveDiscoverySecrets resolves the env secret references in the discoverers config (e.g. the consul "acl_token"),
+// the file and cmd references are not allowed. The compose templates are left as is, the jobs resolve their
+// secret references when they are created.
+func resolveDiscoverySecrets(data []byte, cfg *pipeline.Config) error {
+	var raw struct {
+		Discovery map[string]any `yaml:"discovery"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := secrets.ResolveConfigKinds(raw.Discovery, secrets.Env); err != nil {
+		return fmt.Errorf("discovery: resolve secrets: %v", err)
+	}
+
+	bs, err := yaml.Marshal(raw.Discovery)
+	if err != nil {
+		return err
+	}
+	cfg.Discovery = pipeline.DiscoveryConfig{}
+	return yaml.Unmarshal(bs, &cfg.Discovery)
+}
+
 func (d *ServiceDiscovery) removePipeline(cf ConfigFile) {
 	if stop, ok := d.pipelines[cf.Source]; ok {
 		delete(d.pipelines, cf.Source)
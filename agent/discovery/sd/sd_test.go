@@ -7,6 +7,8 @@ import (
 
 	"github.com/netdata/go.d.plugin/agent/discovery/sd/pipeline"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
 
@@ -87,3 +89,41 @@ func prepareEmptyConfigFile(source string) ConfigFile {
 		Source: source,
 	}
 }
+
+func Test_resolveDiscoverySecrets(t *testing.T) {
+	t.Setenv("TEST_SD_CONSUL_TOKEN", "consul-acl-token")
+
+	data := []byte(`
+name: consul
+discovery:
+  consul:
+    - acl_token: ${env:TEST_SD_CONSUL_TOKEN}
+compose:
+  - selector: "*"
+    config:
+      - selector: "*"
+        template: "password: ${env:TEST_SD_CONSUL_TOKEN}"
+`)
+	var cfg pipeline.Config
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+
+	require.NoError(t, resolveDiscoverySecrets(data, &cfg))
+
+	require.Len(t, cfg.Discovery.Consul, 1)
+	assert.Equal(t, "consul-acl-token", cfg.Discovery.Consul[0].ACLToken)
+	assert.Equal(t, "password: ${env:TEST_SD_CONSUL_TOKEN}", cfg.Compose[0].Config[0].Template)
+
+	data = []byte(`
+discovery:
+  consul:
+    - acl_token: ${env:TEST_SD_NOT_SET}
+`)
+	assert.Error(t, resolveDiscoverySecrets(data, &cfg))
+
+	data = []byte(`
+discovery:
+  consul:
+    - acl_token: ${cmd:/usr/bin/id}
+`)
+	assert.Error(t, resolveDiscoverySecrets(data, &cfg), "cmd reference is resolved")
+}
@@ -88,11 +88,39 @@ func (m *Manager) flush() {
 		return
 	}
 
-	f, err := os.Create(m.path)
+	_ = writeFileAtomic(m.path, bs)
+}
+
+// writeFileAtomic writes the data to a temporary file and renames it, a crash during the write doesn't corrupt the file.
+// An existing file keeps its mode, a new one gets the os.Create mode (0666 before umask).
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	// a leftover of a crash keeps its mode if opened without O_EXCL
+	_ = os.Remove(tmp)
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
 	if err != nil {
-		return
+		return err
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	if fi, err := os.Stat(path); err == nil {
+		if err := f.Chmod(fi.Mode().Perm()); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
 	}
-	defer func() { _ = f.Close() }()
 
-	_, _ = f.Write(bs)
+	return os.Rename(tmp, path)
 }
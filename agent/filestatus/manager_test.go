@@ -120,3 +120,32 @@ func TestManager_Run(t *testing.T) {
 		})
 	}
 }
+
+func Test_writeFileAtomic_FileMode(t *testing.T) {
+	dir := t.TempDir()
+
+	// the mode os.Create gives with the current umask
+	created := path.Join(dir, "created")
+	require.NoError(t, os.WriteFile(created, nil, 0666))
+	fi, err := os.Stat(created)
+	require.NoError(t, err)
+	wantNewMode := fi.Mode().Perm()
+
+	newFile := path.Join(dir, "new")
+	require.NoError(t, writeFileAtomic(newFile, []byte("data")))
+	fi, err = os.Stat(newFile)
+	require.NoError(t, err)
+	assert.Equal(t, wantNewMode, fi.Mode().Perm())
+
+	existing := path.Join(dir, "existing")
+	require.NoError(t, os.WriteFile(existing, nil, 0640))
+	require.NoError(t, os.Chmod(existing, 0640))
+	require.NoError(t, writeFileAtomic(existing, []byte("data")))
+	fi, err = os.Stat(existing)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+
+	bs, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(bs))
+}
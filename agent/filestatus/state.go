@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package filestatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/netdata/go.d.plugin/logger"
+)
+
+const (
+	defaultMaxStateSize      = 64 * 1024
+	defaultMaxStateTotalSize = 4 * 1024 * 1024
+	defaultStateExpiry       = time.Hour * 24 * 7
+)
+
+// NewStateManager creates a modules jobs state manager, the previously saved state is loaded from the path.
+func NewStateManager(path string) *StateManager {
+	m := &StateManager{
+		Logger: logger.New().With(
+			slog.String("component", "filestatus state manager"),
+		),
+		MaxStateSize:      defaultMaxStateSize,
+		MaxStateTotalSize: defaultMaxStateTotalSize,
+		path:              path,
+		items:             make(map[string]stateItem),
+		flushEvery:        time.Second * 5,
+		flushCh:           make(chan struct{}, 1),
+		now:               time.Now,
+	}
+	m.load()
+	return m
+}
+
+// StateManager keeps small per-job state blobs (JSON) keyed by the job full name and periodically flushes them to a file.
+// It implements the module.StateStore interface.
+type StateManager struct {
+	*logger.Logger
+
+	// MaxStateSize is the maximum size of a single job state.
+	MaxStateSize int
+	// MaxStateTotalSize is the maximum size of all jobs states.
+	MaxStateTotalSize int
+
+	path string
+
+	mux   sync.Mutex
+	items map[string]stateItem
+	size  int
+
+	flushEvery time.Duration
+	flushCh    chan struct{}
+	now        func() time.Time
+}
+
+type stateItem struct {
+	Data    json.RawMessage `json:"data"`
+	Updated int64           `json:"updated"`
+}
+
+func (m *StateManager) Run(ctx context.Context) {
+	m.Info("instance is started")
+	defer func() { m.Info("instance is stopped") }()
+
+	tk := time.NewTicker(m.flushEvery)
+	defer tk.Stop()
+	defer m.flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			m.tryFlush()
+		}
+	}
+}
+
+func (m *StateManager) LoadState(key string) ([]byte, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	item, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), item.Data...), true
+}
+
+func (m *StateManager) SaveState(key string, data []byte) error {
+	if len(data) > m.MaxStateSize {
+		return fmt.Errorf("state size %d exceeds the limit %d", len(data), m.MaxStateSize)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("state is not valid JSON")
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	size := m.size - len(m.items[key].Data) + len(data)
+	if size > m.MaxStateTotalSize {
+		return fmt.Errorf("total state size %d exceeds the limit %d", size, m.MaxStateTotalSize)
+	}
+
+	m.items[key] = stateItem{Data: append([]byte(nil), data...), Updated: m.now().Unix()}
+	m.size = size
+	m.triggerFlush()
+
+	return nil
+}
+
+func (m *StateManager) load() {
+	bs, err := os.ReadFile(m.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			m.Warningf("couldn't load state file: %v", err)
+		}
+		return
+	}
+
+	var items map[string]stateItem
+	if err := json.Unmarshal(bs, &items); err != nil {
+		m.Warningf("couldn't load state file '%s': %v", m.path, err)
+		return
+	}
+
+	// the state of the jobs that haven't run for a long time (e.g. removed) is dropped
+	expired := m.now().Add(-defaultStateExpiry).Unix()
+	for key, item := range items {
+		if item.Updated < expired || len(item.Data) > m.MaxStateSize {
+			continue
+		}
+		m.items[key] = item
+		m.size += len(item.Data)
+	}
+}
+
+func (m *StateManager) triggerFlush() {
+	select {
+	case m.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+func (m *StateManager) tryFlush() {
+	select {
+	case <-m.flushCh:
+		m.flush()
+	default:
+	}
+}
+
+func (m *StateManager) flush() {
+	m.mux.Lock()
+	bs, err := json.MarshalIndent(m.items, "", " ")
+	m.mux.Unlock()
+	if err != nil {
+		return
+	}
+
+	if err := writeFileAtomic(m.path, bs); err != nil {
+		m.Warningf("couldn't save state file: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package filestatus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateManager_SaveState(t *testing.T) {
+	tests := map[string]struct {
+		maxSize      int
+		maxTotalSize int
+		saves        map[string]string
+		wantErr      map[string]bool
+	}{
+		"valid states": {
+			saves: map[string]string{"job1": `{"offset":1}`, "job2": `{"offset":2}`},
+		},
+		"invalid JSON": {
+			saves:   map[string]string{"job1": `{"offset":`},
+			wantErr: map[string]bool{"job1": true},
+		},
+		"state exceeds size limit": {
+			maxSize: 10,
+			saves:   map[string]string{"job1": `{"offset":1}`},
+			wantErr: map[string]bool{"job1": true},
+		},
+		"states exceed total size limit": {
+			maxTotalSize: 15,
+			saves:        map[string]string{"job1": `{"offset":1}`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mgr := NewStateManager(filepath.Join(t.TempDir(), "state.json"))
+			if test.maxSize > 0 {
+				mgr.MaxStateSize = test.maxSize
+			}
+			if test.maxTotalSize > 0 {
+				mgr.MaxStateTotalSize = test.maxTotalSize
+			}
+
+			for key, data := range test.saves {
+				err := mgr.SaveState(key, []byte(data))
+				if test.wantErr[key] {
+					assert.Error(t, err)
+					_, ok := mgr.LoadState(key)
+					assert.False(t, ok)
+					continue
+				}
+				require.NoError(t, err)
+				got, ok := mgr.LoadState(key)
+				require.True(t, ok)
+				assert.Equal(t, data, string(got))
+			}
+
+			if test.maxTotalSize > 0 {
+				// the same key is replaced, not added
+				assert.NoError(t, mgr.SaveState("job1", []byte(`{"offset":2}`)))
+				assert.Error(t, mgr.SaveState("job2", []byte(`{"offset":2}`)))
+			}
+		})
+	}
+}
+
+func TestStateManager_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	mgr := NewStateManager(path)
+	mgr.flushEvery = time.Millisecond * 100
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { defer close(done); mgr.Run(ctx) }()
+
+	require.NoError(t, mgr.SaveState("weblog_nginx", []byte(`{"path":"/var/log/nginx/access.log","offset":100}`)))
+	time.Sleep(time.Millisecond * 300)
+	cancel()
+	<-done
+
+	bs, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(bs), `"weblog_nginx"`), string(bs))
+
+	// restored after restart
+	mgr = NewStateManager(path)
+	data, ok := mgr.LoadState("weblog_nginx")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"path":"/var/log/nginx/access.log","offset":100}`, string(data))
+}
+
+func TestNewStateManager_DropsExpiredStates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	updated := time.Now().Add(-defaultStateExpiry - time.Hour).Unix()
+	data := `{"job1": {"data": {"offset": 1}, "updated": ` + strconv.FormatInt(updated, 10) + `}, "job2": {"data": {"offset": 2}, "updated": ` + strconv.FormatInt(time.Now().Unix(), 10) + `}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	mgr := NewStateManager(path)
+
+	_, ok := mgr.LoadState("job1")
+	assert.False(t, ok)
+	_, ok = mgr.LoadState("job2")
+	assert.True(t, ok)
+}
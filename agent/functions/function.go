@@ -3,22 +3,24 @@
 package functions
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/csv"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type Function struct {
-	key     string
-	UID     string
-	Timeout time.Duration
-	Name    string
-	Args    []string
-	Payload []byte
+	ctx       context.Context
+	responded *atomic.Bool
+	key       string
+	UID       string
+	Timeout   time.Duration
+	Name      string
+	Args      []string
+	Payload   []byte
 }
 
 func (f *Function) String() string {
@@ -26,6 +28,25 @@ func (f *Function) String() string {
 		f.key, f.UID, f.Timeout, f.Name, f.Args, string(f.Payload))
 }
 
+// Context returns the function call context. It is done when the call times out,
+// is cancelled by Netdata (FUNCTION_CANCEL) or the manager is stopped.
+// Once it is done the manager sends the result, the function should return without sending one.
+func (f *Function) Context() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
+// TryRespond reports whether the caller may send the call result. It returns true only once per call,
+// both the function and the manager (timeout, cancellation, panic) call it before sending a result.
+func (f *Function) TryRespond() bool {
+	if f.responded == nil {
+		return true
+	}
+	return f.responded.CompareAndSwap(false, true)
+}
+
 func parseFunction(s string) (*Function, error) {
 	r := csv.NewReader(strings.NewReader(s))
 	r.Comma = ' '
@@ -46,32 +67,13 @@ func parseFunction(s string) (*Function, error) {
 	cmd := strings.Split(parts[3], " ")
 
 	fn := &Function{
-		key:     parts[0],
-		UID:     parts[1],
-		Timeout: time.Duration(timeout) * time.Second,
-		Name:    cmd[0],
-		Args:    cmd[1:],
-	}
-
-	return fn, nil
-}
-
-func parseFunctionWithPayload(s string, sc *bufio.Scanner) (*Function, error) {
-	fn, err := parseFunction(s)
-	if err != nil {
-		return nil, err
-	}
-
-	var n int
-	var buf bytes.Buffer
-	for sc.Scan() && sc.Text() != "FUNCTION_PAYLOAD_END" {
-		if n++; n > 1 {
-			buf.WriteString("\n")
-		}
-		buf.WriteString(sc.Text())
+		responded: &atomic.Bool{},
+		key:       parts[0],
+		UID:       parts[1],
+		Timeout:   time.Duration(timeout) * time.Second,
+		Name:      cmd[0],
+		Args:      cmd[1:],
 	}
 
-	fn.Payload = append(fn.Payload, buf.Bytes()...)
-
 	return fn, nil
 }
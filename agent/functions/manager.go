@@ -4,13 +4,19 @@ package functions
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"strings"
 	"sync"
 
+	"github.com/netdata/go.d.plugin/agent/netdataapi"
+	"github.com/netdata/go.d.plugin/agent/safewriter"
 	"github.com/netdata/go.d.plugin/logger"
 
 	"github.com/mattn/go-isatty"
@@ -19,23 +25,41 @@ import (
 
 var isTerminal = isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsTerminal(os.Stdin.Fd())
 
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 100
+)
+
+var errTimeout = errors.New("function call timed out")
+
 func NewManager() *Manager {
 	return &Manager{
 		Logger: logger.New().With(
 			slog.String("component", "functions manager"),
 		),
 		Input:            os.Stdin,
+		Out:              safewriter.Stdout,
+		Workers:          defaultWorkers,
 		mux:              &sync.Mutex{},
 		FunctionRegistry: make(map[string]func(Function)),
+		calls:            make(map[string]context.CancelFunc),
 	}
 }
 
 type Manager struct {
 	*logger.Logger
 
-	Input            io.Reader
+	Input io.Reader
+	// Out is used to send the timeout, cancellation and error results, the functions send their own results.
+	Out io.Writer
+	// Workers is the number of functions executed concurrently.
+	Workers          int
 	mux              *sync.Mutex
 	FunctionRegistry map[string]func(Function)
+
+	api   *netdataapi.API
+	queue chan *Function
+	calls map[string]context.CancelFunc // queued and running calls by UID, guarded by mux
 }
 
 func (m *Manager) Register(name string, fn func(Function)) {
@@ -46,6 +70,12 @@ func (m *Manager) Register(name string, fn func(Function)) {
 	m.addFunction(name, fn)
 }
 
+func (m *Manager) Unregister(name string) {
+	if m.removeFunction(name) {
+		m.Debugf("unregistering function '%s'", name)
+	}
+}
+
 func (m *Manager) Run(ctx context.Context) {
 	m.Info("instance is started")
 	defer func() { m.Info("instance is stopped") }()
@@ -61,59 +91,200 @@ func (m *Manager) Run(ctx context.Context) {
 
 		go func() { <-ctx.Done(); r.Cancel() }()
 
+		m.api = netdataapi.New(m.Out)
+		m.queue = make(chan *Function, defaultQueueSize)
+
+		workers := m.Workers
+		if workers <= 0 {
+			workers = 1
+		}
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() { defer wg.Done(); m.worker(ctx) }()
+		}
+
 		wg.Add(1)
-		go func() { defer wg.Done(); m.run(r) }()
+		go func() { defer wg.Done(); m.run(ctx, r) }()
 
 		wg.Wait()
 		_ = r.Close()
+
+		// respond to the functions that were not executed before stopping
+		for len(m.queue) > 0 {
+			fn := <-m.queue
+			m.removeCall(fn.UID)
+			m.respondContextError(fn)
+		}
 	}
 
 	<-ctx.Done()
 }
 
-func (m *Manager) run(r io.Reader) {
+func (m *Manager) run(ctx context.Context, r io.Reader) {
 	sc := bufio.NewScanner(r)
 
+	// the function waiting for FUNCTION_PAYLOAD_END
+	var pending *Function
+	var payload bytes.Buffer
+
 	for sc.Scan() {
 		text := sc.Text()
 
-		var fn *Function
-		var err error
+		if pending != nil {
+			if text == "FUNCTION_PAYLOAD_END" {
+				pending.Payload = append(pending.Payload, payload.Bytes()...)
+				m.dispatch(ctx, pending)
+				pending = nil
+				continue
+			}
+			if !isFunctionCommand(text) {
+				if payload.Len() > 0 {
+					payload.WriteString("\n")
+				}
+				payload.WriteString(text)
+				continue
+			}
+			// a new command before FUNCTION_PAYLOAD_END: the payload is incomplete, discard the function
+			m.Warningf("discarding function '%s': got '%s' before FUNCTION_PAYLOAD_END", pending.Name, text)
+			m.respondError(pending, "function '%s': incomplete payload", pending.Name)
+			pending = nil
+		}
 
-		// FIXME:  if we are waiting for FUNCTION_PAYLOAD_END and a new FUNCTION* appears,
-		// we need to discard the current one and switch to the new one
 		switch {
 		case strings.HasPrefix(text, "FUNCTION "):
-			fn, err = parseFunction(text)
+			fn, err := parseFunction(text)
+			if err != nil {
+				m.Warningf("parse function: %v ('%s')", err, text)
+				continue
+			}
+			m.dispatch(ctx, fn)
 		case strings.HasPrefix(text, "FUNCTION_PAYLOAD "):
-			fn, err = parseFunctionWithPayload(text, sc)
+			fn, err := parseFunction(text)
+			if err != nil {
+				m.Warningf("parse function: %v ('%s')", err, text)
+				continue
+			}
+			pending = fn
+			payload.Reset()
+		case strings.HasPrefix(text, "FUNCTION_CANCEL "):
+			m.cancelCall(strings.TrimSpace(strings.TrimPrefix(text, "FUNCTION_CANCEL ")))
 		case text == "":
 			continue
 		default:
 			m.Warningf("unexpected line: '%s'", text)
-			continue
 		}
+	}
+}
 
-		if err != nil {
-			m.Warningf("parse function: %v ('%s')", err, text)
-			continue
-		}
+func (m *Manager) dispatch(ctx context.Context, fn *Function) {
+	function, ok := m.lookupFunction(fn.Name)
+	if !ok {
+		m.Infof("skipping execution of '%s': unregistered function", fn.Name)
+		m.respondError(fn, "function '%s' is not registered", fn.Name)
+		return
+	}
+	if function == nil {
+		m.Warningf("skipping execution of '%s': nil function registered", fn.Name)
+		m.respondError(fn, "function '%s' is not registered", fn.Name)
+		return
+	}
 
-		function, ok := m.lookupFunction(fn.Name)
-		if !ok {
-			m.Infof("skipping execution of '%s': unregistered function", fn.Name)
-			continue
-		}
-		if function == nil {
-			m.Warningf("skipping execution of '%s': nil function registered", fn.Name)
-			continue
+	var cancel context.CancelFunc
+	if fn.Timeout > 0 {
+		fn.ctx, cancel = context.WithTimeoutCause(ctx, fn.Timeout, errTimeout)
+	} else {
+		fn.ctx, cancel = context.WithCancel(ctx)
+	}
+
+	if !m.addCall(fn.UID, cancel) {
+		cancel()
+		m.Warningf("skipping execution of '%s': duplicate transaction '%s'", fn.Name, fn.UID)
+		return
+	}
+
+	select {
+	case m.queue <- fn:
+	default:
+		m.removeCall(fn.UID)
+		cancel()
+		m.Warningf("skipping execution of '%s': too many pending functions", fn.Name)
+		m.respondError(fn, "function '%s': too many pending functions", fn.Name)
+	}
+}
+
+func (m *Manager) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case fn := <-m.queue:
+			m.execute(fn)
 		}
+	}
+}
+
+func (m *Manager) execute(fn *Function) {
+	defer m.removeCall(fn.UID)
+
+	// cancelled or timed out while waiting in the queue
+	if fn.ctx.Err() != nil {
+		m.respondContextError(fn)
+		return
+	}
+
+	function, ok := m.lookupFunction(fn.Name)
+	if !ok || function == nil {
+		m.respondError(fn, "function '%s' is not registered", fn.Name)
+		return
+	}
+
+	m.Debugf("executing function: '%s'", fn.String())
 
-		m.Debugf("executing function: '%s'", fn.String())
+	done := make(chan any, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- r
+			}
+			close(done)
+		}()
 		function(*fn)
+	}()
+
+	select {
+	case r, ok := <-done:
+		if ok {
+			m.Errorf("function '%s' panicked: %v", fn.Name, r)
+			m.respondError(fn, "function '%s' failed", fn.Name)
+		} else if fn.ctx.Err() != nil {
+			// the function returned because the call timed out or was cancelled
+			m.respondContextError(fn)
+		}
+	case <-fn.ctx.Done():
+		// the function goroutine is abandoned, it is expected to return once it notices the done context
+		m.respondContextError(fn)
 	}
 }
 
+func (m *Manager) respondContextError(fn *Function) {
+	if errors.Is(context.Cause(fn.ctx), errTimeout) {
+		m.Warningf("function '%s' (transaction '%s') timed out after %s", fn.Name, fn.UID, fn.Timeout)
+		m.respondError(fn, "function '%s' timed out after %s", fn.Name, fn.Timeout)
+		return
+	}
+	m.Infof("function '%s' (transaction '%s') cancelled", fn.Name, fn.UID)
+	m.respondError(fn, "function '%s' cancelled", fn.Name)
+}
+
+func (m *Manager) respondError(fn *Function, format string, a ...any) {
+	if !fn.TryRespond() {
+		m.Debugf("function '%s' (transaction '%s') already responded", fn.Name, fn.UID)
+		return
+	}
+	bs, _ := json.Marshal(map[string]string{"error": fmt.Sprintf(format, a...)})
+	_ = m.api.FunctionResultReject(fn.UID, "application/json", string(bs))
+}
+
 func (m *Manager) addFunction(name string, fn func(Function)) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
@@ -126,6 +297,15 @@ func (m *Manager) addFunction(name string, fn func(Function)) {
 	m.FunctionRegistry[name] = fn
 }
 
+func (m *Manager) removeFunction(name string) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	_, ok := m.FunctionRegistry[name]
+	delete(m.FunctionRegistry, name)
+	return ok
+}
+
 func (m *Manager) lookupFunction(name string) (func(Function), bool) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
@@ -133,3 +313,42 @@ func (m *Manager) lookupFunction(name string) (func(Function), bool) {
 	f, ok := m.FunctionRegistry[name]
 	return f, ok
 }
+
+func (m *Manager) addCall(uid string, cancel context.CancelFunc) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.calls[uid]; ok {
+		return false
+	}
+	m.calls[uid] = cancel
+	return true
+}
+
+func (m *Manager) removeCall(uid string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if cancel, ok := m.calls[uid]; ok {
+		cancel()
+		delete(m.calls, uid)
+	}
+}
+
+func (m *Manager) cancelCall(uid string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if cancel, ok := m.calls[uid]; ok {
+		m.Debugf("cancelling transaction '%s'", uid)
+		cancel()
+	} else {
+		m.Debugf("cancel: unknown transaction '%s'", uid)
+	}
+}
+
+func isFunctionCommand(s string) bool {
+	return strings.HasPrefix(s, "FUNCTION ") ||
+		strings.HasPrefix(s, "FUNCTION_PAYLOAD ") ||
+		strings.HasPrefix(s, "FUNCTION_CANCEL ")
+}
@@ -3,13 +3,20 @@
 package functions
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/netdata/go.d.plugin/agent/netdataapi"
+	"github.com/netdata/go.d.plugin/agent/safewriter"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewManager(t *testing.T) {
@@ -76,6 +83,22 @@ func TestManager_Register(t *testing.T) {
 	}
 }
 
+func TestManager_Unregister(t *testing.T) {
+	mgr := NewManager()
+
+	mgr.Register("fn1", func(Function) {})
+	mgr.Register("fn2", func(Function) {})
+
+	mgr.Unregister("fn1")
+	mgr.Unregister("not_registered")
+
+	var got []string
+	for name := range mgr.FunctionRegistry {
+		got = append(got, name)
+	}
+	assert.Equal(t, []string{"fn2"}, got)
+}
+
 func TestManager_Run(t *testing.T) {
 	tests := map[string]struct {
 		register []string
@@ -85,12 +108,12 @@ func TestManager_Run(t *testing.T) {
 		"valid function: single": {
 			register: []string{"fn1"},
 			input: `
-FUNCTION UID 1 "fn1 arg1 arg2"
+FUNCTION UID1 1 "fn1 arg1 arg2"
 `,
 			expected: []Function{
 				{
 					key:     "FUNCTION",
-					UID:     "UID",
+					UID:     "UID1",
 					Timeout: time.Second,
 					Name:    "fn1",
 					Args:    []string{"arg1", "arg2"},
@@ -101,13 +124,13 @@ FUNCTION UID 1 "fn1 arg1 arg2"
 		"valid function: multiple": {
 			register: []string{"fn1", "fn2"},
 			input: `
-FUNCTION UID 1 "fn1 arg1 arg2"
-FUNCTION UID 1 "fn2 arg1 arg2"
+FUNCTION UID1 1 "fn1 arg1 arg2"
+FUNCTION UID2 1 "fn2 arg1 arg2"
 `,
 			expected: []Function{
 				{
 					key:     "FUNCTION",
-					UID:     "UID",
+					UID:     "UID1",
 					Timeout: time.Second,
 					Name:    "fn1",
 					Args:    []string{"arg1", "arg2"},
@@ -115,7 +138,7 @@ FUNCTION UID 1 "fn2 arg1 arg2"
 				},
 				{
 					key:     "FUNCTION",
-					UID:     "UID",
+					UID:     "UID2",
 					Timeout: time.Second,
 					Name:    "fn2",
 					Args:    []string{"arg1", "arg2"},
@@ -126,7 +149,7 @@ FUNCTION UID 1 "fn2 arg1 arg2"
 		"valid function: single with payload": {
 			register: []string{"fn1", "fn2"},
 			input: `
-FUNCTION_PAYLOAD UID 1 "fn1 arg1 arg2"
+FUNCTION_PAYLOAD UID1 1 "fn1 arg1 arg2"
 payload line1
 payload line2
 FUNCTION_PAYLOAD_END
@@ -134,7 +157,7 @@ FUNCTION_PAYLOAD_END
 			expected: []Function{
 				{
 					key:     "FUNCTION_PAYLOAD",
-					UID:     "UID",
+					UID:     "UID1",
 					Timeout: time.Second,
 					Name:    "fn1",
 					Args:    []string{"arg1", "arg2"},
@@ -145,12 +168,12 @@ FUNCTION_PAYLOAD_END
 		"valid function: multiple with payload": {
 			register: []string{"fn1", "fn2"},
 			input: `
-FUNCTION_PAYLOAD UID 1 "fn1 arg1 arg2"
+FUNCTION_PAYLOAD UID1 1 "fn1 arg1 arg2"
 payload line1
 payload line2
 FUNCTION_PAYLOAD_END
 
-FUNCTION_PAYLOAD UID 1 "fn2 arg1 arg2"
+FUNCTION_PAYLOAD UID2 1 "fn2 arg1 arg2"
 payload line3
 payload line4
 FUNCTION_PAYLOAD_END
@@ -158,7 +181,7 @@ FUNCTION_PAYLOAD_END
 			expected: []Function{
 				{
 					key:     "FUNCTION_PAYLOAD",
-					UID:     "UID",
+					UID:     "UID1",
 					Timeout: time.Second,
 					Name:    "fn1",
 					Args:    []string{"arg1", "arg2"},
@@ -166,7 +189,7 @@ FUNCTION_PAYLOAD_END
 				},
 				{
 					key:     "FUNCTION_PAYLOAD",
-					UID:     "UID",
+					UID:     "UID2",
 					Timeout: time.Second,
 					Name:    "fn2",
 					Args:    []string{"arg1", "arg2"},
@@ -177,15 +200,15 @@ FUNCTION_PAYLOAD_END
 		"valid function: multiple with and without payload": {
 			register: []string{"fn1", "fn2", "fn3", "fn4"},
 			input: `
-FUNCTION_PAYLOAD UID 1 "fn1 arg1 arg2"
+FUNCTION_PAYLOAD UID1 1 "fn1 arg1 arg2"
 payload line1
 payload line2
 FUNCTION_PAYLOAD_END
 
-FUNCTION UID 1 "fn2 arg1 arg2"
-FUNCTION UID 1 "fn3 arg1 arg2"
+FUNCTION UID2 1 "fn2 arg1 arg2"
+FUNCTION UID3 1 "fn3 arg1 arg2"
 
-FUNCTION_PAYLOAD UID 1 "fn4 arg1 arg2"
+FUNCTION_PAYLOAD UID4 1 "fn4 arg1 arg2"
 payload line3
 payload line4
 FUNCTION_PAYLOAD_END
@@ -193,7 +216,7 @@ FUNCTION_PAYLOAD_END
 			expected: []Function{
 				{
 					key:     "FUNCTION_PAYLOAD",
-					UID:     "UID",
+					UID:     "UID1",
 					Timeout: time.Second,
 					Name:    "fn1",
 					Args:    []string{"arg1", "arg2"},
@@ -201,7 +224,7 @@ FUNCTION_PAYLOAD_END
 				},
 				{
 					key:     "FUNCTION",
-					UID:     "UID",
+					UID:     "UID2",
 					Timeout: time.Second,
 					Name:    "fn2",
 					Args:    []string{"arg1", "arg2"},
@@ -209,7 +232,7 @@ FUNCTION_PAYLOAD_END
 				},
 				{
 					key:     "FUNCTION",
-					UID:     "UID",
+					UID:     "UID3",
 					Timeout: time.Second,
 					Name:    "fn3",
 					Args:    []string{"arg1", "arg2"},
@@ -217,7 +240,7 @@ FUNCTION_PAYLOAD_END
 				},
 				{
 					key:     "FUNCTION_PAYLOAD",
-					UID:     "UID",
+					UID:     "UID4",
 					Timeout: time.Second,
 					Name:    "fn4",
 					Args:    []string{"arg1", "arg2"},
@@ -252,6 +275,8 @@ FUNCTION_PAYLOAD_END
 
 			select {
 			case <-done:
+				// the functions are executed concurrently
+				sort.Slice(mock.executed, func(i, j int) bool { return mock.executed[i].Name < mock.executed[j].Name })
 				assert.Equal(t, test.expected, mock.executed)
 			case <-tk.C:
 				t.Errorf("timed out after %s", timeout)
@@ -261,9 +286,205 @@ FUNCTION_PAYLOAD_END
 }
 
 type mockFunctionExecutor struct {
+	mux      sync.Mutex
 	executed []Function
 }
 
 func (m *mockFunctionExecutor) execute(fn Function) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	fn.ctx = nil
+	fn.responded = nil
 	m.executed = append(m.executed, fn)
 }
+
+func TestManager_Run_Results(t *testing.T) {
+	tests := map[string]struct {
+		input        string
+		wantExecuted []string
+		wantResults  map[string]string
+	}{
+		"timeout": {
+			input: `
+FUNCTION UID1 1 "slow"
+`,
+			wantResults: map[string]string{"UID1": "function 'slow' timed out after 1s"},
+		},
+		"cancel": {
+			input: `
+FUNCTION UID1 10 "slow"
+FUNCTION_CANCEL UID1
+`,
+			wantResults: map[string]string{"UID1": "function 'slow' cancelled"},
+		},
+		"panic": {
+			input: `
+FUNCTION UID1 1 "panic"
+`,
+			wantExecuted: []string{"panic"},
+			wantResults:  map[string]string{"UID1": "function 'panic' failed"},
+		},
+		"unregistered function": {
+			input: `
+FUNCTION UID1 1 "unknown"
+`,
+			wantResults: map[string]string{"UID1": "function 'unknown' is not registered"},
+		},
+		"new function before payload end": {
+			input: `
+FUNCTION_PAYLOAD UID1 1 "fast"
+payload line1
+FUNCTION UID2 1 "fast"
+`,
+			wantExecuted: []string{"fast"},
+			wantResults:  map[string]string{"UID1": "function 'fast': incomplete payload"},
+		},
+		"slow function doesn't block others": {
+			input: `
+FUNCTION UID1 10 "slow"
+FUNCTION UID2 1 "fast"
+FUNCTION UID3 1 "fast"
+`,
+			wantExecuted: []string{"fast", "fast"},
+			// cancelled when the manager stops
+			wantResults: map[string]string{"UID1": "function 'slow' cancelled"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			mgr := NewManager()
+			mgr.Input = strings.NewReader(test.input)
+			mgr.Out = safewriter.New(&buf)
+
+			var mux sync.Mutex
+			var executed []string
+			exec := func(fn Function) {
+				mux.Lock()
+				executed = append(executed, fn.Name)
+				mux.Unlock()
+			}
+
+			mgr.Register("fast", func(fn Function) { exec(fn) })
+			mgr.Register("panic", func(fn Function) { exec(fn); panic("boom") })
+			// not recorded: the cancelled function may never start
+			mgr.Register("slow", func(fn Function) { <-fn.Context().Done() })
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			defer cancel()
+
+			done := make(chan struct{})
+			go func() { defer close(done); mgr.Run(ctx) }()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out waiting for the manager to stop")
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+			sort.Strings(executed)
+			assert.Equal(t, test.wantExecuted, executed)
+			assert.Equal(t, test.wantResults, parseRejectResults(t, buf.String()))
+		})
+	}
+}
+
+func TestManager_Run_SingleResult(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		register func(api *netdataapi.API) func(Function)
+		wantCode string
+	}{
+		"function responded before the timeout": {
+			input: `
+FUNCTION UID1 1 "fn"
+`,
+			register: func(api *netdataapi.API) func(Function) {
+				return func(fn Function) {
+					if fn.TryRespond() {
+						_ = api.FunctionResultSuccess(fn.UID, "application/json", "{}")
+					}
+					<-fn.Context().Done()
+				}
+			},
+			wantCode: "1",
+		},
+		"abandoned function responds after the timeout": {
+			input: `
+FUNCTION UID1 1 "fn"
+`,
+			register: func(api *netdataapi.API) func(Function) {
+				return func(fn Function) {
+					<-fn.Context().Done()
+					time.Sleep(time.Millisecond * 100)
+					if fn.TryRespond() {
+						_ = api.FunctionResultSuccess(fn.UID, "application/json", "{}")
+					}
+				}
+			},
+			wantCode: "0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			mgr := NewManager()
+			mgr.Input = strings.NewReader(test.input)
+			mgr.Out = safewriter.New(&buf)
+
+			returned := make(chan struct{}, 1)
+			fn := test.register(netdataapi.New(mgr.Out))
+			mgr.Register("fn", func(f Function) { defer func() { returned <- struct{}{} }(); fn(f) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			defer cancel()
+
+			mgr.Run(ctx)
+
+			select {
+			case <-returned:
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out waiting for the function to return")
+			}
+
+			var codes []string
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if strings.HasPrefix(line, "FUNCTION_RESULT_BEGIN UID1 ") {
+					codes = append(codes, strings.Fields(line)[2])
+				}
+			}
+			assert.Equal(t, []string{test.wantCode}, codes)
+		})
+	}
+}
+
+func parseRejectResults(t *testing.T, output string) map[string]string {
+	results := make(map[string]string)
+
+	lines := strings.Split(output, "\n")
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "FUNCTION_RESULT_BEGIN ") {
+			continue
+		}
+		parts := strings.Fields(lines[i])
+		require.Len(t, parts, 5)
+		assert.Equal(t, "0", parts[2])
+		require.Less(t, i+1, len(lines))
+
+		var v struct {
+			Error string `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(lines[i+1]), &v))
+		results[parts[1]] = v.Error
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+	return results
+}
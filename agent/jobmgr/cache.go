@@ -24,6 +24,7 @@ type (
 		cancel  context.CancelFunc
 		timeout int
 		retries int
+		attempt int
 	}
 )
 
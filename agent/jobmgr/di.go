@@ -4,6 +4,7 @@ package jobmgr
 
 import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/functions"
 	"github.com/netdata/go.d.plugin/agent/vnodes"
 )
 
@@ -25,8 +26,18 @@ type StatusStore interface {
 	Contains(cfg confgroup.Config, states ...string) bool
 }
 
+type StateStore interface {
+	LoadState(key string) ([]byte, bool)
+	SaveState(key string, data []byte) error
+}
+
 type Dyncfg interface {
 	Register(cfg confgroup.Config)
 	Unregister(cfg confgroup.Config)
 	UpdateStatus(cfg confgroup.Config, status, payload string)
 }
+
+type FunctionRegistry interface {
+	Register(name string, reg func(functions.Function))
+	Unregister(name string)
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/netdata/go.d.plugin/agent/functions"
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+// registerJobFunctions registers the job module functions as "<job full name>:<function name>".
+// The job announces them to Netdata with its chart, and they are gone in Netdata when the job charts are obsolete.
+func (m *Manager) registerJobFunctions(job *module.Job) {
+	fns := job.Functions()
+	if len(fns) == 0 {
+		return
+	}
+
+	var names []string
+	for name, fn := range fns {
+		if fn.Handler == nil {
+			continue
+		}
+		fullName := job.FunctionName(name)
+		fn := fn
+
+		m.FunctionRegistry.Register(fullName, func(f functions.Function) { m.execJobFunction(f, fn) })
+
+		names = append(names, fullName)
+	}
+	sort.Strings(names)
+
+	m.Debugf("%s[%s] registered functions: %s", job.ModuleName(), job.Name(), strings.Join(names, ", "))
+	m.jobFunctions[job.FullName()] = names
+}
+
+func (m *Manager) unregisterJobFunctions(fullName string) {
+	for _, name := range m.jobFunctions[fullName] {
+		m.FunctionRegistry.Unregister(name)
+	}
+	delete(m.jobFunctions, fullName)
+}
+
+func (m *Manager) execJobFunction(fn functions.Function, jobFn module.Function) {
+	ctx := fn.Context()
+
+	tbl, err := callJobFunction(ctx, jobFn, fn.Args)
+	if ctx.Err() != nil || !fn.TryRespond() {
+		// timed out or cancelled, the functions manager sends the result
+		return
+	}
+	if err == nil && tbl == nil {
+		err = fmt.Errorf("no result")
+	}
+	if err != nil {
+		m.Warningf("function '%s': %v", fn.Name, err)
+		_ = m.api.FunctionResultReject(fn.UID, "application/json", jsonError(err))
+		return
+	}
+
+	bs, err := json.Marshal(tbl)
+	if err != nil {
+		_ = m.api.FunctionResultReject(fn.UID, "application/json", jsonError(err))
+		return
+	}
+
+	_ = m.api.FunctionResultSuccess(fn.UID, "application/json", string(bs))
+}
+
+func callJobFunction(ctx context.Context, fn module.Function, args []string) (tbl *module.FunctionTable, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn.Handler(ctx, args)
+}
+
+func jsonError(err error) string {
+	bs, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(bs)
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/functions"
+	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/netdataapi"
+	"github.com/netdata/go.d.plugin/agent/safewriter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_JobFunctions(t *testing.T) {
+	var buf bytes.Buffer
+	reg := &mockFunctionRegistry{fns: make(map[string]func(functions.Function))}
+
+	mgr := NewManager()
+	mgr.Modules = module.Registry{"withfunc": module.Creator{Create: func() module.Module { return newModuleWithFunctions() }}}
+	mgr.Out = safewriter.New(&buf)
+	mgr.FunctionRegistry = reg
+	mgr.api = netdataapi.New(mgr.Out)
+
+	cfg := confgroup.Config{"name": "job", "module": "withfunc", "update_every": 1}
+
+	mgr.addConfig(context.Background(), cfg)
+
+	require.Len(t, reg.fns, 2)
+	require.Contains(t, reg.fns, "withfunc_job:ok")
+	require.Contains(t, reg.fns, "withfunc_job:fail")
+
+	buf.Reset()
+	reg.fns["withfunc_job:ok"](functions.Function{UID: "uid1", Name: "withfunc_job:ok", Args: []string{"arg"}})
+	assert.Contains(t, buf.String(), "FUNCTION_RESULT_BEGIN uid1 1 application/json")
+	assert.Contains(t, buf.String(), `"data":[["arg"]]`)
+
+	buf.Reset()
+	reg.fns["withfunc_job:fail"](functions.Function{UID: "uid2", Name: "withfunc_job:fail"})
+	assert.Contains(t, buf.String(), "FUNCTION_RESULT_BEGIN uid2 0 application/json")
+	assert.Contains(t, buf.String(), `{"error":"panic: boom"}`)
+
+	mgr.removeConfig(cfg)
+
+	assert.Empty(t, reg.fns)
+	assert.Empty(t, mgr.jobFunctions)
+}
+
+func newModuleWithFunctions() *moduleWithFunctions {
+	return &moduleWithFunctions{
+		MockModule: module.MockModule{
+			ChartsFunc: func() *module.Charts {
+				return &module.Charts{&module.Chart{ID: "id", Title: "title", Units: "units", Dims: module.Dims{{ID: "id1"}}}}
+			},
+			CollectFunc: func() map[string]int64 { return map[string]int64{"id1": 1} },
+		},
+	}
+}
+
+type moduleWithFunctions struct {
+	module.MockModule
+}
+
+func (m *moduleWithFunctions) Functions() map[string]module.Function {
+	return map[string]module.Function{
+		"ok": {
+			Help: "returns a table",
+			Handler: func(_ context.Context, args []string) (*module.FunctionTable, error) {
+				tbl := &module.FunctionTable{Columns: []module.FunctionColumn{{ID: "arg", Type: module.ColumnString}}}
+				for _, arg := range args {
+					tbl.AddRow(arg)
+				}
+				return tbl, nil
+			},
+		},
+		"fail": {
+			Handler: func(context.Context, []string) (*module.FunctionTable, error) {
+				panic(errors.New("boom"))
+			},
+		},
+	}
+}
+
+type mockFunctionRegistry struct {
+	fns map[string]func(functions.Function)
+}
+
+func (m *mockFunctionRegistry) Register(name string, fn func(functions.Function)) { m.fns[name] = fn }
+func (m *mockFunctionRegistry) Unregister(name string)                            { delete(m.fns, name) }
@@ -8,13 +8,18 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/netdataapi"
+	"github.com/netdata/go.d.plugin/agent/secrets"
 	"github.com/netdata/go.d.plugin/logger"
+	"github.com/netdata/go.d.plugin/pkg/recorder"
 
 	"gopkg.in/yaml.v2"
 )
@@ -26,6 +31,7 @@ type Job interface {
 	AutoDetection() bool
 	AutoDetectionEvery() int
 	RetryAutoDetection() bool
+	UpdateEvery() int
 	Tick(clock int)
 	Start()
 	Stop()
@@ -54,16 +60,23 @@ func NewManager() *Manager {
 		FileLock:    np,
 		StatusSaver: np,
 		StatusStore: np,
+		StateStore:  np,
 		Vnodes:      np,
 		Dyncfg:      np,
 
+		FunctionRegistry: noopFunctions{},
+
 		confGroupCache: confgroup.NewCache(),
 
 		runningJobs:  newRunningJobsCache(),
 		retryingJobs: newRetryingJobsCache(),
+		jobFunctions: make(map[string][]string),
+		vnodeJobs:    make(map[uint64]confgroup.Config),
 
 		addCh:    make(chan confgroup.Config),
 		removeCh: make(chan confgroup.Config),
+		giveUpCh: make(chan jobGiveUp),
+		vnodesCh: make(chan Vnodes, 1),
 	}
 
 	return mgr
@@ -75,28 +88,82 @@ type Manager struct {
 	PluginName string
 	Out        io.Writer
 	Modules    module.Registry
+	TickSpread bool
+	// ConfDir is the modules config dirs, only the job configs read from the files in them
+	// can reference the file and cmd secrets.
+	ConfDir []string
 
 	FileLock    FileLocker
 	StatusSaver StatusSaver
 	StatusStore StatusStore
+	StateStore  StateStore
 	Vnodes      Vnodes
 	Dyncfg      Dyncfg
 
+	FunctionRegistry FunctionRegistry
+
+	api *netdataapi.API
+
 	confGroupCache *confgroup.Cache
 	runningJobs    *runningJobsCache
 	retryingJobs   *retryingJobsCache
+	jobFunctions   map[string][]string         // registered functions by job full name
+	vnodeJobs      map[uint64]confgroup.Config // the configs of the jobs that have a vnode, by hash
 
 	addCh    chan confgroup.Config
 	removeCh chan confgroup.Config
+	giveUpCh chan jobGiveUp
+	vnodesCh chan Vnodes
 
 	queueMux sync.Mutex
-	queue    []Job
+	queue    []*scheduledJob
+
+	modulesMux sync.Mutex
+}
+
+// jobGiveUp is sent by a running job that gave up data collection.
+type jobGiveUp struct {
+	cfg    confgroup.Config
+	reason string
+}
+
+// SetModules replaces the modules registry. The new jobs are created using the new registry, the running jobs
+// of the removed modules are stopped when their configs are removed.
+func (m *Manager) SetModules(modules module.Registry) {
+	m.modulesMux.Lock()
+	defer m.modulesMux.Unlock()
+	m.Modules = modules
+}
+
+// SetVnodes replaces the virtual nodes. The jobs whose vnode is added, changed or removed are restarted.
+// It doesn't block, the vnodes are applied by the running manager.
+func (m *Manager) SetVnodes(vnodes Vnodes) {
+	if vnodes == nil {
+		vnodes = noop{}
+	}
+	for {
+		select {
+		case m.vnodesCh <- vnodes:
+			return
+		case <-m.vnodesCh:
+			// the previous vnodes are not applied yet, they are replaced
+		}
+	}
+}
+
+func (m *Manager) lookupModule(name string) (module.Creator, bool) {
+	m.modulesMux.Lock()
+	defer m.modulesMux.Unlock()
+	creator, ok := m.Modules[name]
+	return creator, ok
 }
 
 func (m *Manager) Run(ctx context.Context, in chan []*confgroup.Group) {
 	m.Info("instance is started")
 	defer func() { m.cleanup(); m.Info("instance is stopped") }()
 
+	m.api = netdataapi.New(m.Out)
+
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -142,6 +209,10 @@ func (m *Manager) runConfigsHandling(ctx context.Context) {
 			m.addConfig(ctx, cfg)
 		case cfg := <-m.removeCh:
 			m.removeConfig(cfg)
+		case v := <-m.giveUpCh:
+			m.stopGaveUpJob(v.cfg, v.reason)
+		case vnodes := <-m.vnodesCh:
+			m.applyVnodes(ctx, vnodes)
 		}
 	}
 }
@@ -152,12 +223,17 @@ func (m *Manager) cleanup() {
 	}
 	for name := range *m.runningJobs {
 		_ = m.FileLock.Unlock(name)
+		m.unregisterJobFunctions(name)
 	}
 	// TODO: m.Dyncfg.Register() ?
 	m.stopRunningJobs()
 }
 
 func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
+	if cfg.Vnode() != "" {
+		m.vnodeJobs[cfg.Hash()] = cfg
+	}
+
 	task, isRetry := m.retryingJobs.lookup(cfg)
 	if isRetry {
 		task.cancel()
@@ -191,6 +267,8 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 	if isRetry {
 		job.AutoDetectEvery = task.timeout
 		job.AutoDetectTries = task.retries
+	} else if n := job.RetryPolicy().GiveUpAfter; n > 0 && job.AutoDetectionEvery() > 0 {
+		job.AutoDetectTries = n
 	} else if job.AutoDetectionEvery() == 0 {
 		switch {
 		case m.StatusStore.Contains(cfg, jobStatusRunning, jobStatusRetrying):
@@ -211,7 +289,17 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 			m.runningJobs.put(cfg)
 			m.StatusSaver.Save(cfg, jobStatusRunning)
 			m.Dyncfg.UpdateStatus(cfg, "running", "")
+			job.OnGiveUp = func(reason string) {
+				// the manager may be stopping the job, so it must not block the job goroutine
+				go func() {
+					select {
+					case <-ctx.Done():
+					case m.giveUpCh <- jobGiveUp{cfg: cfg, reason: reason}:
+					}
+				}()
+			}
 			m.startJob(job)
+			m.registerJobFunctions(job)
 		} else if isTooManyOpenFiles(err) {
 			m.Error(err)
 			m.StatusSaver.Save(cfg, jobStatusStoppedRegErr)
@@ -222,19 +310,25 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 			m.Dyncfg.UpdateStatus(cfg, "error", "duplicate, served by another plugin")
 		}
 	case jobStatusRetrying:
-		m.Infof("%s[%s] job detection failed, will retry in %d seconds", cfg.Module(), cfg.Name(), job.AutoDetectionEvery())
+		var attempt int
+		if isRetry {
+			attempt = task.attempt + 1
+		}
+		interval := job.RetryPolicy().RetryInterval(job.AutoDetectionEvery(), attempt)
+		m.Infof("%s[%s] job detection failed, will retry in %d seconds", cfg.Module(), cfg.Name(), interval)
 		ctx, cancel := context.WithCancel(ctx)
 		m.retryingJobs.put(cfg, retryTask{
 			cancel:  cancel,
 			timeout: job.AutoDetectionEvery(),
 			retries: job.AutoDetectTries,
+			attempt: attempt,
 		})
-		go runRetryTask(ctx, m.addCh, cfg, time.Second*time.Duration(job.AutoDetectionEvery()))
+		go runRetryTask(ctx, m.addCh, cfg, time.Second*time.Duration(interval))
 		m.StatusSaver.Save(cfg, jobStatusRetrying)
-		m.Dyncfg.UpdateStatus(cfg, "error", "job detection failed, will retry later")
+		m.Dyncfg.UpdateStatus(cfg, "error", fmt.Sprintf("job detection failed (%s), will retry in %d seconds", job.FailReason(), interval))
 	case jobStatusStoppedFailed:
 		m.StatusSaver.Save(cfg, jobStatusStoppedFailed)
-		m.Dyncfg.UpdateStatus(cfg, "error", "job detection failed, stopping it")
+		m.Dyncfg.UpdateStatus(cfg, "error", fmt.Sprintf("job detection failed (%s), stopping it", job.FailReason()))
 	default:
 		m.Warningf("%s[%s] job detection: unknown state", cfg.Module(), cfg.Name())
 	}
@@ -242,6 +336,7 @@ func (m *Manager) addConfig(ctx context.Context, cfg confgroup.Config) {
 
 func (m *Manager) removeConfig(cfg confgroup.Config) {
 	if m.runningJobs.has(cfg) {
+		m.unregisterJobFunctions(cfg.FullName())
 		m.stopJob(cfg.FullName())
 		_ = m.FileLock.Unlock(cfg.FullName())
 		m.runningJobs.remove(cfg)
@@ -252,23 +347,101 @@ func (m *Manager) removeConfig(cfg confgroup.Config) {
 		m.retryingJobs.remove(cfg)
 	}
 
+	delete(m.vnodeJobs, cfg.Hash())
+
 	m.StatusSaver.Remove(cfg)
 	m.Dyncfg.Unregister(cfg)
 }
 
+// applyVnodes replaces the virtual nodes and restarts the jobs whose vnode definition has changed.
+func (m *Manager) applyVnodes(ctx context.Context, vnodes Vnodes) {
+	prev := m.Vnodes
+	m.Vnodes = vnodes
+
+	var restart []confgroup.Config
+	for _, cfg := range m.vnodeJobs {
+		before, okBefore := prev.Lookup(cfg.Vnode())
+		after, okAfter := vnodes.Lookup(cfg.Vnode())
+		if okBefore != okAfter || okAfter && !reflect.DeepEqual(before, after) {
+			restart = append(restart, cfg)
+		}
+	}
+
+	for _, cfg := range restart {
+		m.Infof("%s[%s] job vnode '%s' has changed, restarting the job", cfg.Module(), cfg.Name(), cfg.Vnode())
+		m.removeConfig(cfg)
+		m.addConfig(ctx, cfg)
+	}
+}
+
+// stopGaveUpJob stops the job that gave up data collection and reports it as failed.
+// The job config is kept, the job is re-created if its config is added again.
+func (m *Manager) stopGaveUpJob(cfg confgroup.Config, reason string) {
+	if !m.runningJobs.has(cfg) {
+		return
+	}
+
+	m.Warningf("%s[%s] job %s, stopping it", cfg.Module(), cfg.Name(), reason)
+
+	m.unregisterJobFunctions(cfg.FullName())
+	m.stopJob(cfg.FullName())
+	_ = m.FileLock.Unlock(cfg.FullName())
+	m.runningJobs.remove(cfg)
+
+	m.StatusSaver.Save(cfg, jobStatusStoppedFailed)
+	m.Dyncfg.UpdateStatus(cfg, "error", reason)
+}
+
+// CreateJob creates a job from the config the same way the manager does for the discovered configs, but doesn't run it.
+func (m *Manager) CreateJob(cfg confgroup.Config) (*module.Job, error) {
+	return m.createJob(cfg)
+}
+
 func (m *Manager) createJob(cfg confgroup.Config) (*module.Job, error) {
-	creator, ok := m.Modules[cfg.Module()]
+	creator, ok := m.lookupModule(cfg.Module())
 	if !ok {
 		return nil, fmt.Errorf("can not find %s module", cfg.Module())
 	}
 
 	m.Debugf("creating %s[%s] job, config: %v", cfg.Module(), cfg.Name(), cfg)
 
+	// the config keeps the secret references, only the module gets the resolved values
+	modCfg, err := resolveSecrets(cfg, m.secretKinds(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("resolve secrets: %v", err)
+	}
+	if recorder.Default.Mode() != recorder.Off {
+		// the plaintext credentials are redacted in the recorded bundle
+		recorder.AddConfigSecrets(modCfg)
+	}
+
 	mod := creator.Create()
-	if err := unmarshal(cfg, mod); err != nil {
+	if err := unmarshal(modCfg, mod); err != nil {
+		return nil, err
+	}
+	mod.GetBase().SetStateStore(m.StateStore, cfg.FullName())
+
+	var policy confgroup.JobPolicy
+	if err := unmarshal(cfg, &policy); err != nil {
+		return nil, err
+	}
+	if err := policy.Init(); err != nil {
 		return nil, err
 	}
 
+	collectTimeout, err := cfg.CollectTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	var schedule *module.Schedule
+	if v := cfg.Schedule(); v != "" {
+		var err error
+		if schedule, err = module.ParseSchedule(v); err != nil {
+			return nil, err
+		}
+	}
+
 	labels := make(map[string]string)
 	for name, value := range cfg.Labels() {
 		n, ok1 := name.(string)
@@ -286,6 +459,14 @@ func (m *Manager) createJob(cfg confgroup.Config) (*module.Job, error) {
 		UpdateEvery:     cfg.UpdateEvery(),
 		AutoDetectEvery: cfg.AutoDetectionRetry(),
 		Priority:        cfg.Priority(),
+		CollectTimeout:  collectTimeout,
+		RetryPolicy:     policy.RetryPolicy,
+		Schedule:        schedule,
+		ChartRules:      policy.ChartRules,
+		DerivedCharts:   policy.DerivedCharts,
+		Limits:          policy.Limits,
+		ObsoleteAfter:   policy.ObsoleteAfter,
+		HealthCharts:    policy.HealthCharts,
 		Labels:          labels,
 		IsStock:         isStockConfig(cfg),
 		Module:          mod,
@@ -344,6 +525,54 @@ func sendConfig(ctx context.Context, out chan<- confgroup.Config, cfg confgroup.
 	}
 }
 
+// secretKinds returns the secret references the job config is allowed to use:
+//   - all kinds in the local config files (go.d/*.conf).
+//   - none in the dyncfg configs, they are set by the Netdata functions callers.
+//   - env in the other ones (e.g. service discovery), they contain values of the discovered targets.
+func (m *Manager) secretKinds(cfg confgroup.Config) []secrets.Kind {
+	switch {
+	case cfg.Provider() == "dyncfg":
+		return nil
+	case cfg.Provider() == "file reader" && isFileInDirs(cfg.Source(), m.ConfDir):
+		return secrets.AllKinds
+	default:
+		return []secrets.Kind{secrets.Env}
+	}
+}
+
+func isFileInDirs(path string, dirs []string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range dirs {
+		if dir, err = filepath.Abs(dir); err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSecrets returns a copy of the config with the secret references (e.g. "${env:MYSQL_PASSWORD}") of the allowed
+// kinds resolved, the other references are an error.
+func resolveSecrets(cfg confgroup.Config, allowed []secrets.Kind) (map[string]any, error) {
+	bs, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var resolved map[string]any
+	if err := yaml.Unmarshal(bs, &resolved); err != nil {
+		return nil, err
+	}
+	if err := secrets.ResolveConfigKinds(resolved, allowed...); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
 func unmarshal(conf interface{}, module interface{}) error {
 	bs, err := yaml.Marshal(conf)
 	if err != nil {
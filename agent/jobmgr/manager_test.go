@@ -5,6 +5,8 @@ package jobmgr
 import (
 	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -12,7 +14,9 @@ import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/module"
 	"github.com/netdata/go.d.plugin/agent/safewriter"
+	"github.com/netdata/go.d.plugin/agent/vnodes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TODO: tech dept
@@ -75,6 +79,95 @@ func TestManager_Run(t *testing.T) {
 	assert.True(t, buf.String() != "")
 }
 
+func TestManager_createJob_ResolvesSecrets(t *testing.T) {
+	t.Setenv("TEST_JOBMGR_PASSWORD", "jobmgr-secret")
+
+	var mod *moduleWithPassword
+	mgr := NewManager()
+	mgr.Modules = module.Registry{
+		"withpass": module.Creator{Create: func() module.Module { mod = &moduleWithPassword{}; return mod }},
+	}
+	cfg := confgroup.Config{"name": "job", "module": "withpass", "password": "${env:TEST_JOBMGR_PASSWORD}"}
+
+	_, err := mgr.createJob(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "jobmgr-secret", mod.Password)
+	assert.Equal(t, "${env:TEST_JOBMGR_PASSWORD}", cfg["password"], "the config keeps the reference")
+
+	cfg["password"] = "${env:TEST_JOBMGR_NOT_SET}"
+	_, err = mgr.createJob(cfg)
+	assert.Error(t, err)
+}
+
+func TestManager_createJob_SecretKinds(t *testing.T) {
+	t.Setenv("TEST_JOBMGR_PASSWORD", "jobmgr-secret")
+
+	confDir := t.TempDir()
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+
+	tests := map[string]struct {
+		provider string
+		source   string
+		password string
+		want     string
+		wantErr  bool
+	}{
+		"local file, file reference": {
+			provider: "file reader", source: filepath.Join(confDir, "withpass.conf"),
+			password: "${file:" + secretFile + "}", want: "file-secret",
+		},
+		"file outside the conf dir, file reference": {
+			provider: "file reader", source: filepath.Join(confDir, "..", "withpass.conf"),
+			password: "${file:" + secretFile + "}", wantErr: true,
+		},
+		"file watcher, cmd reference": {
+			provider: "file watcher", source: filepath.Join(confDir, "withpass.conf"),
+			password: "${cmd:/usr/bin/id}", wantErr: true,
+		},
+		"service discovery, env reference": {
+			provider: "sd:docker:container", source: "sd:docker:container(unix:///var/run/docker.sock)",
+			password: "${env:TEST_JOBMGR_PASSWORD}", want: "jobmgr-secret",
+		},
+		"service discovery, file reference": {
+			provider: "sd:docker:container", source: "sd:docker:container(unix:///var/run/docker.sock)",
+			password: "${file:" + secretFile + "}", wantErr: true,
+		},
+		"dyncfg, env reference": {
+			provider: "dyncfg", source: "dyncfg/withpass/job",
+			password: "${env:TEST_JOBMGR_PASSWORD}", wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var mod *moduleWithPassword
+			mgr := NewManager()
+			mgr.ConfDir = []string{confDir}
+			mgr.Modules = module.Registry{
+				"withpass": module.Creator{Create: func() module.Module { mod = &moduleWithPassword{}; return mod }},
+			}
+			cfg := confgroup.Config{"name": "job", "module": "withpass", "password": test.password}
+			cfg.SetProvider(test.provider)
+			cfg.SetSource(test.source)
+
+			_, err := mgr.createJob(cfg)
+
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, mod.Password)
+		})
+	}
+}
+
+type moduleWithPassword struct {
+	module.MockModule
+	Password string `yaml:"password"`
+}
+
 func prepareMockRegistry() module.Registry {
 	reg := module.Registry{}
 	reg.Register("success", module.Creator{
@@ -102,3 +195,137 @@ func prepareMockRegistry() module.Registry {
 	})
 	return reg
 }
+
+func TestManager_Run_JobGivesUp(t *testing.T) {
+	reg := module.Registry{}
+	reg.Register("giveup", module.Creator{
+		Create: func() module.Module {
+			return &module.MockModule{
+				InitFunc:  func() bool { return true },
+				CheckFunc: func() bool { return true },
+				ChartsFunc: func() *module.Charts {
+					return &module.Charts{
+						&module.Chart{ID: "id", Title: "title", Units: "units", Dims: module.Dims{{ID: "id1"}}},
+					}
+				},
+				CollectFunc: func() map[string]int64 { return nil },
+			}
+		},
+	})
+	cfg := confgroup.Config{
+		"name":                "name",
+		"module":              "giveup",
+		"update_every":        1,
+		"autodetection_retry": 0,
+		"priority":            module.Priority,
+		"retry_policy":        map[string]any{"give_up_after": 2},
+	}
+
+	statuses := &mockStatusSaver{}
+	mgr := NewManager()
+	mgr.Modules = reg
+	mgr.PluginName = "test.plugin"
+	mgr.StatusSaver = statuses
+	mgr.Dyncfg = statuses
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []*confgroup.Group)
+	done := make(chan struct{})
+	go func() { defer close(done); mgr.Run(ctx, in) }()
+	defer func() { cancel(); <-done }()
+
+	in <- []*confgroup.Group{{Source: "source", Configs: []confgroup.Config{cfg}}}
+
+	assert.Eventually(t, func() bool {
+		return statuses.last() == jobStatusStoppedFailed
+	}, time.Second*10, time.Millisecond*100)
+	assert.Equal(t, "gave up after 2 consecutive failed data collections", statuses.lastDyncfgPayload())
+}
+
+func TestManager_SetVnodes(t *testing.T) {
+	cfg := confgroup.Config{
+		"name":                "name",
+		"module":              "success",
+		"vnode":               "vnode1",
+		"update_every":        module.UpdateEvery,
+		"autodetection_retry": 0,
+		"priority":            module.Priority,
+	}
+
+	statuses := &mockStatusSaver{}
+	mgr := NewManager()
+	mgr.Modules = prepareMockRegistry()
+	mgr.PluginName = "test.plugin"
+	mgr.StatusSaver = statuses
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan []*confgroup.Group)
+	done := make(chan struct{})
+	go func() { defer close(done); mgr.Run(ctx, in) }()
+	defer func() { cancel(); <-done }()
+
+	in <- []*confgroup.Group{{Source: "source", Configs: []confgroup.Config{cfg}}}
+	assert.Eventually(t, func() bool {
+		return statuses.last() == jobStatusStoppedCreateErr
+	}, time.Second*5, time.Millisecond*50, "vnode is not found")
+
+	mgr.SetVnodes(mockVnodes{"vnode1": {GUID: "guid", Hostname: "vnode1"}})
+	assert.Eventually(t, func() bool {
+		return statuses.last() == jobStatusRunning
+	}, time.Second*5, time.Millisecond*50, "vnode is added")
+
+	n := statuses.count()
+	mgr.SetVnodes(mockVnodes{"vnode1": {GUID: "guid", Hostname: "vnode1"}, "vnode2": {GUID: "guid2", Hostname: "vnode2"}})
+	mgr.SetVnodes(mockVnodes{"vnode1": {GUID: "guid", Hostname: "vnode1_renamed"}})
+	assert.Eventually(t, func() bool {
+		return statuses.count() > n && statuses.last() == jobStatusRunning
+	}, time.Second*5, time.Millisecond*50, "vnode is changed")
+}
+
+type mockVnodes map[string]*vnodes.VirtualNode
+
+func (m mockVnodes) Lookup(key string) (*vnodes.VirtualNode, bool) { v, ok := m[key]; return v, ok }
+
+type mockStatusSaver struct {
+	mux      sync.Mutex
+	statuses []string
+	payloads []string
+}
+
+func (m *mockStatusSaver) Save(_ confgroup.Config, status string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.statuses = append(m.statuses, status)
+}
+func (m *mockStatusSaver) Remove(confgroup.Config)     {}
+func (m *mockStatusSaver) Register(confgroup.Config)   {}
+func (m *mockStatusSaver) Unregister(confgroup.Config) {}
+func (m *mockStatusSaver) UpdateStatus(_ confgroup.Config, _, payload string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.payloads = append(m.payloads, payload)
+}
+
+func (m *mockStatusSaver) last() string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if len(m.statuses) == 0 {
+		return ""
+	}
+	return m.statuses[len(m.statuses)-1]
+}
+
+func (m *mockStatusSaver) count() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return len(m.statuses)
+}
+
+func (m *mockStatusSaver) lastDyncfgPayload() string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if len(m.payloads) == 0 {
+		return ""
+	}
+	return m.payloads[len(m.payloads)-1]
+}
@@ -4,6 +4,7 @@ package jobmgr
 
 import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/functions"
 	"github.com/netdata/go.d.plugin/agent/vnodes"
 )
 
@@ -15,6 +16,13 @@ func (n noop) Save(confgroup.Config, string)                 {}
 func (n noop) Remove(confgroup.Config)                       {}
 func (n noop) Contains(confgroup.Config, ...string) bool     { return false }
 func (n noop) Lookup(string) (*vnodes.VirtualNode, bool)     { return nil, false }
+func (n noop) LoadState(string) ([]byte, bool)               { return nil, false }
+func (n noop) SaveState(string, []byte) error                { return nil }
 func (n noop) Register(confgroup.Config)                     { return }
 func (n noop) Unregister(confgroup.Config)                   { return }
 func (n noop) UpdateStatus(confgroup.Config, string, string) { return }
+
+type noopFunctions struct{}
+
+func (n noopFunctions) Register(string, func(functions.Function)) {}
+func (n noopFunctions) Unregister(string)                         {}
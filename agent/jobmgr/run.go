@@ -4,12 +4,19 @@ package jobmgr
 
 import (
 	"context"
+	"hash/fnv"
 	"slices"
 	"time"
 
 	"github.com/netdata/go.d.plugin/agent/ticker"
 )
 
+type scheduledJob struct {
+	Job
+	// offset is the job start offset within its data collection interval, it is used only if tick spread is enabled.
+	offset time.Duration
+}
+
 func (m *Manager) runRunningJobsHandling(ctx context.Context) {
 	tk := ticker.New(time.Second)
 	defer tk.Stop()
@@ -20,7 +27,11 @@ func (m *Manager) runRunningJobsHandling(ctx context.Context) {
 			return
 		case clock := <-tk.C:
 			//m.Debugf("tick %d", clock)
-			m.notifyRunningJobs(clock)
+			if m.TickSpread {
+				go m.notifyRunningJobsSpread(ctx, clock)
+			} else {
+				m.notifyRunningJobs(clock)
+			}
 		}
 	}
 }
@@ -34,20 +45,56 @@ func (m *Manager) notifyRunningJobs(clock int) {
 	}
 }
 
+// notifyRunningJobsSpread spreads ticks within a second according to the jobs offsets.
+// The whole seconds part of an offset shifts the clock, so that jobs with the same update_every start on different seconds.
+func (m *Manager) notifyRunningJobsSpread(ctx context.Context, clock int) {
+	m.queueMux.Lock()
+	jobs := slices.Clone(m.queue)
+	m.queueMux.Unlock()
+
+	start := time.Now()
+	t := time.NewTimer(0)
+	defer t.Stop()
+
+	for _, v := range jobs {
+		if d := v.offset%time.Second - time.Since(start); d > 0 {
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+			t.Reset(d)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+		v.Tick(clock + int(v.offset/time.Second))
+	}
+}
+
 func (m *Manager) startJob(job Job) {
 	m.queueMux.Lock()
 	defer m.queueMux.Unlock()
 
 	go job.Start()
 
-	m.queue = append(m.queue, job)
+	sj := &scheduledJob{Job: job, offset: tickOffset(job.FullName(), job.UpdateEvery())}
+
+	// keep the queue sorted by the sub-second part of the offset
+	idx, _ := slices.BinarySearchFunc(m.queue, sj, func(a, b *scheduledJob) int {
+		return int(a.offset%time.Second - b.offset%time.Second)
+	})
+	m.queue = slices.Insert(m.queue, idx, sj)
 }
 
 func (m *Manager) stopJob(name string) {
 	m.queueMux.Lock()
 	defer m.queueMux.Unlock()
 
-	idx := slices.IndexFunc(m.queue, func(job Job) bool {
+	idx := slices.IndexFunc(m.queue, func(job *scheduledJob) bool {
 		return job.FullName() == name
 	})
 
@@ -71,3 +118,14 @@ func (m *Manager) stopRunningJobs() {
 	}
 	m.queue = m.queue[:0]
 }
+
+// tickOffset returns the job start offset within its data collection interval.
+// It is deterministic per job full name, so charts stay aligned across restarts.
+func tickOffset(fullName string, updateEvery int) time.Duration {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fullName))
+
+	window := uint32(max(updateEvery, 1) * 1000)
+
+	return time.Duration(h.Sum32()%window) * time.Millisecond
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package jobmgr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_tickOffset(t *testing.T) {
+	for _, updateEvery := range []int{0, 1, 5, 60} {
+		window := time.Duration(max(updateEvery, 1)) * time.Second
+		seen := make(map[time.Duration]bool)
+
+		for i := 0; i < 100; i++ {
+			name := fmt.Sprintf("job%d", i)
+			offset := tickOffset(name, updateEvery)
+
+			assert.Equal(t, offset, tickOffset(name, updateEvery), "offset is not deterministic")
+			assert.GreaterOrEqual(t, offset, time.Duration(0))
+			assert.Less(t, offset, window)
+			seen[offset] = true
+		}
+
+		assert.Greater(t, len(seen), 50, "offsets are not spread (update_every %d)", updateEvery)
+	}
+}
+
+func TestManager_notifyRunningJobsSpread(t *testing.T) {
+	mgr := NewManager()
+	mgr.TickSpread = true
+
+	var jobs []*tickRecorder
+	for i := 0; i < 10; i++ {
+		job := &tickRecorder{name: fmt.Sprintf("job%d", i), updateEvery: 5}
+		jobs = append(jobs, job)
+		mgr.startJob(job)
+	}
+
+	for i := 1; i < len(mgr.queue); i++ {
+		assert.LessOrEqual(t, mgr.queue[i-1].offset%time.Second, mgr.queue[i].offset%time.Second)
+	}
+
+	mgr.notifyRunningJobsSpread(context.Background(), 100)
+
+	for _, job := range jobs {
+		offset := tickOffset(job.name, job.updateEvery)
+		assert.Equal(t, []int{100 + int(offset/time.Second)}, job.ticks)
+	}
+}
+
+type tickRecorder struct {
+	mux         sync.Mutex
+	name        string
+	updateEvery int
+	ticks       []int
+}
+
+func (j *tickRecorder) Name() string             { return j.name }
+func (j *tickRecorder) ModuleName() string       { return j.name }
+func (j *tickRecorder) FullName() string         { return j.name }
+func (j *tickRecorder) AutoDetection() bool      { return true }
+func (j *tickRecorder) AutoDetectionEvery() int  { return 0 }
+func (j *tickRecorder) RetryAutoDetection() bool { return false }
+func (j *tickRecorder) UpdateEvery() int         { return j.updateEvery }
+func (j *tickRecorder) Start()                   {}
+func (j *tickRecorder) Stop()                    {}
+func (j *tickRecorder) Cleanup()                 {}
+func (j *tickRecorder) Tick(clock int) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	j.ticks = append(j.ticks, clock)
+}
@@ -108,6 +108,10 @@ type (
 
 		// ignore flag is used to indicate that the chart shouldn't be sent to the netdata plugins.d
 		ignore bool
+		// counted flag is used to indicate that the chart is counted against the job limits.
+		counted bool
+		// stale flag is used to indicate that the chart is obsoleted by the job because it had no data for a long time.
+		stale bool
 	}
 
 	Label struct {
@@ -135,6 +139,12 @@ type (
 		DimOpts
 
 		remove bool
+		// drop flag is used to indicate that the dimension is dropped by the job chart rules or refused because of the job limits.
+		drop bool
+		// counted flag is used to indicate that the dimension is counted against the job limits.
+		counted bool
+		// rename is the dimension name set by the job chart rules, it doesn't change the dimension ID.
+		rename string
 	}
 
 	// Var represents a chart variable.
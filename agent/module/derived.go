@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DerivedChart is a job level chart whose dimensions are arithmetic expressions over the metrics returned by Collect.
+// See parseMetricExpr for the expression syntax.
+type DerivedChart struct {
+	ID      string        `yaml:"id"`
+	Title   string        `yaml:"title"`
+	Units   string        `yaml:"units"`
+	Family  string        `yaml:"family"`
+	Context string        `yaml:"context"`
+	Type    string        `yaml:"type"`
+	Dims    []*DerivedDim `yaml:"dimensions"`
+}
+
+// DerivedDim is a derived chart dimension. The expression result is rounded to an integer,
+// use the divisor to keep the precision (e.g. "hits * 100 / total" with divisor 100 gives a 2 decimal places ratio).
+type DerivedDim struct {
+	Name       string `yaml:"name"`
+	Expr       string `yaml:"expr"`
+	Algorithm  string `yaml:"algorithm"`
+	Multiplier int    `yaml:"multiplier"`
+	Divisor    int    `yaml:"divisor"`
+
+	expr metricExpr
+}
+
+// DerivedCharts is a list of derived charts.
+type DerivedCharts []*DerivedChart
+
+// Init validates the derived charts and compiles the dimensions expressions.
+func (dcs DerivedCharts) Init() error {
+	seen := make(map[string]bool)
+	for _, dc := range dcs {
+		if err := dc.init(); err != nil {
+			return fmt.Errorf("derived chart '%s': %v", dc.ID, err)
+		}
+		if seen[dc.ID] {
+			return fmt.Errorf("derived chart '%s': duplicate id", dc.ID)
+		}
+		seen[dc.ID] = true
+	}
+	return nil
+}
+
+func (dc *DerivedChart) init() error {
+	if dc.ID == "" {
+		return errors.New("'id' not set")
+	}
+	if dc.Units == "" {
+		return errors.New("'units' not set")
+	}
+	if len(dc.Dims) == 0 {
+		return errors.New("'dimensions' not set")
+	}
+
+	seen := make(map[string]bool)
+	for _, dim := range dc.Dims {
+		if dim.Name == "" {
+			return errors.New("dimension 'name' not set")
+		}
+		if seen[dim.Name] {
+			return fmt.Errorf("dimension '%s': duplicate name", dim.Name)
+		}
+		seen[dim.Name] = true
+
+		expr, err := parseMetricExpr(dim.Expr)
+		if err != nil {
+			return fmt.Errorf("dimension '%s': invalid expression '%s': %v", dim.Name, dim.Expr, err)
+		}
+		dim.expr = expr
+	}
+	return nil
+}
+
+// charts creates the derived charts, the default context is "<module name>.<chart id>".
+func (dcs DerivedCharts) charts(moduleName string) Charts {
+	var charts Charts
+	for _, dc := range dcs {
+		chart := &Chart{
+			ID:    dc.ID,
+			Title: firstNotEmpty(dc.Title, dc.ID),
+			Units: dc.Units,
+			Fam:   dc.Family,
+			Ctx:   firstNotEmpty(dc.Context, moduleName+"."+dc.ID),
+			Type:  ChartType(dc.Type),
+		}
+		for _, dim := range dc.Dims {
+			chart.Dims = append(chart.Dims, &Dim{
+				ID:   dc.dimID(dim),
+				Name: dim.Name,
+				Algo: DimAlgo(dim.Algorithm),
+				Mul:  dim.Multiplier,
+				Div:  dim.Divisor,
+			})
+		}
+		charts = append(charts, chart)
+	}
+	return charts
+}
+
+// eval returns a copy of the collected metrics with the derived dimensions values added, the collected metrics
+// map is not modified (modules can reuse it between data collections).
+// A dimension that can not be evaluated (a missing metric, a division by zero) is not set.
+func (dcs DerivedCharts) eval(mx map[string]int64) map[string]int64 {
+	if len(dcs) == 0 {
+		return mx
+	}
+
+	res := make(map[string]int64, len(mx))
+	for k, v := range mx {
+		res[k] = v
+	}
+
+	for _, dc := range dcs {
+		for _, dim := range dc.Dims {
+			id := dc.dimID(dim)
+			v, err := dim.expr(mx)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				delete(res, id)
+				continue
+			}
+			res[id] = int64(math.Round(v))
+		}
+	}
+	return res
+}
+
+func (dc *DerivedChart) dimID(dim *DerivedDim) string {
+	return "derived_" + dc.ID + "_" + dim.Name
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivedCharts_Init(t *testing.T) {
+	tests := map[string]struct {
+		charts  DerivedCharts
+		wantErr bool
+	}{
+		"valid": {
+			charts: DerivedCharts{
+				{ID: "hit_ratio", Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "div(hits * 100, hits + misses, 0)"}}},
+			},
+		},
+		"no id": {
+			charts:  DerivedCharts{{Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "hits"}}}},
+			wantErr: true,
+		},
+		"no dimensions": {
+			charts:  DerivedCharts{{ID: "hit_ratio", Units: "percentage"}},
+			wantErr: true,
+		},
+		"invalid expression": {
+			charts:  DerivedCharts{{ID: "hit_ratio", Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "hits +"}}}},
+			wantErr: true,
+		},
+		"duplicate dimension": {
+			charts: DerivedCharts{
+				{ID: "hit_ratio", Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "hits"}, {Name: "ratio", Expr: "misses"}}},
+			},
+			wantErr: true,
+		},
+		"duplicate chart": {
+			charts: DerivedCharts{
+				{ID: "hit_ratio", Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "hits"}}},
+				{ID: "hit_ratio", Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "hits"}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.charts.Init()
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDerivedCharts_eval(t *testing.T) {
+	dcs := DerivedCharts{
+		{ID: "cache", Units: "percentage", Dims: []*DerivedDim{
+			{Name: "hit_ratio", Expr: "hits * 10000 / (hits + misses)", Divisor: 100},
+			{Name: "errors_per_request", Expr: "errors / requests"},
+		}},
+	}
+	require.NoError(t, dcs.Init())
+
+	charts := dcs.charts("module")
+	require.Len(t, charts, 1)
+	assert.Equal(t, "module.cache", charts[0].Ctx)
+	assert.Equal(t, "derived_cache_hit_ratio", charts[0].Dims[0].ID)
+
+	mx := map[string]int64{"hits": 2, "misses": 1, "errors": 1, "requests": 0, "derived_cache_errors_per_request": 5}
+	res := dcs.eval(mx)
+
+	assert.Equal(t, int64(6667), res["derived_cache_hit_ratio"])
+	_, ok := res["derived_cache_errors_per_request"]
+	assert.False(t, ok, "division by zero is not set")
+	assert.Equal(t, int64(2), res["hits"])
+
+	assert.Equal(t,
+		map[string]int64{"hits": 2, "misses": 1, "errors": 1, "requests": 0, "derived_cache_errors_per_request": 5},
+		mx, "the collected metrics are modified")
+}
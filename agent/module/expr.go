@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+var errDivByZero = errors.New("division by zero")
+
+// metricExpr is a compiled arithmetic expression over the collected metrics.
+type metricExpr func(mx map[string]int64) (float64, error)
+
+// parseMetricExpr compiles an arithmetic expression. Supported are:
+//   - numbers: 1, 0.5
+//   - metric keys: cache_hits, cache.hits (identifiers, dots allowed) or "cache-hits" (any key, quoted)
+//   - operators: + - * / and parentheses
+//   - functions: min(a, b, ...), max(a, b, ...) and div(a, b, fallback) that returns fallback if b is zero
+//
+// A division by zero and a missing metric key are evaluation errors.
+func parseMetricExpr(s string) (metricExpr, error) {
+	node, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	return compileExpr(node)
+}
+
+func compileExpr(node ast.Expr) (metricExpr, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return compileExpr(n.X)
+	case *ast.BasicLit:
+		return compileLit(n)
+	case *ast.Ident, *ast.SelectorExpr:
+		key, ok := exprKey(n)
+		if !ok {
+			return nil, fmt.Errorf("invalid metric key at %d", n.Pos())
+		}
+		return metricValue(key), nil
+	case *ast.UnaryExpr:
+		x, err := compileExpr(n.X)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x, nil
+		case token.SUB:
+			return func(mx map[string]int64) (float64, error) {
+				v, err := x(mx)
+				return -v, err
+			}, nil
+		}
+		return nil, fmt.Errorf("unsupported operator '%s'", n.Op)
+	case *ast.BinaryExpr:
+		return compileBinary(n)
+	case *ast.CallExpr:
+		return compileCall(n)
+	}
+	return nil, fmt.Errorf("unsupported expression at %d", node.Pos())
+}
+
+func compileLit(n *ast.BasicLit) (metricExpr, error) {
+	switch n.Kind {
+	case token.INT, token.FLOAT:
+		v, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(map[string]int64) (float64, error) { return v, nil }, nil
+	case token.STRING:
+		key, err := strconv.Unquote(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		return metricValue(key), nil
+	}
+	return nil, fmt.Errorf("unsupported literal '%s'", n.Value)
+}
+
+func compileBinary(n *ast.BinaryExpr) (metricExpr, error) {
+	var op func(a, b float64) (float64, error)
+	switch n.Op {
+	case token.ADD:
+		op = func(a, b float64) (float64, error) { return a + b, nil }
+	case token.SUB:
+		op = func(a, b float64) (float64, error) { return a - b, nil }
+	case token.MUL:
+		op = func(a, b float64) (float64, error) { return a * b, nil }
+	case token.QUO:
+		op = func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, errDivByZero
+			}
+			return a / b, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported operator '%s'", n.Op)
+	}
+
+	x, err := compileExpr(n.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := compileExpr(n.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(mx map[string]int64) (float64, error) {
+		a, err := x(mx)
+		if err != nil {
+			return 0, err
+		}
+		b, err := y(mx)
+		if err != nil {
+			return 0, err
+		}
+		return op(a, b)
+	}, nil
+}
+
+func compileCall(n *ast.CallExpr) (metricExpr, error) {
+	name, ok := n.Fun.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("unsupported function at %d", n.Pos())
+	}
+
+	args := make([]metricExpr, 0, len(n.Args))
+	for _, arg := range n.Args {
+		v, err := compileExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+
+	switch name.Name {
+	case "min", "max":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s(): expected at least 1 argument", name.Name)
+		}
+		pick := math.Min
+		if name.Name == "max" {
+			pick = math.Max
+		}
+		return func(mx map[string]int64) (float64, error) {
+			var res float64
+			for i, arg := range args {
+				v, err := arg(mx)
+				if err != nil {
+					return 0, err
+				}
+				if i == 0 {
+					res = v
+				} else {
+					res = pick(res, v)
+				}
+			}
+			return res, nil
+		}, nil
+	case "div":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("div(): expected 3 arguments, got %d", len(args))
+		}
+		return func(mx map[string]int64) (float64, error) {
+			b, err := args[1](mx)
+			if err != nil {
+				return 0, err
+			}
+			if b == 0 {
+				return args[2](mx)
+			}
+			a, err := args[0](mx)
+			if err != nil {
+				return 0, err
+			}
+			return a / b, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown function '%s'", name.Name)
+}
+
+func metricValue(key string) metricExpr {
+	return func(mx map[string]int64) (float64, error) {
+		v, ok := mx[key]
+		if !ok {
+			return 0, fmt.Errorf("metric '%s' not found", key)
+		}
+		return float64(v), nil
+	}
+}
+
+// exprKey returns the metric key of an identifier or a dotted identifier ("cache.hits").
+func exprKey(node ast.Expr) (string, bool) {
+	switch n := node.(type) {
+	case *ast.Ident:
+		return n.Name, true
+	case *ast.SelectorExpr:
+		prefix, ok := exprKey(n.X)
+		if !ok {
+			return "", false
+		}
+		return prefix + "." + n.Sel.Name, true
+	}
+	return "", false
+}
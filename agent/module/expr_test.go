@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricExpr(t *testing.T) {
+	mx := map[string]int64{
+		"hits":       30,
+		"misses":     10,
+		"zero":       0,
+		"cache.size": 100,
+		"cache-free": 25,
+	}
+
+	tests := map[string]struct {
+		expr         string
+		want         float64
+		wantParseErr bool
+		wantEvalErr  bool
+	}{
+		"number":                     {expr: "1.5", want: 1.5},
+		"metric":                     {expr: "hits", want: 30},
+		"dotted metric":              {expr: "cache.size", want: 100},
+		"quoted metric":              {expr: `"cache-free"`, want: 25},
+		"arithmetic":                 {expr: "hits * 100 / (hits + misses)", want: 75},
+		"unary minus":                {expr: "-hits + misses", want: -20},
+		"min":                        {expr: "min(hits, misses, 20)", want: 10},
+		"max":                        {expr: "max(hits, misses)", want: 30},
+		"div":                        {expr: "div(hits, misses, 0)", want: 3},
+		"div by zero fallback":       {expr: "div(hits, zero, -1)", want: -1},
+		"division by zero":           {expr: "hits / zero", wantEvalErr: true},
+		"missing metric":             {expr: "hits + not_exists", wantEvalErr: true},
+		"syntax error":               {expr: "hits +", wantParseErr: true},
+		"unsupported operator":       {expr: "hits % misses", wantParseErr: true},
+		"unknown function":           {expr: "avg(hits, misses)", wantParseErr: true},
+		"div wrong number of args":   {expr: "div(hits, misses)", wantParseErr: true},
+		"min without args":           {expr: "min()", wantParseErr: true},
+		"unsupported expression":     {expr: "hits[0]", wantParseErr: true},
+		"unsupported literal (char)": {expr: "'a'", wantParseErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, err := parseMetricExpr(test.expr)
+			if test.wantParseErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			v, err := expr(mx)
+			if test.wantEvalErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.want, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// FunctionProvider is an optional interface a module can implement to expose Netdata functions.
+// The functions are registered when the job starts and unregistered when it stops.
+// Netdata gets them as functions of the job chart, so it stops offering them once the job charts are obsolete.
+type FunctionProvider interface {
+	// Functions returns the module functions, the key is the function name.
+	Functions() map[string]Function
+}
+
+// FunctionTimeout is the job functions timeout (seconds) announced to Netdata,
+// the functions manager enforces the timeout Netdata sends with the call.
+const FunctionTimeout = 10
+
+// Function is a module function. The handler is called concurrently with Collect, the module must synchronize
+// access to the shared state (e.g. by using a dedicated connection).
+type Function struct {
+	// Help is the function description shown in the Netdata UI.
+	Help string
+	// Handler executes the function. The args are the function arguments, the ctx is done when the call times out.
+	Handler func(ctx context.Context, args []string) (*FunctionTable, error)
+}
+
+// Function table column types.
+const (
+	ColumnString    = "string"
+	ColumnInteger   = "integer"
+	ColumnNumber    = "number"
+	ColumnBoolean   = "boolean"
+	ColumnTimestamp = "timestamp"
+	ColumnDuration  = "duration"
+)
+
+// FunctionTable is a tabular function result.
+type FunctionTable struct {
+	Help        string
+	UpdateEvery int
+	Columns     []FunctionColumn
+	Rows        [][]any
+}
+
+// FunctionColumn is a function table column.
+type FunctionColumn struct {
+	ID        string
+	Name      string
+	Type      string
+	Units     string
+	UniqueKey bool
+	Hidden    bool
+}
+
+// AddRow appends a row, the values must be in the columns order.
+func (t *FunctionTable) AddRow(values ...any) {
+	t.Rows = append(t.Rows, values)
+}
+
+// MarshalJSON encodes the table in the Netdata functions table format.
+func (t *FunctionTable) MarshalJSON() ([]byte, error) {
+	type column struct {
+		Index     int    `json:"index"`
+		UniqueKey bool   `json:"unique_key"`
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		Units     string `json:"units,omitempty"`
+		Visible   bool   `json:"visible"`
+	}
+
+	columns := make(map[string]column, len(t.Columns))
+	for i, col := range t.Columns {
+		name := col.Name
+		if name == "" {
+			name = col.ID
+		}
+		columns[col.ID] = column{
+			Index:     i,
+			UniqueKey: col.UniqueKey,
+			Name:      name,
+			Type:      col.Type,
+			Units:     col.Units,
+			Visible:   !col.Hidden,
+		}
+	}
+
+	rows := t.Rows
+	if rows == nil {
+		rows = [][]any{}
+	}
+
+	return json.Marshal(struct {
+		Status      int               `json:"status"`
+		Type        string            `json:"type"`
+		HasHistory  bool              `json:"has_history"`
+		Help        string            `json:"help,omitempty"`
+		UpdateEvery int               `json:"update_every"`
+		Columns     map[string]column `json:"columns"`
+		Data        [][]any           `json:"data"`
+	}{
+		Status:      200,
+		Type:        "table",
+		HasHistory:  false,
+		Help:        t.Help,
+		UpdateEvery: t.UpdateEvery,
+		Columns:     columns,
+		Data:        rows,
+	})
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionTable_MarshalJSON(t *testing.T) {
+	tests := map[string]struct {
+		table    *FunctionTable
+		expected string
+	}{
+		"empty table": {
+			table:    &FunctionTable{},
+			expected: `{"status":200,"type":"table","has_history":false,"update_every":0,"columns":{},"data":[]}`,
+		},
+		"table with rows": {
+			table: func() *FunctionTable {
+				tbl := &FunctionTable{
+					Help:        "containers",
+					UpdateEvery: 1,
+					Columns: []FunctionColumn{
+						{ID: "id", Type: ColumnString, UniqueKey: true, Hidden: true},
+						{ID: "name", Name: "Name", Type: ColumnString},
+						{ID: "size", Name: "Size", Type: ColumnInteger, Units: "bytes"},
+					},
+				}
+				tbl.AddRow("1", "first", 10)
+				tbl.AddRow("2", "second", 20)
+				return tbl
+			}(),
+			expected: `{"status":200,"type":"table","has_history":false,"help":"containers","update_every":1,` +
+				`"columns":{` +
+				`"id":{"index":0,"unique_key":true,"name":"id","type":"string","visible":false},` +
+				`"name":{"index":1,"unique_key":false,"name":"Name","type":"string","visible":true},` +
+				`"size":{"index":2,"unique_key":false,"name":"Size","type":"integer","units":"bytes","visible":true}},` +
+				`"data":[["1","first",10],["2","second",20]]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bs, err := json.Marshal(test.table)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, test.expected, string(bs))
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// healthWindow is the number of the last data collections used to calculate the collection duration percentiles.
+const healthWindow = 60
+
+func newHealthCharts(pluginName, jobFullName string) Charts {
+	chart := func(name, title, units string, prio int, dims ...*Dim) *Chart {
+		return &Chart{
+			typ:      "netdata",
+			ID:       fmt.Sprintf("%s_of_%s", name, jobFullName),
+			Title:    title,
+			Units:    units,
+			Fam:      pluginName,
+			Ctx:      fmt.Sprintf("netdata.%s_plugin_job_%s", pluginCtxName(pluginName), name),
+			Priority: prio,
+			Dims:     dims,
+		}
+	}
+
+	return Charts{
+		chart("collection_duration", "Data collection duration", "ms", 145010,
+			&Dim{ID: "duration_p50", Name: "p50"},
+			&Dim{ID: "duration_p90", Name: "p90"},
+			&Dim{ID: "duration_p99", Name: "p99"},
+		),
+		chart("collections", "Data collections", "collections/s", 145011,
+			&Dim{ID: "collections_success", Name: "success", Algo: Incremental},
+			&Dim{ID: "collections_failed", Name: "failed", Algo: Incremental},
+		),
+		chart("consecutive_failures", "Consecutive failed data collections", "failures", 145012,
+			&Dim{ID: "consecutive_failures", Name: "failures"},
+		),
+		chart("penalty", "Data collection penalty", "seconds", 145013,
+			&Dim{ID: "penalty"},
+		),
+		chart("charts", "Charts and dimensions", "charts", 145014,
+			&Dim{ID: "charts"},
+			&Dim{ID: "dimensions"},
+		),
+		chart("collected_metrics", "Collected metrics", "metrics", 145015,
+			&Dim{ID: "collected_metrics", Name: "metrics"},
+		),
+	}
+}
+
+// jobHealth keeps the job data collection statistics for the job health charts.
+type jobHealth struct {
+	charts Charts
+
+	durations []int64 // the last healthWindow data collections durations (ms), a ring buffer
+	next      int
+	success   int64
+	failed    int64
+}
+
+func newJobHealth(pluginName, jobFullName string) *jobHealth {
+	return &jobHealth{
+		charts:    newHealthCharts(pluginName, jobFullName),
+		durations: make([]int64, 0, healthWindow),
+	}
+}
+
+func (h *jobHealth) observe(duration time.Duration, ok bool) {
+	ms := duration.Milliseconds()
+	if len(h.durations) < healthWindow {
+		h.durations = append(h.durations, ms)
+	} else {
+		h.durations[h.next] = ms
+	}
+	h.next = (h.next + 1) % healthWindow
+
+	if ok {
+		h.success++
+	} else {
+		h.failed++
+	}
+}
+
+func (h *jobHealth) percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (len(sorted)*p+99)/100 - 1
+	return sorted[max(idx, 0)]
+}
+
+// updateHealth sends the job health charts if they are enabled. It is called after every data collection.
+func (j *Job) updateHealth(duration time.Duration, ok bool, collected int, sinceLastRun int) {
+	if !j.healthCharts || ndInternalMonitoringDisabled {
+		return
+	}
+
+	h := j.health
+	h.observe(duration, ok)
+
+	sorted := slices.Clone(h.durations)
+	slices.Sort(sorted)
+
+	mx := map[string]int64{
+		"duration_p50":         h.percentile(sorted, 50),
+		"duration_p90":         h.percentile(sorted, 90),
+		"duration_p99":         h.percentile(sorted, 99),
+		"collections_success":  h.success,
+		"collections_failed":   h.failed,
+		"consecutive_failures": int64(j.retries),
+		"penalty":              int64(j.curPenalty),
+		"charts":               int64(j.chartsCount),
+		"dimensions":           int64(j.dimsCount),
+		"collected_metrics":    int64(collected),
+	}
+
+	for _, chart := range h.charts {
+		if !chart.created {
+			j.createChart(chart)
+		}
+		j.updateChart(chart, mx, sinceLastRun)
+	}
+}
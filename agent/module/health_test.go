@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobHealth_observe(t *testing.T) {
+	h := newJobHealth(pluginName, modName+"_"+jobName)
+
+	for i := 1; i <= healthWindow+40; i++ {
+		h.observe(time.Duration(i)*time.Millisecond, i%10 != 0)
+	}
+
+	assert.Len(t, h.durations, healthWindow)
+	assert.Equal(t, int64(90), h.success)
+	assert.Equal(t, int64(10), h.failed)
+
+	sorted := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, int64(5), h.percentile(sorted, 50))
+	assert.Equal(t, int64(9), h.percentile(sorted, 90))
+	assert.Equal(t, int64(10), h.percentile(sorted, 99))
+	assert.Equal(t, int64(0), h.percentile(nil, 50))
+}
+
+func TestJob_runOnce_HealthCharts(t *testing.T) {
+	var calls int
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}, {ID: "id2"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			if calls++; calls == 2 {
+				return nil
+			}
+			return map[string]int64{"id1": 1, "id2": 2, "id3": 3}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.out = &buf
+	job.healthCharts = true
+
+	job.runOnce()
+	out := buf.String()
+	assert.Contains(t, out, "CHART 'netdata.collection_duration_of_module_job'")
+	assert.Contains(t, out, "SET 'success' = 1")
+	assert.Contains(t, out, "SET 'failed' = 0")
+	assert.Contains(t, out, "SET 'charts' = 1")
+	assert.Contains(t, out, "SET 'dimensions' = 2")
+	assert.Contains(t, out, "SET 'metrics' = 3")
+
+	buf.Reset()
+	job.runOnce()
+	out = buf.String()
+	assert.Contains(t, out, "SET 'failed' = 1")
+	assert.Contains(t, out, "SET 'failures' = 1")
+	assert.Contains(t, out, "SET 'metrics' = 0")
+}
+
+func TestJob_runOnce_HealthCharts_Disabled(t *testing.T) {
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 { return map[string]int64{"id1": 1} },
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.out = &buf
+
+	job.runOnce()
+
+	assert.Contains(t, buf.String(), "SET 'id1' = 1")
+	assert.NotContains(t, buf.String(), "collection_duration_of_module_job", "the health charts are sent by default")
+}
+
+func TestJob_runOnce_HealthCharts_Panic(t *testing.T) {
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 { panic("panic in Collect") },
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.out = &buf
+	job.healthCharts = true
+
+	job.runOnce()
+	out := buf.String()
+
+	assert.True(t, job.Panicked())
+	assert.Equal(t, 1, job.retries)
+	assert.Contains(t, out, "CHART 'netdata.collection_duration_of_module_job'")
+	assert.Contains(t, out, "SET 'success' = 0")
+	assert.Contains(t, out, "SET 'failed' = 1")
+	assert.Contains(t, out, "SET 'failures' = 1")
+}
@@ -10,8 +10,10 @@ import (
 	"os"
 	"regexp"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/netdata/go.d.plugin/agent/netdataapi"
@@ -38,19 +40,22 @@ var reSpace = regexp.MustCompile(`\s+`)
 
 var ndInternalMonitoringDisabled = os.Getenv("NETDATA_INTERNALS_MONITORING") == "NO"
 
-func newRuntimeChart(pluginName string) *Chart {
+func pluginCtxName(pluginName string) string {
 	// this is needed to keep the same name as we had before https://github.com/netdata/go.d.plugin/issues/650
 	ctxName := pluginName
 	if ctxName == "go.d" {
 		ctxName = "go"
 	}
-	ctxName = reSpace.ReplaceAllString(ctxName, "_")
+	return reSpace.ReplaceAllString(ctxName, "_")
+}
+
+func newRuntimeChart(pluginName string) *Chart {
 	return &Chart{
 		typ:      "netdata",
 		Title:    "Execution time",
 		Units:    "ms",
 		Fam:      pluginName,
-		Ctx:      fmt.Sprintf("netdata.%s_plugin_execution_time", ctxName),
+		Ctx:      fmt.Sprintf("netdata.%s_plugin_execution_time", pluginCtxName(pluginName)),
 		Priority: 145000,
 		Dims: Dims{
 			{ID: "time"},
@@ -58,6 +63,21 @@ func newRuntimeChart(pluginName string) *Chart {
 	}
 }
 
+func newCollectIssuesChart(pluginName string) *Chart {
+	return &Chart{
+		typ:      "netdata",
+		Title:    "Data collection timeouts and skipped ticks",
+		Units:    "events/s",
+		Fam:      pluginName,
+		Ctx:      fmt.Sprintf("netdata.%s_plugin_collection_issues", pluginCtxName(pluginName)),
+		Priority: 145002,
+		Dims: Dims{
+			{ID: "timeouts", Algo: Incremental},
+			{ID: "skipped_ticks", Algo: Incremental},
+		},
+	}
+}
+
 type JobConfig struct {
 	PluginName      string
 	Name            string
@@ -70,6 +90,14 @@ type JobConfig struct {
 	AutoDetectEvery int
 	Priority        int
 	IsStock         bool
+	CollectTimeout  time.Duration
+	RetryPolicy     RetryPolicy
+	Schedule        *Schedule
+	ChartRules      ChartRules
+	DerivedCharts   DerivedCharts
+	Limits          Limits
+	ObsoleteAfter   int
+	HealthCharts    bool
 
 	VnodeGUID     string
 	VnodeHostname string
@@ -80,6 +108,17 @@ const (
 	penaltyStep = 5
 	maxPenalty  = 600
 	infTries    = -1
+
+	// stuckWarnIntervals is the number of data collection intervals after which a job
+	// that hasn't finished its data collection is considered stuck.
+	stuckWarnIntervals = 5
+
+	// scheduleTolerance is how early a scheduled run may start, the ticks are not exactly on time.
+	scheduleTolerance = time.Millisecond * 500
+
+	// pendingCollectWait is how long a stopping job waits for its timed out data collection to finish
+	// before cleaning up the module.
+	pendingCollectWait = time.Second * 5
 )
 
 func NewJob(cfg JobConfig) *Job {
@@ -89,21 +128,33 @@ func NewJob(cfg JobConfig) *Job {
 		AutoDetectEvery: cfg.AutoDetectEvery,
 		AutoDetectTries: infTries,
 
-		pluginName:  cfg.PluginName,
-		name:        cfg.Name,
-		moduleName:  cfg.ModuleName,
-		fullName:    cfg.FullName,
-		updateEvery: cfg.UpdateEvery,
-		priority:    cfg.Priority,
-		isStock:     cfg.IsStock,
-		module:      cfg.Module,
-		labels:      cfg.Labels,
-		out:         cfg.Out,
-		runChart:    newRuntimeChart(cfg.PluginName),
-		stop:        make(chan struct{}),
-		tick:        make(chan int),
-		buf:         &buf,
-		api:         netdataapi.New(&buf),
+		pluginName:    cfg.PluginName,
+		name:          cfg.Name,
+		moduleName:    cfg.ModuleName,
+		fullName:      cfg.FullName,
+		updateEvery:   cfg.UpdateEvery,
+		priority:      cfg.Priority,
+		timeout:       cfg.CollectTimeout,
+		pendingWait:   pendingCollectWait,
+		retryPolicy:   cfg.RetryPolicy,
+		schedule:      cfg.Schedule,
+		chartRules:    cfg.ChartRules,
+		derived:       cfg.DerivedCharts,
+		limits:        cfg.Limits,
+		obsoleteAfter: cfg.ObsoleteAfter,
+		isStock:       cfg.IsStock,
+		module:        cfg.Module,
+		labels:        cfg.Labels,
+		out:           cfg.Out,
+		runChart:      newRuntimeChart(cfg.PluginName),
+		limitsChart:   newLimitsChart(cfg.PluginName),
+		issuesChart:   newCollectIssuesChart(cfg.PluginName),
+		health:        newJobHealth(cfg.PluginName, cfg.FullName),
+		healthCharts:  cfg.HealthCharts,
+		stop:          make(chan struct{}),
+		tick:          make(chan int),
+		buf:           &buf,
+		api:           netdataapi.New(&buf),
 
 		vnodeGUID:     cfg.VnodeGUID,
 		vnodeHostname: cfg.VnodeHostname,
@@ -135,6 +186,17 @@ type Job struct {
 	AutoDetectTries int
 	priority        int
 	labels          map[string]string
+	chartRules      ChartRules
+	derived         DerivedCharts
+
+	limits        Limits
+	chartsCount   int
+	dimsCount     int
+	refusedCharts int64
+	refusedDims   int64
+	limitWarned   bool
+
+	obsoleteAfter int
 
 	*logger.Logger
 
@@ -144,16 +206,43 @@ type Job struct {
 
 	initialized bool
 	panicked    bool
-
-	runChart *Chart
-	charts   *Charts
-	tick     chan int
-	out      io.Writer
-	buf      *bytes.Buffer
-	api      *netdataapi.API
-
-	retries int
-	prevRun time.Time
+	failReason  string
+
+	runChart     *Chart
+	limitsChart  *Chart
+	issuesChart  *Chart
+	fnChart      *Chart // the chart the job functions are announced with
+	health       *jobHealth
+	healthCharts bool // the job health charts are sent (opt-in, "health_charts" job option)
+	charts       *Charts
+	tick         chan int
+	out          io.Writer
+	buf          *bytes.Buffer
+	api          *netdataapi.API
+
+	retries     int
+	curPenalty  int
+	retryPolicy RetryPolicy
+	gaveUp      bool
+	prevRun     time.Time
+
+	// OnGiveUp is called from the job goroutine when the job gives up data collection (see RetryPolicy).
+	OnGiveUp func(reason string)
+
+	schedule     *Schedule
+	nextRun      time.Time
+	scheduleDone bool             // the schedule has no next run
+	samples      samples          // the sub-second data collections results
+	lastMetrics  map[string]int64 // the last successfully collected metrics, used to fill the gaps between scheduled runs and by Snapshot
+	repeating    bool
+
+	timeout      time.Duration
+	pending      chan collectResult // the result of the timed out Collect that is still running
+	collectStart atomic.Int64       // unix nano, 0 if the job is not collecting
+	stuckWarned  atomic.Bool
+	timeouts     atomic.Int64
+	skippedTicks atomic.Int64
+	pendingWait  time.Duration
 
 	stop chan struct{}
 
@@ -167,32 +256,42 @@ type Job struct {
 const NetdataChartIDMaxLength = 1200
 
 // FullName returns job full name.
-func (j Job) FullName() string {
+func (j *Job) FullName() string {
 	return j.fullName
 }
 
 // ModuleName returns job module name.
-func (j Job) ModuleName() string {
+func (j *Job) ModuleName() string {
 	return j.moduleName
 }
 
 // Name returns job name.
-func (j Job) Name() string {
+func (j *Job) Name() string {
 	return j.name
 }
 
 // Panicked returns 'panicked' flag value.
-func (j Job) Panicked() bool {
+func (j *Job) Panicked() bool {
 	return j.panicked
 }
 
+// UpdateEvery returns the job data collection interval in seconds.
+func (j *Job) UpdateEvery() int {
+	return j.updateEvery
+}
+
+// RetryPolicy returns the job retry policy.
+func (j *Job) RetryPolicy() RetryPolicy {
+	return j.retryPolicy
+}
+
 // AutoDetectionEvery returns value of AutoDetectEvery.
-func (j Job) AutoDetectionEvery() int {
+func (j *Job) AutoDetectionEvery() int {
 	return j.AutoDetectEvery
 }
 
 // RetryAutoDetection returns whether it is needed to retry autodetection.
-func (j Job) RetryAutoDetection() bool {
+func (j *Job) RetryAutoDetection() bool {
 	return j.AutoDetectEvery > 0 && (j.AutoDetectTries == infTries || j.AutoDetectTries > 0)
 }
 
@@ -202,6 +301,7 @@ func (j *Job) AutoDetection() (ok bool) {
 		if r := recover(); r != nil {
 			ok = false
 			j.panicked = true
+			j.failReason = fmt.Sprintf("panic: %v", r)
 			j.disableAutoDetection()
 
 			j.Errorf("PANIC %v", r)
@@ -219,6 +319,7 @@ func (j *Job) AutoDetection() (ok bool) {
 	}
 
 	if ok = j.init(); !ok {
+		j.setFailReason("init failed")
 		j.Error("init failed")
 		j.Unmute()
 		j.disableAutoDetection()
@@ -226,6 +327,7 @@ func (j *Job) AutoDetection() (ok bool) {
 	}
 
 	if ok = j.check(); !ok {
+		j.setFailReason("check failed")
 		j.Error("check failed")
 		j.Unmute()
 		return
@@ -235,44 +337,123 @@ func (j *Job) AutoDetection() (ok bool) {
 
 	j.Info("check success")
 	if ok = j.postCheck(); !ok {
+		j.setFailReason("postCheck failed")
 		j.Error("postCheck failed")
 		j.disableAutoDetection()
 		return
 	}
 
+	j.failReason = ""
+
 	return true
 }
 
+// Functions returns the module functions, nil if the module doesn't implement FunctionProvider.
+func (j *Job) Functions() map[string]Function {
+	if v, ok := j.module.(FunctionProvider); ok {
+		return v.Functions()
+	}
+	return nil
+}
+
+// FunctionName returns the job function full name ("<job full name>:<function name>").
+func (j *Job) FunctionName(name string) string {
+	return j.FullName() + ":" + name
+}
+
+// FailReason returns the reason of the last failed auto-detection, it includes the last error logged by the module.
+func (j *Job) FailReason() string {
+	return j.failReason
+}
+
+func (j *Job) setFailReason(reason string) {
+	if msg := j.LastError(); msg != "" {
+		reason += ": " + msg
+	}
+	j.failReason = reason
+}
+
 // Tick Tick.
 func (j *Job) Tick(clock int) {
 	select {
 	case j.tick <- clock:
 	default:
+		j.skippedTicks.Add(1)
 		j.Debug("skip the tick due to previous run hasn't been finished")
+		j.checkStuck()
 	}
 }
 
 // Start starts job main loop.
 func (j *Job) Start() {
-	j.Infof("started, data collection interval %ds", j.updateEvery)
+	switch {
+	case j.schedule != nil:
+		j.Infof("started, data collection schedule '%s', timeout %s", j.schedule, j.timeout)
+	case j.timeout > 0:
+		j.Infof("started, data collection interval %ds, timeout %s", j.updateEvery, j.timeout)
+	default:
+		j.Infof("started, data collection interval %ds", j.updateEvery)
+	}
 	defer func() { j.Info("stopped") }()
 
+	var sampleC <-chan time.Time
+	if j.schedule != nil && j.schedule.IsSubSecond() {
+		tk := time.NewTicker(j.schedule.Interval)
+		defer tk.Stop()
+		sampleC = tk.C
+	}
+
 LOOP:
 	for {
 		select {
 		case <-j.stop:
 			break LOOP
+		case <-sampleC:
+			if !j.gaveUp {
+				j.sample()
+			}
 		case t := <-j.tick:
-			if t%(j.updateEvery+j.penalty()) == 0 {
+			switch {
+			case j.gaveUp:
+			case sampleC != nil:
+				j.runSampled(t)
+			case j.schedule != nil:
+				j.runScheduled(t)
+			case t%(j.updateEvery+j.penalty()) == 0:
 				j.runOnce()
 			}
 		}
 	}
-	j.module.Cleanup()
-	j.Cleanup()
+	if j.waitPending() {
+		j.module.Cleanup()
+	} else {
+		j.Warningf("timed out data collection hasn't finished in %s, skipping the module cleanup", j.pendingWait)
+	}
+	if !j.gaveUp {
+		j.Cleanup()
+	}
 	j.stop <- struct{}{}
 }
 
+// waitPending waits (bounded) for the timed out Collect that is still running.
+// The module must not be cleaned up concurrently with Collect.
+func (j *Job) waitPending() bool {
+	if j.pending == nil {
+		return true
+	}
+
+	t := time.NewTimer(j.pendingWait)
+	defer t.Stop()
+
+	select {
+	case <-j.pending:
+		j.pending = nil
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
 // Stop stops job main loop. It blocks until the job is stopped.
 func (j *Job) Stop() {
 	// TODO: should have blocking and non blocking stop
@@ -302,6 +483,20 @@ func (j *Job) Cleanup() {
 		j.runChart.MarkRemove()
 		j.createChart(j.runChart)
 	}
+	if j.limitsChart.created {
+		j.limitsChart.MarkRemove()
+		j.createChart(j.limitsChart)
+	}
+	if j.issuesChart.created {
+		j.issuesChart.MarkRemove()
+		j.createChart(j.issuesChart)
+	}
+	for _, chart := range j.health.charts {
+		if chart.created {
+			chart.MarkRemove()
+			j.createChart(chart)
+		}
+	}
 	if j.charts != nil {
 		for _, chart := range *j.charts {
 			if chart.created {
@@ -343,42 +538,209 @@ func (j *Job) postCheck() bool {
 		j.Errorf("charts check: %v", err)
 		return false
 	}
+	if err := j.charts.Add(j.derived.charts(j.moduleName)...); err != nil {
+		j.Errorf("derived charts: %v", err)
+		return false
+	}
 	return true
 }
 
+// RunOnce runs a single data collection and writes the result to the job output.
+// It is meant for one-shot runs and tests, the job must pass AutoDetection first.
+func (j *Job) RunOnce() {
+	j.runOnce()
+}
+
 func (j *Job) runOnce() {
 	curTime := time.Now()
+	metrics := j.collect()
+	// a panic is a failed data collection
+	j.emit(metrics, !j.panicked, curTime)
+}
+
+// emit processes the collected metrics, updates the job state and health and writes the result to the job output.
+func (j *Job) emit(metrics map[string]int64, collected bool, curTime time.Time) {
 	sinceLastRun := calcSinceLastRun(curTime, j.prevRun)
 	j.prevRun = curTime
 
-	metrics := j.collect()
-
-	if j.panicked {
-		return
+	var ok bool
+	if collected {
+		if len(metrics) > 0 {
+			metrics = j.derived.eval(metrics)
+		}
+		ok = j.processMetrics(metrics, curTime, sinceLastRun)
 	}
 
-	if j.processMetrics(metrics, curTime, sinceLastRun) {
+	if ok {
 		j.retries = 0
+		j.lastMetrics = metrics
 	} else {
 		j.retries++
 	}
+	j.curPenalty = j.retryPolicy.Penalty(j.retries, j.updateEvery)
+
+	j.updateHealth(time.Since(curTime), ok, len(metrics), sinceLastRun)
+
+	_, _ = io.Copy(j.out, j.buf)
+	j.buf.Reset()
+
+	j.checkGiveUp()
+}
+
+// sample runs a sub-second data collection, the result is sent by runSampled.
+func (j *Job) sample() {
+	metrics := j.collect()
+	if j.panicked || len(metrics) == 0 {
+		j.samples.failed++
+		return
+	}
+	j.samples.add(metrics)
+}
+
+// runSampled sends the aggregated results of the sub-second data collections every update_every seconds.
+func (j *Job) runSampled(clock int) {
+	if j.updateEvery > 0 && clock%j.updateEvery != 0 {
+		return
+	}
+	if j.samples.empty() {
+		return
+	}
+
+	metrics := j.samples.flush(j.charts)
+	j.emit(metrics, metrics != nil, time.Now())
+}
+
+func (j *Job) checkGiveUp() {
+	if n := j.retryPolicy.GiveUpAfter; n > 0 && j.retries >= n {
+		j.giveUp()
+	}
+}
+
+// runScheduled runs data collection if the schedule is due, otherwise it re-sends the last collected values
+// every update_every seconds, so charts don't have gaps between runs.
+func (j *Job) runScheduled(clock int) {
+	if j.updateEvery > 0 && clock%j.updateEvery != 0 {
+		return
+	}
+
+	now := time.Now()
+	if !j.scheduleDone && (j.nextRun.IsZero() || !now.Add(scheduleTolerance).Before(j.nextRun)) {
+		if j.nextRun = j.schedule.Next(now); j.nextRun.IsZero() {
+			j.scheduleDone = true
+			j.Warningf("schedule '%s' has no next run, this is the last data collection", j.schedule)
+		} else {
+			j.Debugf("next data collection at %s", j.nextRun.Format(time.RFC3339))
+		}
+		j.runOnce()
+		return
+	}
+
+	if j.lastMetrics == nil {
+		return
+	}
+
+	sinceLastRun := calcSinceLastRun(now, j.prevRun)
+	j.prevRun = now
+
+	j.repeating = true
+	j.processMetrics(j.lastMetrics, now, sinceLastRun)
+	j.repeating = false
 
 	_, _ = io.Copy(j.out, j.buf)
 	j.buf.Reset()
 }
 
-func (j *Job) collect() (result map[string]int64) {
+func (j *Job) giveUp() {
+	j.failReason = fmt.Sprintf("gave up after %d consecutive failed data collections", j.retries)
+	j.Errorf("%s, stopping data collection", j.failReason)
+	j.gaveUp = true
+	j.Cleanup()
+	if j.OnGiveUp != nil {
+		j.OnGiveUp(j.failReason)
+	}
+}
+
+type collectResult struct {
+	metrics  map[string]int64
+	panicked bool
+}
+
+func (j *Job) collect() map[string]int64 {
 	j.panicked = false
+
+	if j.timeout <= 0 {
+		res := j.safeCollect()
+		j.panicked = res.panicked
+		return res.metrics
+	}
+
+	if j.pending != nil {
+		select {
+		case <-j.pending:
+			// the result is outdated, it is dropped
+			j.pending = nil
+		default:
+			j.skippedTicks.Add(1)
+			j.Debug("skip data collection due to previous timed out run hasn't been finished")
+			j.checkStuck()
+			return nil
+		}
+	}
+
+	ch := make(chan collectResult, 1)
+	go func() { ch <- j.safeCollect() }()
+
+	t := time.NewTimer(j.timeout)
+	defer t.Stop()
+
+	select {
+	case res := <-ch:
+		j.panicked = res.panicked
+		return res.metrics
+	case <-t.C:
+		j.pending = ch
+		j.timeouts.Add(1)
+		j.Warningf("data collection timed out after %s", j.timeout)
+		return nil
+	}
+}
+
+func (j *Job) safeCollect() (res collectResult) {
+	j.collectStart.Store(time.Now().UnixNano())
+	defer func() {
+		j.collectStart.Store(0)
+		if j.stuckWarned.Swap(false) {
+			j.Info("data collection is not stuck anymore")
+		}
+	}()
 	defer func() {
 		if r := recover(); r != nil {
-			j.panicked = true
+			res.panicked = true
 			j.Errorf("PANIC: %v", r)
 			if logger.Level.Enabled(slog.LevelDebug) {
 				j.Errorf("STACK: %s", debug.Stack())
 			}
 		}
 	}()
-	return j.module.Collect()
+	res.metrics = j.module.Collect()
+	return res
+}
+
+func (j *Job) checkStuck() {
+	start := j.collectStart.Load()
+	if start == 0 || j.updateEvery <= 0 {
+		return
+	}
+
+	stuck := time.Since(time.Unix(0, start))
+	if stuck < time.Duration(stuckWarnIntervals*j.updateEvery)*time.Second {
+		return
+	}
+
+	if !j.stuckWarned.Swap(true) {
+		j.Warningf("data collection is stuck for %s (%d data collection intervals)",
+			stuck.Round(time.Second), int(stuck/time.Second)/j.updateEvery)
+	}
 }
 
 func (j *Job) processMetrics(metrics map[string]int64, startTime time.Time, sinceLastRun int) bool {
@@ -398,7 +760,9 @@ func (j *Job) processMetrics(metrics map[string]int64, startTime time.Time, sinc
 
 	elapsed := int64(durationTo(time.Since(startTime), time.Millisecond))
 
-	var i, updated int
+	// the charts and dimensions counted against the job limits are recalculated on every run,
+	// so the ones removed by the module (in any way) are released
+	var i, updated, active, charts, dims int
 	for _, chart := range *j.charts {
 		if !chart.created {
 			typeID := fmt.Sprintf("%s.%s", j.FullName(), chart.ID)
@@ -407,30 +771,87 @@ func (j *Job) processMetrics(metrics map[string]int64, startTime time.Time, sinc
 					len(typeID), NetdataChartIDMaxLength, typeID)
 				chart.ignore = true
 			}
+			j.chartRules.apply(chart)
+			j.applyLimits(chart)
 			j.createChart(chart)
+		} else if !chart.remove {
+			j.applyLimits(chart)
 		}
 		if chart.remove {
 			continue
 		}
 		(*j.charts)[i] = chart
 		i++
+		j.countLimits(chart, &charts, &dims)
+		if isChartActive(chart) {
+			active++
+		}
 		if len(metrics) == 0 || chart.Obsolete {
 			continue
 		}
+		if chart.stale {
+			if !chartHasMetrics(chart, metrics) {
+				continue
+			}
+			j.reviveChart(chart)
+		}
 		if j.updateChart(chart, metrics, sinceLastRun) {
 			updated++
+		} else if j.obsoleteAfter > 0 && chart.Retries >= j.obsoleteAfter {
+			j.obsoleteStaleChart(chart)
 		}
 	}
 	*j.charts = (*j.charts)[:i]
+	j.chartsCount, j.dimsCount = charts, dims
+
+	if !ndInternalMonitoringDisabled && !j.repeating {
+		mx := make(map[string]int64)
+		if updated > 0 {
+			mx["time"] = elapsed
+		}
+		j.updateChart(j.runChart, mx, sinceLastRun)
+
+		// the chart is created on the first timeout or skipped tick, most jobs never have them
+		if timeouts, skipped := j.timeouts.Load(), j.skippedTicks.Load(); timeouts > 0 || skipped > 0 {
+			if !j.issuesChart.created {
+				j.issuesChart.ID = fmt.Sprintf("collection_issues_of_%s", j.FullName())
+				j.createChart(j.issuesChart)
+			}
+			mx := map[string]int64{
+				"timeouts":      timeouts,
+				"skipped_ticks": skipped,
+			}
+			j.updateChart(j.issuesChart, mx, sinceLastRun)
+		}
 
-	if updated == 0 {
+		if j.refusedCharts > 0 || j.refusedDims > 0 {
+			if !j.limitsChart.created {
+				j.limitsChart.ID = fmt.Sprintf("refused_charts_of_%s", j.FullName())
+				j.createChart(j.limitsChart)
+			}
+			mx := map[string]int64{
+				"refused_charts": j.refusedCharts,
+				"refused_dims":   j.refusedDims,
+			}
+			j.updateChart(j.limitsChart, mx, sinceLastRun)
+		}
+	}
+
+	// the charts dropped by the job chart rules (or refused because of the job limits) are not a failed collection
+	return updated > 0 || (active == 0 && len(metrics) > 0)
+}
+
+// isChartActive reports whether the chart is sent to Netdata: it is not dropped (refused) and has dimensions to send.
+func isChartActive(chart *Chart) bool {
+	if chart.ignore {
 		return false
 	}
-	if !ndInternalMonitoringDisabled {
-		j.updateChart(j.runChart, map[string]int64{"time": elapsed}, sinceLastRun)
+	for _, dim := range chart.Dims {
+		if !dim.drop && !dim.remove {
+			return true
+		}
 	}
-
-	return true
+	return false
 }
 
 func (j *Job) createChart(chart *Chart) {
@@ -464,31 +885,18 @@ func (j *Job) createChart(chart *Chart) {
 		return
 	}
 
-	seen := make(map[string]bool)
-	for _, l := range chart.Labels {
-		if l.Key != "" {
-			seen[l.Key] = true
-			ls := l.Source
-			// the default should be auto
-			// https://github.com/netdata/netdata/blob/cc2586de697702f86a3c34e60e23652dd4ddcb42/database/rrd.h#L205
-			if ls == 0 {
-				ls = LabelSourceAuto
-			}
-			_ = j.api.CLABEL(l.Key, l.Value, ls)
-		}
+	for _, l := range j.chartLabels(chart) {
+		_ = j.api.CLABEL(l.Key, l.Value, l.Source)
 	}
-	for k, v := range j.labels {
-		if !seen[k] {
-			_ = j.api.CLABEL(k, v, LabelSourceConf)
-		}
-	}
-	_ = j.api.CLABEL("_collect_job", j.Name(), LabelSourceAuto)
 	_ = j.api.CLABELCOMMIT()
 
 	for _, dim := range chart.Dims {
+		if dim.drop {
+			continue
+		}
 		_ = j.api.DIMENSION(
 			firstNotEmpty(dim.Name, dim.ID),
-			dim.Name,
+			firstNotEmpty(dim.rename, dim.Name),
 			dim.Algo.String(),
 			handleZero(dim.Mul),
 			handleZero(dim.Div),
@@ -502,9 +910,36 @@ func (j *Job) createChart(chart *Chart) {
 			_ = j.api.VARIABLE(v.ID, v.Value)
 		}
 	}
+	if j.isFunctionsChart(chart) {
+		j.announceFunctions()
+	}
 	_ = j.api.EMPTYLINE()
 }
 
+// isFunctionsChart reports whether the job functions belong to the chart. It is the runtime chart,
+// or the first created chart if the internal monitoring is disabled.
+func (j *Job) isFunctionsChart(chart *Chart) bool {
+	if j.fnChart == nil && (chart == j.runChart || ndInternalMonitoringDisabled) {
+		j.fnChart = chart
+	}
+	return chart == j.fnChart
+}
+
+func (j *Job) announceFunctions() {
+	fns := j.Functions()
+	names := make([]string, 0, len(fns))
+	for name, fn := range fns {
+		if fn.Handler != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_ = j.api.FUNCTION(j.FunctionName(name), FunctionTimeout, fns[name].Help)
+	}
+}
+
 func (j *Job) updateChart(chart *Chart, collected map[string]int64, sinceLastRun int) bool {
 	if chart.ignore {
 		dims := chart.Dims[:0]
@@ -533,6 +968,9 @@ func (j *Job) updateChart(chart *Chart, collected map[string]int64, sinceLastRun
 		}
 		chart.Dims[i] = dim
 		i++
+		if dim.drop {
+			continue
+		}
 		if v, ok := collected[dim.ID]; !ok {
 			_ = j.api.SETEMPTY(firstNotEmpty(dim.Name, dim.ID))
 		} else {
@@ -562,12 +1000,63 @@ func (j *Job) updateChart(chart *Chart, collected map[string]int64, sinceLastRun
 	return chart.updated
 }
 
-func (j Job) penalty() int {
-	v := j.retries / penaltyStep * penaltyStep * j.updateEvery / 2
-	if v > maxPenalty {
-		return maxPenalty
+// obsoleteStaleChart obsoletes the chart that hasn't had data for obsoleteAfter intervals.
+// The chart is kept, it is re-created once its dimensions appear in the collected metrics again.
+func (j *Job) obsoleteStaleChart(chart *Chart) {
+	j.Debugf("chart '%s' has no data for %d data collection intervals, obsoleting it", chart.ID, chart.Retries)
+	chart.stale = true
+	chart.Obsolete = true
+	j.createChart(chart)
+	chart.Obsolete = false
+}
+
+func (j *Job) reviveChart(chart *Chart) {
+	j.Debugf("chart '%s' has data again, re-creating it", chart.ID)
+	chart.stale = false
+	chart.Retries = 0
+	j.createChart(chart)
+}
+
+func chartHasMetrics(chart *Chart, metrics map[string]int64) bool {
+	for _, dim := range chart.Dims {
+		if _, ok := metrics[dim.ID]; ok && !dim.remove && !dim.drop {
+			return true
+		}
 	}
-	return v
+	return false
+}
+
+func (j *Job) penalty() int {
+	return j.curPenalty
+}
+
+// chartLabels returns the chart labels: the chart own labels, then the chart rules and the job labels that don't override them.
+func (j *Job) chartLabels(chart *Chart) []Label {
+	var labels []Label
+	seen := make(map[string]bool)
+	for _, l := range chart.Labels {
+		if l.Key != "" {
+			seen[l.Key] = true
+			// the default should be auto
+			// https://github.com/netdata/netdata/blob/cc2586de697702f86a3c34e60e23652dd4ddcb42/database/rrd.h#L205
+			if l.Source == 0 {
+				l.Source = LabelSourceAuto
+			}
+			labels = append(labels, l)
+		}
+	}
+	for k, v := range j.chartRules.labels(chart) {
+		if !seen[k] {
+			seen[k] = true
+			labels = append(labels, Label{Key: k, Value: v, Source: LabelSourceConf})
+		}
+	}
+	for k, v := range j.labels {
+		if !seen[k] {
+			labels = append(labels, Label{Key: k, Value: v, Source: LabelSourceConf})
+		}
+	}
+	return append(labels, Label{Key: "_collect_job", Value: j.Name(), Source: LabelSourceAuto})
 }
 
 func getChartType(chart *Chart, j *Job) string {
@@ -3,12 +3,17 @@
 package module
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -133,6 +138,7 @@ func TestJob_AutoDetection_FailInit(t *testing.T) {
 
 	assert.False(t, job.AutoDetection())
 	assert.True(t, m.CleanupDone)
+	assert.Equal(t, "init failed", job.FailReason())
 }
 
 func TestJob_AutoDetection_FailCheck(t *testing.T) {
@@ -142,6 +148,7 @@ func TestJob_AutoDetection_FailCheck(t *testing.T) {
 			return true
 		},
 		CheckFunc: func() bool {
+			job.Error("connection refused")
 			return false
 		},
 	}
@@ -149,6 +156,7 @@ func TestJob_AutoDetection_FailCheck(t *testing.T) {
 
 	assert.False(t, job.AutoDetection())
 	assert.True(t, m.CleanupDone)
+	assert.Equal(t, "check failed: connection refused", job.FailReason())
 }
 
 func TestJob_AutoDetection_FailPostCheck(t *testing.T) {
@@ -181,6 +189,7 @@ func TestJob_AutoDetection_PanicInit(t *testing.T) {
 
 	assert.False(t, job.AutoDetection())
 	assert.True(t, m.CleanupDone)
+	assert.Equal(t, "panic: panic in Init", job.FailReason())
 }
 
 func TestJob_AutoDetection_PanicCheck(t *testing.T) {
@@ -282,9 +291,516 @@ func TestJob_MainLoop_Panic(t *testing.T) {
 	assert.True(t, m.CleanupDone)
 }
 
+func TestJob_MainLoop_CollectTimeout(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int64
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			calls.Add(1)
+			<-release
+			return map[string]int64{"id1": 1}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.updateEvery = 1
+	job.timeout = time.Millisecond * 100
+	job.out = &buf
+
+	job.runOnce()
+	assert.Equal(t, int64(1), job.timeouts.Load())
+	assert.Equal(t, 1, job.retries)
+	assert.NotNil(t, job.pending)
+
+	// the previous Collect is still running, the job must not call it again
+	job.runOnce()
+	assert.Equal(t, int64(1), calls.Load())
+	assert.Equal(t, int64(1), job.skippedTicks.Load())
+	assert.Equal(t, 2, job.retries)
+
+	close(release)
+	time.Sleep(time.Millisecond * 50)
+
+	job.runOnce()
+	assert.Equal(t, int64(2), calls.Load())
+	assert.Nil(t, job.pending)
+	assert.Equal(t, 0, job.retries)
+
+	out := buf.String()
+	assert.Contains(t, out, "CHART 'netdata.collection_issues_of_module_job' '' 'Data collection timeouts and skipped ticks' 'events/s'")
+	assert.Contains(t, out, "SET 'timeouts' = 1")
+	assert.Contains(t, out, "SET 'skipped_ticks' = 1")
+	assert.Equal(t, Dims{{ID: "time"}}, job.runChart.Dims, "the execution time chart has only the time dimension")
+}
+
+func TestJob_Start_WaitsTimedOutCollect(t *testing.T) {
+	tests := map[string]struct {
+		collectTime time.Duration
+		wantCleanup bool
+	}{
+		"collect finishes while waiting": {collectTime: time.Millisecond * 200, wantCleanup: true},
+		"collect is stuck":               {collectTime: time.Second * 2, wantCleanup: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var collecting atomic.Bool
+			m := &MockModule{
+				ChartsFunc: func() *Charts {
+					return &Charts{
+						&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+					}
+				},
+				CollectFunc: func() map[string]int64 {
+					collecting.Store(true)
+					defer collecting.Store(false)
+					time.Sleep(test.collectTime)
+					return map[string]int64{"id1": 1}
+				},
+			}
+			var cleanupWhileCollecting atomic.Bool
+			m.CleanupFunc = func() { cleanupWhileCollecting.Store(collecting.Load()) }
+
+			job := newTestJob()
+			job.module = m
+			job.charts = job.module.Charts()
+			job.updateEvery = 1
+			job.timeout = time.Millisecond * 50
+			job.pendingWait = time.Second
+
+			go func() {
+				// the tick is skipped if the job loop hasn't started yet
+				for !collecting.Load() {
+					job.Tick(1)
+					time.Sleep(time.Millisecond * 10)
+				}
+				time.Sleep(time.Millisecond * 100)
+				job.Stop()
+			}()
+
+			job.Start()
+
+			assert.Equal(t, int64(1), job.timeouts.Load())
+			assert.Equal(t, test.wantCleanup, m.CleanupDone)
+			assert.False(t, cleanupWhileCollecting.Load())
+		})
+	}
+}
+
+func TestJob_RetryPolicy_GiveUp(t *testing.T) {
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 { return nil },
+	}
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.updateEvery = 1
+	job.retryPolicy = RetryPolicy{GiveUpAfter: 3}
+
+	for i := 0; i < 2; i++ {
+		job.runOnce()
+		assert.False(t, job.gaveUp)
+	}
+	job.runOnce()
+	assert.True(t, job.gaveUp)
+	assert.True(t, (*job.charts)[0].Obsolete)
+}
+
+func TestJob_RetryPolicy_GiveUpOnPanics(t *testing.T) {
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 { panic("panic in Collect") },
+	}
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.updateEvery = 1
+	job.retryPolicy = RetryPolicy{GiveUpAfter: 2}
+	var reason string
+	job.OnGiveUp = func(r string) { reason = r }
+
+	job.runOnce()
+	assert.Equal(t, 1, job.retries)
+	assert.False(t, job.gaveUp)
+
+	job.runOnce()
+	assert.True(t, job.gaveUp)
+	assert.Equal(t, "gave up after 2 consecutive failed data collections", reason)
+}
+
+func TestJob_runScheduled(t *testing.T) {
+	var calls int
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			calls++
+			return map[string]int64{"id1": int64(calls)}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.updateEvery = 1
+	job.out = &buf
+	job.schedule, _ = ParseSchedule("@yearly")
+
+	job.runScheduled(1)
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, buf.String(), "SET 'id1' = 1")
+
+	buf.Reset()
+	for i := 2; i < 5; i++ {
+		job.runScheduled(i)
+	}
+	assert.Equal(t, 1, calls, "collected between scheduled runs")
+	assert.Equal(t, 3, strings.Count(buf.String(), "SET 'id1' = 1"), "last value is not repeated between scheduled runs")
+
+	job.nextRun = time.Now()
+	job.runScheduled(5)
+	assert.Equal(t, 2, calls)
+	assert.True(t, job.nextRun.After(time.Now()))
+}
+
+func TestJob_runScheduled_Interval(t *testing.T) {
+	var calls int
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			calls++
+			return map[string]int64{"id1": int64(calls)}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.updateEvery = 1
+	job.out = &buf
+	job.schedule, _ = ParseSchedule("1h")
+
+	job.runScheduled(1)
+	assert.Equal(t, 1, calls)
+
+	buf.Reset()
+	job.runScheduled(2)
+	assert.Equal(t, 1, calls, "collected between scheduled runs")
+	assert.Contains(t, buf.String(), "SET 'id1' = 1", "last value is not repeated between scheduled runs")
+
+	// the tick is slightly early
+	job.nextRun = time.Now().Add(time.Millisecond * 100)
+	job.runScheduled(3)
+	assert.Equal(t, 2, calls)
+}
+
+func TestJob_Start_SubSecondSchedule(t *testing.T) {
+	var calls atomic.Int64
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "id", Title: "title", Units: "units", Dims: Dims{{ID: "id1"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			calls.Add(1)
+			return map[string]int64{"id1": 1}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.charts = job.module.Charts()
+	job.updateEvery = 1
+	job.out = &buf
+	job.schedule, _ = ParseSchedule("50ms")
+
+	go func() {
+		time.Sleep(time.Millisecond * 520)
+		job.Tick(1)
+		job.Stop()
+	}()
+
+	job.Start()
+
+	assert.GreaterOrEqual(t, calls.Load(), int64(8))
+	assert.Equal(t, 1, strings.Count(buf.String(), "SET 'id1' = 1"), "sub-second results are not sent once per update_every")
+}
+
+func TestJob_checkStuck(t *testing.T) {
+	job := newTestJob()
+	job.updateEvery = 1
+
+	job.checkStuck()
+	assert.False(t, job.stuckWarned.Load())
+
+	job.collectStart.Store(time.Now().Add(-time.Second).UnixNano())
+	job.checkStuck()
+	assert.False(t, job.stuckWarned.Load())
+
+	job.collectStart.Store(time.Now().Add(-time.Second * stuckWarnIntervals).UnixNano())
+	job.checkStuck()
+	assert.True(t, job.stuckWarned.Load())
+}
+
 func TestJob_Tick(t *testing.T) {
 	job := newTestJob()
 	for i := 0; i < 3; i++ {
 		job.Tick(i)
 	}
 }
+
+func TestJob_processMetrics_ChartRules(t *testing.T) {
+	rules := ChartRules{
+		{Chart: "* dropped", Drop: true},
+		{Chart: "= requests", Dimension: "= failed", Drop: true},
+		{Chart: "= requests", Dimension: "= success", Rename: "ok", Hide: true},
+		{Context: "= module.requests", Labels: map[string]string{"tier": "web"}},
+	}
+	assert.NoError(t, rules.Init())
+
+	job := newTestJob()
+	job.chartRules = rules
+	job.charts = &Charts{
+		&Chart{ID: "dropped", Ctx: "module.dropped", Dims: Dims{{ID: "dropped_dim"}}},
+		&Chart{ID: "requests", Ctx: "module.requests", Dims: Dims{{ID: "success"}, {ID: "failed"}}},
+	}
+
+	job.processMetrics(map[string]int64{"dropped_dim": 1, "success": 2, "failed": 3}, time.Now(), 0)
+	out := job.buf.String()
+
+	assert.NotContains(t, out, "dropped")
+	assert.NotContains(t, out, "failed")
+	assert.Contains(t, out, "CLABEL 'tier' 'web' '2'")
+	assert.Contains(t, out, "DIMENSION 'success' 'ok' 'absolute' '1' '1' 'hidden'")
+	assert.Contains(t, out, "SET 'success' = 2")
+}
+
+func TestJob_processMetrics_ChartRules_RenameKeepsDimensionIDs(t *testing.T) {
+	rules := ChartRules{{Chart: "= requests", Dimension: "* code_*", Rename: "codes"}}
+	require.NoError(t, rules.Init())
+
+	job := newTestJob()
+	job.chartRules = rules
+	job.charts = &Charts{
+		&Chart{ID: "requests", Dims: Dims{{ID: "code_200"}, {ID: "code_500"}}},
+	}
+
+	job.processMetrics(map[string]int64{"code_200": 1, "code_500": 2}, time.Now(), 0)
+	out := job.buf.String()
+
+	assert.Contains(t, out, "DIMENSION 'code_200' 'codes'")
+	assert.Contains(t, out, "DIMENSION 'code_500' 'codes'")
+	assert.Contains(t, out, "SET 'code_200' = 1")
+	assert.Contains(t, out, "SET 'code_500' = 2")
+}
+
+func TestJob_processMetrics_ChartRules_DropAll(t *testing.T) {
+	tests := map[string]ChartRules{
+		"all charts dropped":     {{Chart: "*", Drop: true}},
+		"all dimensions dropped": {{Dimension: "*", Drop: true}},
+	}
+
+	for name, rules := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, rules.Init())
+
+			job := newTestJob()
+			job.chartRules = rules
+			job.charts = &Charts{&Chart{ID: "chart", Dims: Dims{{ID: "dim"}}}}
+
+			assert.True(t, job.processMetrics(map[string]int64{"dim": 1}, time.Now(), 0),
+				"the charts dropped by the rules are not a failed collection")
+			assert.False(t, job.processMetrics(nil, time.Now(), 0))
+		})
+	}
+}
+
+func TestJob_DerivedCharts(t *testing.T) {
+	dcs := DerivedCharts{
+		{ID: "hit_ratio", Units: "percentage", Dims: []*DerivedDim{{Name: "ratio", Expr: "div(hits * 100, hits + misses, 0)"}}},
+	}
+	require.NoError(t, dcs.Init())
+
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "cache", Title: "title", Units: "units", Dims: Dims{{ID: "hits"}, {ID: "misses"}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			return map[string]int64{"hits": 3, "misses": 1}
+		},
+	}
+	var buf bytes.Buffer
+	job := newTestJob()
+	job.module = m
+	job.derived = dcs
+	job.out = &buf
+
+	require.True(t, job.postCheck())
+	job.runOnce()
+
+	assert.Contains(t, buf.String(), "CHART 'module_job.hit_ratio'")
+	assert.Contains(t, buf.String(), "SET 'ratio' = 75")
+}
+
+func TestJob_processMetrics_Limits(t *testing.T) {
+	job := newTestJob()
+	job.limits = Limits{MaxCharts: 2, MaxDims: 3}
+	job.charts = &Charts{
+		&Chart{ID: "chart1", Dims: Dims{{ID: "dim1"}, {ID: "dim2"}}},
+		&Chart{ID: "chart2", Dims: Dims{{ID: "dim3"}, {ID: "dim4"}}},
+		&Chart{ID: "chart3", Dims: Dims{{ID: "dim5"}}},
+	}
+	mx := map[string]int64{"dim1": 1, "dim2": 2, "dim3": 3, "dim4": 4, "dim5": 5}
+
+	job.processMetrics(mx, time.Now(), 0)
+	out := job.buf.String()
+
+	assert.Contains(t, out, "CHART 'module_job.chart1'")
+	assert.Contains(t, out, "CHART 'module_job.chart2'")
+	assert.NotContains(t, out, "chart3")
+	assert.Contains(t, out, "SET 'dim3' = 3")
+	assert.NotContains(t, out, "dim4")
+	assert.Equal(t, 2, job.chartsCount)
+	assert.Equal(t, 3, job.dimsCount)
+	assert.Contains(t, out, "CHART 'netdata.refused_charts_of_module_job'")
+	assert.Contains(t, out, "SET 'charts' = 1")
+	assert.Contains(t, out, "SET 'dimensions' = 1")
+
+	// the removed chart releases its charts and dimensions
+	(*job.charts)[0].MarkRemove()
+	(*job.charts)[0].MarkNotCreated()
+	job.processMetrics(mx, time.Now(), 0)
+	assert.Equal(t, 1, job.chartsCount)
+	assert.Equal(t, 1, job.dimsCount)
+
+	require.NoError(t, job.charts.Add(&Chart{ID: "chart4", Title: "title", Units: "units", Dims: Dims{{ID: "dim6"}}}))
+	job.buf.Reset()
+	job.processMetrics(map[string]int64{"dim6": 6}, time.Now(), 0)
+	assert.Contains(t, job.buf.String(), "SET 'dim6' = 6")
+}
+
+func TestJob_processMetrics_Limits_AddDim(t *testing.T) {
+	job := newTestJob()
+	job.limits = Limits{MaxDims: 2}
+	job.charts = &Charts{&Chart{ID: "chart1", Dims: Dims{{ID: "dim1"}}}}
+
+	job.processMetrics(map[string]int64{"dim1": 1}, time.Now(), 0)
+
+	// the dimensions added to the created chart without re-creating it
+	chart := (*job.charts)[0]
+	require.NoError(t, chart.AddDim(&Dim{ID: "dim2"}))
+	require.NoError(t, chart.AddDim(&Dim{ID: "dim3"}))
+
+	job.buf.Reset()
+	job.processMetrics(map[string]int64{"dim1": 1, "dim2": 2, "dim3": 3}, time.Now(), 0)
+
+	assert.Contains(t, job.buf.String(), "SET 'dim2' = 2")
+	assert.NotContains(t, job.buf.String(), "SET 'dim3'")
+	assert.Equal(t, 2, job.dimsCount)
+	assert.Contains(t, job.buf.String(), "SET 'dimensions' = 1")
+}
+
+func TestJob_processMetrics_NoLimitsByDefault(t *testing.T) {
+	job := newTestJob()
+	job.charts = &Charts{}
+	mx := make(map[string]int64)
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("dim%d", i)
+		require.NoError(t, job.charts.Add(&Chart{ID: fmt.Sprintf("chart%d", i), Title: "title", Units: "units", Dims: Dims{{ID: id}}}))
+		mx[id] = int64(i)
+	}
+
+	job.processMetrics(mx, time.Now(), 0)
+
+	assert.Equal(t, 3, job.chartsCount)
+	assert.Equal(t, 3, job.dimsCount)
+	assert.Contains(t, job.buf.String(), "SET 'dim2' = 2")
+	assert.NotContains(t, job.buf.String(), "refused_charts_of_module_job")
+}
+
+func TestJob_processMetrics_ObsoleteStaleCharts(t *testing.T) {
+	job := newTestJob()
+	job.obsoleteAfter = 2
+	job.charts = &Charts{
+		&Chart{ID: "chart1", Dims: Dims{{ID: "dim1"}}},
+		&Chart{ID: "chart2", Dims: Dims{{ID: "dim2"}}},
+	}
+
+	job.processMetrics(map[string]int64{"dim1": 1, "dim2": 2}, time.Now(), 0)
+
+	for i := 0; i < 2; i++ {
+		job.buf.Reset()
+		job.processMetrics(map[string]int64{"dim1": 1}, time.Now(), 0)
+	}
+	assert.Contains(t, job.buf.String(), "CHART 'module_job.chart2' '' '' '' '' '' 'line' '1' '0' 'obsolete'")
+	assert.True(t, (*job.charts)[1].stale)
+
+	job.buf.Reset()
+	job.processMetrics(map[string]int64{"dim1": 1}, time.Now(), 0)
+	assert.NotContains(t, job.buf.String(), "chart2", "stale chart is not updated")
+
+	job.buf.Reset()
+	job.processMetrics(map[string]int64{"dim1": 1, "dim2": 3}, time.Now(), 0)
+	assert.Contains(t, job.buf.String(), "CHART 'module_job.chart2' '' '' '' '' '' 'line' '1' '0' ''")
+	assert.Contains(t, job.buf.String(), "SET 'dim2' = 3")
+	assert.False(t, (*job.charts)[1].stale)
+}
+
+func TestJob_processMetrics_Functions(t *testing.T) {
+	job := newTestJob()
+	job.module = &mockFunctionsModule{}
+	job.charts = &Charts{&Chart{ID: "chart1", Dims: Dims{{ID: "dim1"}}}}
+
+	job.processMetrics(map[string]int64{"dim1": 1}, time.Now(), 0)
+	out := job.buf.String()
+
+	chart := strings.Index(out, "CHART 'netdata.execution_time_of_module_job'")
+	fn := strings.Index(out, `FUNCTION "module_job:top" 10 "top queries"`)
+	require.True(t, chart >= 0 && fn > chart, "the functions are announced with the runtime chart")
+	assert.NotContains(t, out, "module_job:nohandler")
+	assert.Equal(t, 1, strings.Count(out, "FUNCTION "))
+
+	job.buf.Reset()
+	job.processMetrics(map[string]int64{"dim1": 1}, time.Now(), 0)
+	assert.NotContains(t, job.buf.String(), "FUNCTION ")
+}
+
+type mockFunctionsModule struct {
+	MockModule
+}
+
+func (m *mockFunctionsModule) Functions() map[string]Function {
+	return map[string]Function{
+		"top": {
+			Help:    "top queries",
+			Handler: func(context.Context, []string) (*FunctionTable, error) { return &FunctionTable{}, nil },
+		},
+		"nohandler": {Help: "no handler"},
+	}
+}
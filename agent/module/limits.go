@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"fmt"
+)
+
+// Limits defines the max number of charts and dimensions a job can create.
+// The charts and dimensions over the limits are refused. Zero means no limit.
+type Limits struct {
+	// MaxCharts is the max number of charts.
+	MaxCharts int `yaml:"max_charts"`
+	// MaxDims is the max number of dimensions in all charts.
+	MaxDims int `yaml:"max_dims"`
+}
+
+// Validate checks the limits are not negative.
+func (l Limits) Validate() error {
+	if l.MaxCharts < 0 {
+		return fmt.Errorf("limits: max_charts must be >= 0, got %d", l.MaxCharts)
+	}
+	if l.MaxDims < 0 {
+		return fmt.Errorf("limits: max_dims must be >= 0, got %d", l.MaxDims)
+	}
+	return nil
+}
+
+func newLimitsChart(pluginName string) *Chart {
+	return &Chart{
+		typ:      "netdata",
+		Title:    "Charts and dimensions refused because of the limits",
+		Units:    "charts",
+		Fam:      pluginName,
+		Ctx:      fmt.Sprintf("netdata.%s_plugin_refused_charts", pluginCtxName(pluginName)),
+		Priority: 145001,
+		Dims: Dims{
+			{ID: "refused_charts", Name: "charts"},
+			{ID: "refused_dims", Name: "dimensions"},
+		},
+	}
+}
+
+// applyLimits counts the chart and its new dimensions against the job limits, the ones over the limits are refused.
+// It is called for the created charts too, the module can add dimensions without re-creating the chart.
+func (j *Job) applyLimits(chart *Chart) {
+	if chart.ignore {
+		return
+	}
+
+	if !chart.counted {
+		if n := j.limits.MaxCharts; n > 0 && j.chartsCount >= n {
+			chart.ignore = true
+			j.refusedCharts++
+			j.warnLimit("charts", n)
+			return
+		}
+		chart.counted = true
+		j.chartsCount++
+	}
+
+	for _, dim := range chart.Dims {
+		if dim.counted || dim.drop || dim.remove {
+			continue
+		}
+		if n := j.limits.MaxDims; n > 0 && j.dimsCount >= n {
+			dim.drop = true
+			j.refusedDims++
+			j.warnLimit("dimensions", n)
+			continue
+		}
+		dim.counted = true
+		j.dimsCount++
+	}
+}
+
+func (j *Job) countLimits(chart *Chart, charts, dims *int) {
+	if !chart.counted {
+		return
+	}
+	*charts++
+	for _, dim := range chart.Dims {
+		if dim.counted && !dim.remove {
+			*dims++
+		}
+	}
+}
+
+func (j *Job) warnLimit(what string, limit int) {
+	if !j.limitWarned {
+		j.limitWarned = true
+		j.Warningf("the number of %s reached the limit (%d), the new ones are refused", what, limit)
+	}
+}
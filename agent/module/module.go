@@ -32,6 +32,9 @@ type Module interface {
 // Base is a helper struct. All modules should embed this struct.
 type Base struct {
 	*logger.Logger
+
+	stateStore StateStore
+	stateKey   string
 }
 
 func (b *Base) GetBase() *Base { return b }
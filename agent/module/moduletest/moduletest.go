@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package moduletest runs a module as a job and compares the produced plugins.d output with a golden file.
+//
+// The golden files are updated by running the tests with the -update-golden flag:
+//
+//	go test ./modules/nginx/ -run Golden -update-golden
+package moduletest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+var update = flag.Bool("update-golden", false, "update the golden files")
+
+// Case is a module golden output test case.
+type Case struct {
+	// Module is the module under test. The upstream stand-in (httptest server, socket server, mock) is set up by the caller.
+	Module module.Module
+	// Config is the job config (YAML), it is unmarshaled into the Module. Optional.
+	Config string
+	// ModuleName is the module name, it is a part of the charts type. Default is "module".
+	ModuleName string
+	// JobName is the job name. Default is "test".
+	JobName string
+	// Ticks is the number of data collections. Default is 1.
+	Ticks int
+	// Golden is the golden file path. Default is "testdata/golden/<test name>.txt".
+	Golden string
+	// AllowMissingDims disables the check that every dimension ID is in the collected metrics.
+	AllowMissingDims bool
+}
+
+// Run runs the module Init, Check and Collect (Ticks times) as a job and compares the produced
+// CHART/DIMENSION/BEGIN/SET lines with the golden file. The netdata internal (job health) charts are excluded.
+func Run(t *testing.T, c Case) {
+	t.Helper()
+
+	if c.Config != "" {
+		require.NoError(t, yaml.Unmarshal([]byte(c.Config), c.Module), "unmarshal job config")
+	}
+
+	moduleName := firstNotEmpty(c.ModuleName, "module")
+	jobName := firstNotEmpty(c.JobName, "test")
+
+	var buf bytes.Buffer
+	job := module.NewJob(module.JobConfig{
+		PluginName:  "go.d",
+		Name:        jobName,
+		ModuleName:  moduleName,
+		FullName:    moduleName + "_" + jobName,
+		Module:      c.Module,
+		Out:         &buf,
+		UpdateEvery: 1,
+	})
+	defer func() { buf.Reset(); job.Cleanup() }()
+
+	require.True(t, job.AutoDetection(), "job autodetection failed: %s", job.FailReason())
+
+	ticks := max(c.Ticks, 1)
+	for i := 0; i < ticks; i++ {
+		job.RunOnce()
+	}
+
+	out := Normalize(buf.String())
+
+	if !c.AllowMissingDims {
+		for _, v := range MissingDims(out) {
+			t.Errorf("dimension is not in the collected metrics: %s", v)
+		}
+	}
+
+	golden := c.Golden
+	if golden == "" {
+		golden = filepath.Join("testdata", "golden", strings.ReplaceAll(t.Name(), "/", "_")+".txt")
+	}
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+		require.NoError(t, os.WriteFile(golden, []byte(out), 0644))
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err, "read golden file (run the test with -update-golden to create it)")
+	assert.Equal(t, string(want), out, "the output differs from the golden file '%s' (run the test with -update-golden to update it)", golden)
+}
+
+var reBegin = regexp.MustCompile(`^(BEGIN '[^']*') \d+$`)
+
+// Normalize removes the non-deterministic parts of the plugins.d output: the HOST lines,
+// the netdata internal charts and the microseconds since the last run in BEGIN lines.
+func Normalize(out string) string {
+	var b strings.Builder
+	for _, block := range strings.Split(out, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" || strings.HasPrefix(block, "HOST ") ||
+			strings.HasPrefix(block, "CHART 'netdata.") || strings.HasPrefix(block, "BEGIN 'netdata.") {
+			continue
+		}
+		for _, line := range strings.Split(block, "\n") {
+			b.WriteString(reBegin.ReplaceAllString(line, "$1"))
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// MissingDims returns the dimensions ("<chart>: <dim>") that were set empty, i.e. are not in the collected metrics.
+func MissingDims(out string) []string {
+	var missing []string
+	var chart string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "BEGIN '"):
+			chart = strings.SplitN(line, "'", 3)[1]
+		case strings.HasPrefix(line, "SET '") && strings.HasSuffix(line, "' = "):
+			dim := strings.TrimSuffix(strings.TrimPrefix(line, "SET '"), "' = ")
+			if v := fmt.Sprintf("%s: %s", chart, dim); !seen[v] {
+				seen[v] = true
+				missing = append(missing, v)
+			}
+		}
+	}
+	return missing
+}
+
+func firstNotEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
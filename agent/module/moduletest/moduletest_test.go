@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package moduletest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testOutput = `HOST ''
+
+CHART 'netdata.execution_time_of_module_test' '' 'Execution time' 'ms' 'go.d' 'netdata.go_plugin_execution_time' 'line' '145000' '1' '' 'go.d' 'module'
+DIMENSION 'time' '' 'absolute' '1' '1' ''
+
+CHART 'module_test.chart' '' 'Title' 'units' 'fam' 'module.chart' 'line' '1' '1' '' 'go.d' 'module'
+DIMENSION 'dim1' '' 'absolute' '1' '1' ''
+DIMENSION 'dim2' '' 'absolute' '1' '1' ''
+
+BEGIN 'module_test.chart' 1000123
+SET 'dim1' = 1
+SET 'dim2' = 
+END
+
+BEGIN 'netdata.execution_time_of_module_test' 1000123
+SET 'time' = 3
+END
+
+`
+
+func TestNormalize(t *testing.T) {
+	want := `CHART 'module_test.chart' '' 'Title' 'units' 'fam' 'module.chart' 'line' '1' '1' '' 'go.d' 'module'
+DIMENSION 'dim1' '' 'absolute' '1' '1' ''
+DIMENSION 'dim2' '' 'absolute' '1' '1' ''
+
+BEGIN 'module_test.chart'
+SET 'dim1' = 1
+SET 'dim2' = 
+END
+
+`
+	assert.Equal(t, want, Normalize(testOutput))
+}
+
+func TestMissingDims(t *testing.T) {
+	assert.Equal(t, []string{"module_test.chart: dim2"}, MissingDims(Normalize(testOutput)))
+}
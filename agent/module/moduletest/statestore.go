@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package moduletest
+
+import "sync"
+
+// StateStore is an in-memory module.StateStore, it keeps the jobs state between the module instances.
+// The zero value is ready to use.
+type StateStore struct {
+	mux   sync.Mutex
+	items map[string][]byte
+}
+
+func (s *StateStore) LoadState(key string) ([]byte, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	data, ok := s.items[key]
+	return data, ok
+}
+
+func (s *StateStore) SaveState(key string, data []byte) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.items == nil {
+		s.items = make(map[string][]byte)
+	}
+	s.items[key] = data
+	return nil
+}
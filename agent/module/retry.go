@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	// BackoffLinear increases the penalty linearly with the number of consecutive failures.
+	BackoffLinear = "linear"
+	// BackoffExponential doubles the penalty every penaltyStep consecutive failures.
+	BackoffExponential = "exponential"
+)
+
+// RetryPolicy defines how a job backs off after failed data collections and failed autodetection.
+// The zero value is the default policy: linear backoff, no jitter, 600 seconds max penalty, never give up.
+type RetryPolicy struct {
+	// Backoff is the backoff type: "linear" (default) or "exponential".
+	Backoff string `yaml:"backoff"`
+	// Jitter is the randomization factor [0, 1] applied to the penalty and the autodetection retry interval.
+	Jitter float64 `yaml:"jitter"`
+	// MaxPenalty is the max penalty (and the max autodetection retry interval) in seconds.
+	MaxPenalty int `yaml:"max_penalty"`
+	// GiveUpAfter is the number of consecutive failures after which the job stops, 0 means never.
+	GiveUpAfter int `yaml:"give_up_after"`
+}
+
+// Validate returns an error if the policy is invalid.
+func (p RetryPolicy) Validate() error {
+	switch p.Backoff {
+	case "", BackoffLinear, BackoffExponential:
+	default:
+		return fmt.Errorf("retry policy: unknown backoff '%s' (expected '%s' or '%s')",
+			p.Backoff, BackoffLinear, BackoffExponential)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("retry policy: jitter must be in range [0, 1], got %v", p.Jitter)
+	}
+	if p.MaxPenalty < 0 {
+		return fmt.Errorf("retry policy: max_penalty must be >= 0, got %d", p.MaxPenalty)
+	}
+	if p.GiveUpAfter < 0 {
+		return fmt.Errorf("retry policy: give_up_after must be >= 0, got %d", p.GiveUpAfter)
+	}
+	return nil
+}
+
+// Penalty returns the data collection penalty in seconds after the given number of consecutive failures.
+func (p RetryPolicy) Penalty(failures, updateEvery int) int {
+	if failures < penaltyStep {
+		return 0
+	}
+
+	var v int
+	switch p.Backoff {
+	case BackoffExponential:
+		v = updateEvery << min(failures/penaltyStep, 16)
+	default:
+		v = failures / penaltyStep * penaltyStep * updateEvery / 2
+	}
+
+	return min(p.jitter(v), p.maxPenalty())
+}
+
+// RetryInterval returns the interval in seconds before the next autodetection attempt.
+// Attempt is the number of already failed autodetection retries.
+func (p RetryPolicy) RetryInterval(base, attempt int) int {
+	if base <= 0 {
+		return base
+	}
+
+	v := base
+	if p.Backoff == BackoffExponential {
+		v = base << min(attempt, 16)
+	}
+
+	return max(1, min(p.jitter(v), max(base, p.maxPenalty())))
+}
+
+func (p RetryPolicy) maxPenalty() int {
+	if p.MaxPenalty > 0 {
+		return p.MaxPenalty
+	}
+	return maxPenalty
+}
+
+func (p RetryPolicy) jitter(v int) int {
+	if p.Jitter <= 0 || v <= 0 {
+		return v
+	}
+	// [v - v*jitter, v + v*jitter]
+	delta := float64(v) * p.Jitter
+	return int(float64(v) - delta + rand.Float64()*2*delta)
+}
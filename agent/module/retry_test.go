@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	tests := map[string]struct {
+		policy  RetryPolicy
+		wantErr bool
+	}{
+		"default":            {policy: RetryPolicy{}},
+		"linear":             {policy: RetryPolicy{Backoff: BackoffLinear}},
+		"exponential":        {policy: RetryPolicy{Backoff: BackoffExponential, Jitter: 0.5, MaxPenalty: 60, GiveUpAfter: 10}},
+		"unknown backoff":    {policy: RetryPolicy{Backoff: "fibonacci"}, wantErr: true},
+		"negative jitter":    {policy: RetryPolicy{Jitter: -0.1}, wantErr: true},
+		"jitter > 1":         {policy: RetryPolicy{Jitter: 1.1}, wantErr: true},
+		"negative max":       {policy: RetryPolicy{MaxPenalty: -1}, wantErr: true},
+		"negative give up":   {policy: RetryPolicy{GiveUpAfter: -1}, wantErr: true},
+		"zero values are ok": {policy: RetryPolicy{Backoff: BackoffLinear, Jitter: 0, MaxPenalty: 0, GiveUpAfter: 0}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.wantErr {
+				assert.Error(t, test.policy.Validate())
+			} else {
+				assert.NoError(t, test.policy.Validate())
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Penalty(t *testing.T) {
+	tests := map[string]struct {
+		policy      RetryPolicy
+		updateEvery int
+		failures    []int
+		expected    []int
+	}{
+		"default (linear)": {
+			policy:      RetryPolicy{},
+			updateEvery: 1,
+			failures:    []int{0, 4, 5, 9, 10, 15, 10000},
+			expected:    []int{0, 0, 2, 2, 5, 7, maxPenalty},
+		},
+		"exponential": {
+			policy:      RetryPolicy{Backoff: BackoffExponential},
+			updateEvery: 1,
+			failures:    []int{0, 4, 5, 10, 15, 20, 10000},
+			expected:    []int{0, 0, 2, 4, 8, 16, maxPenalty},
+		},
+		"max penalty": {
+			policy:      RetryPolicy{Backoff: BackoffExponential, MaxPenalty: 10},
+			updateEvery: 5,
+			failures:    []int{4, 5, 10, 100},
+			expected:    []int{0, 10, 10, 10},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i, failures := range test.failures {
+				assert.Equalf(t, test.expected[i], test.policy.Penalty(failures, test.updateEvery), "failures %d", failures)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Penalty_Jitter(t *testing.T) {
+	policy := RetryPolicy{Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		v := policy.Penalty(40, 1) // 20 without jitter
+		assert.GreaterOrEqual(t, v, 10)
+		assert.LessOrEqual(t, v, 30)
+	}
+}
+
+func TestRetryPolicy_RetryInterval(t *testing.T) {
+	tests := map[string]struct {
+		policy   RetryPolicy
+		base     int
+		attempts []int
+		expected []int
+	}{
+		"default (linear)": {
+			policy:   RetryPolicy{},
+			base:     10,
+			attempts: []int{0, 1, 5, 100},
+			expected: []int{10, 10, 10, 10},
+		},
+		"exponential": {
+			policy:   RetryPolicy{Backoff: BackoffExponential},
+			base:     10,
+			attempts: []int{0, 1, 2, 3, 100},
+			expected: []int{10, 20, 40, 80, maxPenalty},
+		},
+		"exponential with max": {
+			policy:   RetryPolicy{Backoff: BackoffExponential, MaxPenalty: 30},
+			base:     10,
+			attempts: []int{0, 1, 2},
+			expected: []int{10, 20, 30},
+		},
+		"base is greater than max": {
+			policy:   RetryPolicy{MaxPenalty: 30},
+			base:     60,
+			attempts: []int{0, 1},
+			expected: []int{60, 60},
+		},
+		"no retry": {
+			policy:   RetryPolicy{Backoff: BackoffExponential},
+			base:     0,
+			attempts: []int{0, 1},
+			expected: []int{0, 0},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i, attempt := range test.attempts {
+				assert.Equalf(t, test.expected[i], test.policy.RetryInterval(test.base, attempt), "attempt %d", attempt)
+			}
+		})
+	}
+}
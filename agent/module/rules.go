@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/netdata/go.d.plugin/pkg/matcher"
+)
+
+// ChartRule is a job level rule that changes the charts of any module before they are sent to netdata.
+// The Chart, Context and Dimension patterns use the pkg/matcher syntax ("* glob", "~ regexp", "= string", "glob:...", ...),
+// an empty pattern matches everything.
+type ChartRule struct {
+	// Chart is the chart ID pattern.
+	Chart string `yaml:"chart"`
+	// Context is the chart context pattern.
+	Context string `yaml:"context"`
+	// Dimension is the dimension ID pattern. If set, Drop, Hide and Rename are applied to the matching dimensions.
+	Dimension string `yaml:"dimension"`
+
+	// Drop drops the matching dimensions or, if Dimension is not set, the matching charts.
+	Drop bool `yaml:"drop"`
+	// Hide sets the matching dimensions hidden.
+	Hide bool `yaml:"hide"`
+	// Rename sets the matching dimensions name (the name shown in the dashboard, the dimension ID doesn't change).
+	Rename string `yaml:"rename"`
+	// Labels are added to the matching charts.
+	Labels map[string]string `yaml:"labels"`
+
+	chart     matcher.Matcher
+	context   matcher.Matcher
+	dimension matcher.Matcher
+}
+
+// ChartRules is a list of chart rules, they are applied in order.
+type ChartRules []*ChartRule
+
+// Init validates the rules and compiles their patterns.
+func (rs ChartRules) Init() error {
+	for i, r := range rs {
+		if err := r.init(); err != nil {
+			return fmt.Errorf("chart rule #%d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *ChartRule) init() error {
+	if !r.Drop && !r.Hide && r.Rename == "" && len(r.Labels) == 0 {
+		return errors.New("no action defined (expected 'drop', 'hide', 'rename' or 'labels')")
+	}
+	if r.Dimension == "" && (r.Hide || r.Rename != "") {
+		return errors.New("'hide' and 'rename' require 'dimension'")
+	}
+	if r.Dimension != "" && len(r.Labels) > 0 {
+		return errors.New("'labels' can not be used with 'dimension'")
+	}
+
+	var err error
+	if r.chart, err = parseRulePattern(r.Chart); err != nil {
+		return fmt.Errorf("invalid chart pattern '%s': %v", r.Chart, err)
+	}
+	if r.context, err = parseRulePattern(r.Context); err != nil {
+		return fmt.Errorf("invalid context pattern '%s': %v", r.Context, err)
+	}
+	if r.dimension, err = parseRulePattern(r.Dimension); err != nil {
+		return fmt.Errorf("invalid dimension pattern '%s': %v", r.Dimension, err)
+	}
+	return nil
+}
+
+func (r *ChartRule) matchChart(chart *Chart) bool {
+	return r.chart.MatchString(chart.ID) && r.context.MatchString(chart.Ctx)
+}
+
+// apply applies the rules to the chart. It is called every time the chart is (re)created,
+// so it is safe to call it more than once.
+func (rs ChartRules) apply(chart *Chart) {
+	for _, r := range rs {
+		if r.chart == nil || !r.matchChart(chart) {
+			continue
+		}
+		if r.Dimension == "" {
+			if r.Drop {
+				chart.ignore = true
+			}
+			continue
+		}
+		for _, dim := range chart.Dims {
+			if !r.dimension.MatchString(dim.ID) {
+				continue
+			}
+			if r.Drop {
+				dim.drop = true
+			}
+			if r.Hide {
+				dim.Hidden = true
+			}
+			if r.Rename != "" {
+				dim.rename = r.Rename
+			}
+		}
+	}
+}
+
+// labels returns the rules labels for the chart, the later rules override the earlier ones.
+func (rs ChartRules) labels(chart *Chart) map[string]string {
+	var labels map[string]string
+	for _, r := range rs {
+		if len(r.Labels) == 0 || r.chart == nil || !r.matchChart(chart) {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		for k, v := range r.Labels {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+func parseRulePattern(pattern string) (matcher.Matcher, error) {
+	if pattern == "" {
+		return matcher.TRUE(), nil
+	}
+	return matcher.Parse(pattern)
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChartRules_Init(t *testing.T) {
+	tests := map[string]struct {
+		rules   ChartRules
+		wantErr bool
+	}{
+		"no rules": {},
+		"valid rules": {
+			rules: ChartRules{
+				{Chart: "* requests*", Drop: true},
+				{Context: "~ ^nginx\\.", Dimension: "= reading", Rename: "read", Hide: true},
+				{Chart: "glob:conn*", Labels: map[string]string{"tier": "web"}},
+			},
+		},
+		"no action": {
+			rules:   ChartRules{{Chart: "* requests*"}},
+			wantErr: true,
+		},
+		"rename without dimension": {
+			rules:   ChartRules{{Chart: "* requests*", Rename: "req"}},
+			wantErr: true,
+		},
+		"labels with dimension": {
+			rules:   ChartRules{{Dimension: "* req*", Labels: map[string]string{"tier": "web"}}},
+			wantErr: true,
+		},
+		"invalid pattern": {
+			rules:   ChartRules{{Chart: "~ (", Drop: true}},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.rules.Init()
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChartRules_apply(t *testing.T) {
+	rules := ChartRules{
+		{Chart: "* dropped*", Drop: true},
+		{Context: "= module.requests", Dimension: "= failed", Drop: true},
+		{Dimension: "* success", Rename: "ok", Hide: true},
+	}
+	assert.NoError(t, rules.Init())
+
+	dropped := &Chart{ID: "dropped_chart", Ctx: "module.dropped", Dims: Dims{{ID: "success"}}}
+	requests := &Chart{ID: "requests", Ctx: "module.requests", Dims: Dims{{ID: "success"}, {ID: "failed"}}}
+
+	for i := 0; i < 2; i++ {
+		rules.apply(dropped)
+		rules.apply(requests)
+	}
+
+	assert.True(t, dropped.ignore)
+	assert.False(t, requests.ignore)
+	assert.Equal(t, &Dim{ID: "success", DimOpts: DimOpts{Hidden: true}, rename: "ok"}, requests.Dims[0])
+	assert.Equal(t, &Dim{ID: "failed", drop: true}, requests.Dims[1])
+}
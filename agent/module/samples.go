@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+// samples aggregates the results of the sub-second data collections between the update_every runs.
+// The absolute values are averaged, the incremental ones (counters) are taken from the last sample.
+type samples struct {
+	sum    map[string]int64
+	count  map[string]int64
+	last   map[string]int64
+	n      int // the successful data collections
+	failed int // the failed data collections
+}
+
+func (s *samples) add(metrics map[string]int64) {
+	if s.sum == nil {
+		s.sum = make(map[string]int64)
+		s.count = make(map[string]int64)
+		s.last = make(map[string]int64)
+	}
+	for k, v := range metrics {
+		s.sum[k] += v
+		s.count[k]++
+		s.last[k] = v
+	}
+	s.n++
+}
+
+// empty returns true if there were no data collections since the last flush.
+func (s *samples) empty() bool {
+	return s.n == 0 && s.failed == 0
+}
+
+// flush returns the aggregated metrics (nil if all the data collections failed) and resets the samples.
+func (s *samples) flush(charts *Charts) map[string]int64 {
+	defer func() { *s = samples{} }()
+
+	if s.n == 0 {
+		return nil
+	}
+
+	incremental := make(map[string]bool)
+	if charts != nil {
+		for _, chart := range *charts {
+			for _, dim := range chart.Dims {
+				if dim.Algo == Incremental || dim.Algo == PercentOfIncremental {
+					incremental[dim.ID] = true
+				}
+			}
+		}
+	}
+
+	metrics := make(map[string]int64, len(s.sum))
+	for k, sum := range s.sum {
+		if incremental[k] {
+			metrics[k] = s.last[k]
+		} else {
+			metrics[k] = sum / s.count[k]
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamples_flush(t *testing.T) {
+	charts := &Charts{
+		&Chart{ID: "chart", Dims: Dims{
+			{ID: "latency"},
+			{ID: "requests", Algo: Incremental},
+		}},
+	}
+
+	tests := map[string]struct {
+		add      []map[string]int64
+		failed   int
+		expected map[string]int64
+	}{
+		"absolute averaged, incremental last": {
+			add: []map[string]int64{
+				{"latency": 10, "requests": 100},
+				{"latency": 20, "requests": 105},
+				{"latency": 60, "requests": 107},
+			},
+			expected: map[string]int64{"latency": 30, "requests": 107},
+		},
+		"averaged over the samples having the metric": {
+			add: []map[string]int64{
+				{"latency": 10},
+				{"latency": 20, "requests": 1},
+			},
+			expected: map[string]int64{"latency": 15, "requests": 1},
+		},
+		"some failed": {
+			add:      []map[string]int64{{"latency": 10}},
+			failed:   2,
+			expected: map[string]int64{"latency": 10},
+		},
+		"all failed": {
+			failed:   2,
+			expected: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s samples
+			for _, mx := range test.add {
+				s.add(mx)
+			}
+			s.failed = test.failed
+
+			assert.False(t, s.empty())
+			assert.Equal(t, test.expected, s.flush(charts))
+			assert.True(t, s.empty(), "not reset after flush")
+		})
+	}
+}
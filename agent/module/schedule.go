@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinScheduleInterval is the min allowed fixed schedule interval.
+const MinScheduleInterval = time.Millisecond * 10
+
+// Schedule defines when a job collects data if it is not driven by update_every.
+// It is either a fixed interval or a cron expression.
+//
+// A sub-second interval runs on its own ticker, the results are aggregated and sent every update_every seconds
+// (see samples). Otherwise, the job checks the schedule every update_every seconds and re-sends the last collected
+// values between the runs, so the interval is rounded up to a multiple of update_every.
+type Schedule struct {
+	// Interval is the fixed data collection interval. Zero for cron schedules.
+	Interval time.Duration
+
+	expr string
+	cron *cronSpec
+}
+
+// ParseSchedule parses a schedule. It accepts a Go duration ("250ms", "1m30s"),
+// a standard 5 fields cron expression ("5 * * * *") or a cron descriptor ("@hourly", "@daily", "@weekly", "@monthly", "@yearly").
+func ParseSchedule(s string) (*Schedule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("empty schedule")
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < MinScheduleInterval {
+			return nil, fmt.Errorf("schedule interval '%s' is less than min allowed (%s)", s, MinScheduleInterval)
+		}
+		return &Schedule{Interval: d, expr: s}, nil
+	}
+
+	spec, err := parseCron(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule '%s': %v", s, err)
+	}
+	// e.g. "0 0 31 2 *"
+	if spec.next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("invalid schedule '%s': the expression never matches", s)
+	}
+
+	return &Schedule{cron: spec, expr: s}, nil
+}
+
+// IsSubSecond returns true if the schedule is a fixed interval shorter than a second.
+func (s *Schedule) IsSubSecond() bool {
+	return s.cron == nil && s.Interval < time.Second
+}
+
+// IsCron returns true if the schedule is a cron expression.
+func (s *Schedule) IsCron() bool {
+	return s.cron != nil
+}
+
+// Next returns the next activation time after t. It returns the zero time if there is none.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.cron == nil {
+		return t.Add(s.Interval)
+	}
+	return s.cron.next(t)
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	if v, ok := cronDescriptors[expr]; ok {
+		expr = v
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	var spec cronSpec
+	var err error
+
+	if spec.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if spec.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if spec.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if spec.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	if spec.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	// both 0 and 7 are Sunday
+	if spec.dow&(1<<7) != 0 {
+		spec.dow = spec.dow&^(1<<7) | 1
+	}
+	spec.domStar = fields[2] == "*" || fields[2] == "?"
+	spec.dowStar = fields[4] == "*" || fields[4] == "?"
+
+	return &spec, nil
+}
+
+// parseCronField parses a comma separated list of '*', 'N', 'N-M' with optional '/step'.
+func parseCronField(field string, minVal, maxVal int) (uint64, error) {
+	var bitset uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i != -1 {
+			v, err := strconv.Atoi(part[i+1:])
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid step in '%s'", part)
+			}
+			rng, step = part[:i], v
+		}
+
+		lo, hi := minVal, maxVal
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			parts := strings.SplitN(rng, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(parts[0])
+			hi, err2 = strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range '%s'", rng)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", rng)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = maxVal
+			}
+		}
+
+		if lo < minVal || hi > maxVal || lo > hi {
+			return 0, fmt.Errorf("'%s' is out of range [%d, %d]", part, minVal, maxVal)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bitset |= 1 << v
+		}
+	}
+
+	if bitset == 0 {
+		return 0, fmt.Errorf("empty field '%s'", field)
+	}
+
+	return bitset, nil
+}
+
+func (c *cronSpec) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// a valid expression always matches within 5 years (leap years)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+
+	// if both fields are restricted, the day matches if either field matches (standard cron behaviour)
+	if !c.domStar && !c.dowStar {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
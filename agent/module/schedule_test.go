@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := map[string]struct {
+		input        string
+		wantErr      bool
+		wantCron     bool
+		wantInterval time.Duration
+	}{
+		"interval":             {input: "1m30s", wantInterval: time.Second * 90},
+		"sub-second interval":  {input: "250ms", wantInterval: time.Millisecond * 250},
+		"interval below min":   {input: "5ms", wantErr: true},
+		"cron never matches":   {input: "0 0 31 2 *", wantErr: true},
+		"cron":                 {input: "5 * * * *", wantCron: true},
+		"cron with day names":  {input: "0,30 8-18/2 * 1-6 MON", wantErr: true},
+		"cron ranges steps":    {input: "*/15 8-18/2 1,15 * 1-5", wantCron: true},
+		"cron descriptor":      {input: "@hourly", wantCron: true},
+		"cron too many fields": {input: "0 5 * * * *", wantErr: true},
+		"cron out of range":    {input: "60 * * * *", wantErr: true},
+		"cron invalid step":    {input: "*/0 * * * *", wantErr: true},
+		"cron invalid range":   {input: "10-5 * * * *", wantErr: true},
+		"empty":                {input: " ", wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseSchedule(test.input)
+
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.wantCron, s.IsCron())
+			assert.Equal(t, test.wantInterval, s.Interval)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC) // Wednesday
+
+	tests := map[string]struct {
+		expr     string
+		from     time.Time
+		expected []time.Time
+	}{
+		"interval": {
+			expr: "30s",
+			from: base,
+			expected: []time.Time{
+				base.Add(time.Second * 30),
+				base.Add(time.Minute),
+			},
+		},
+		"every hour at :05": {
+			expr: "5 * * * *",
+			from: base,
+			expected: []time.Time{
+				time.Date(2024, time.January, 31, 11, 5, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 12, 5, 0, 0, time.UTC),
+			},
+		},
+		"every 15 minutes": {
+			expr: "*/15 * * * *",
+			from: base,
+			expected: []time.Time{
+				time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC),
+			},
+		},
+		"daily crosses month": {
+			expr: "@daily",
+			from: base,
+			expected: []time.Time{
+				time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"leap day": {
+			expr: "0 0 29 2 *",
+			from: base,
+			expected: []time.Time{
+				time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"sunday as 7": {
+			expr: "30 6 * * 7",
+			from: base,
+			expected: []time.Time{
+				time.Date(2024, time.February, 4, 6, 30, 0, 0, time.UTC),
+				time.Date(2024, time.February, 11, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		"day of month or day of week": {
+			expr: "0 12 1 * 5",
+			from: base,
+			expected: []time.Time{
+				time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 2, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 9, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseSchedule(test.expr)
+			require.NoError(t, err)
+
+			next := test.from
+			for _, want := range test.expected {
+				next = s.Next(next)
+				assert.Equal(t, want, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+type (
+	// ChartSnapshot is a chart with the last collected values.
+	ChartSnapshot struct {
+		ID      string              `json:"id"`
+		Title   string              `json:"title"`
+		Units   string              `json:"units"`
+		Family  string              `json:"family"`
+		Context string              `json:"context"`
+		Labels  map[string]string   `json:"labels,omitempty"`
+		Dims    []DimensionSnapshot `json:"dimensions"`
+	}
+	// DimensionSnapshot is a dimension with the last collected value (multiplied and divided), nil if not collected.
+	DimensionSnapshot struct {
+		ID        string   `json:"id"`
+		Name      string   `json:"name"`
+		Algorithm string   `json:"algorithm"`
+		Value     *float64 `json:"value"`
+	}
+)
+
+// Snapshot returns the job charts sent to Netdata (in the order they were added) with the values
+// of the last successful data collection. The job internal charts (execution time, health) are not included.
+func (j *Job) Snapshot() []ChartSnapshot {
+	snapshot := make([]ChartSnapshot, 0, len(*j.charts))
+
+	for _, chart := range *j.charts {
+		if !chart.created || chart.ignore || chart.remove || chart.Obsolete {
+			continue
+		}
+
+		typeID := getChartType(chart, j) + "." + getChartID(chart)
+		cs := ChartSnapshot{
+			ID:      typeID,
+			Title:   chart.Title,
+			Units:   chart.Units,
+			Family:  chart.Fam,
+			Context: firstNotEmpty(chart.Ctx, typeID),
+			Labels:  make(map[string]string),
+			Dims:    make([]DimensionSnapshot, 0, len(chart.Dims)),
+		}
+		for _, l := range j.chartLabels(chart) {
+			cs.Labels[l.Key] = l.Value
+		}
+
+		for _, dim := range chart.Dims {
+			if dim.drop || dim.remove {
+				continue
+			}
+			id := firstNotEmpty(dim.Name, dim.ID)
+			ds := DimensionSnapshot{
+				ID:        id,
+				Name:      firstNotEmpty(dim.rename, id),
+				Algorithm: dim.Algo.String(),
+			}
+			if v, ok := j.lastMetrics[dim.ID]; ok {
+				value := float64(v) * float64(handleZero(dim.Mul)) / float64(handleZero(dim.Div))
+				ds.Value = &value
+			}
+			cs.Dims = append(cs.Dims, ds)
+		}
+
+		snapshot = append(snapshot, cs)
+	}
+
+	return snapshot
+}
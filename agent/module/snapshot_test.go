@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_Snapshot(t *testing.T) {
+	rules := ChartRules{
+		{Chart: "* dropped", Drop: true},
+		{Chart: "= requests", Dimension: "= failed", Drop: true},
+		{Chart: "= requests", Dimension: "= success", Rename: "ok"},
+	}
+	require.NoError(t, rules.Init())
+
+	m := &MockModule{
+		ChartsFunc: func() *Charts {
+			return &Charts{
+				&Chart{ID: "dropped", Title: "Dropped", Units: "units", Dims: Dims{{ID: "dropped_dim"}}},
+				&Chart{
+					ID: "requests", Title: "Requests", Units: "requests/s", Fam: "requests", Ctx: "module.requests",
+					Labels: []Label{{Key: "instance", Value: "localhost"}},
+					Dims: Dims{
+						{ID: "success", Algo: Incremental},
+						{ID: "failed", Algo: Incremental},
+						{ID: "redirect", Name: "redirects", Algo: Incremental},
+					},
+				},
+				&Chart{ID: "latency", Title: "Latency", Units: "ms", Dims: Dims{{ID: "latency", Div: 1000}}},
+			}
+		},
+		CollectFunc: func() map[string]int64 {
+			return map[string]int64{"dropped_dim": 1, "success": 10, "failed": 2, "latency": 1500}
+		},
+	}
+	job := newTestJob()
+	job.module = m
+	job.charts = m.Charts()
+	job.chartRules = rules
+
+	job.RunOnce()
+
+	v10, v1500 := 10.0, 1.5
+	expected := []ChartSnapshot{
+		{
+			ID:      "module_job.requests",
+			Title:   "Requests",
+			Units:   "requests/s",
+			Family:  "requests",
+			Context: "module.requests",
+			Labels:  map[string]string{"instance": "localhost", "_collect_job": jobName},
+			Dims: []DimensionSnapshot{
+				{ID: "success", Name: "ok", Algorithm: "incremental", Value: &v10},
+				{ID: "redirects", Name: "redirects", Algorithm: "incremental"},
+			},
+		},
+		{
+			ID:      "module_job.latency",
+			Title:   "Latency",
+			Units:   "ms",
+			Context: "module_job.latency",
+			Labels:  map[string]string{"_collect_job": jobName},
+			Dims: []DimensionSnapshot{
+				{ID: "latency", Name: "latency", Algorithm: "absolute", Value: &v1500},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, job.Snapshot())
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// StateStore persists small per-job state blobs between the plugin restarts and reloads.
+type StateStore interface {
+	LoadState(key string) ([]byte, bool)
+	SaveState(key string, data []byte) error
+}
+
+// SetStateStore sets the job state store, the key is the job full name. It is called by the jobs manager.
+func (b *Base) SetStateStore(store StateStore, key string) {
+	b.stateStore = store
+	b.stateKey = key
+}
+
+// LoadState restores the previously saved job state into v.
+// It returns false if there is no saved state or it can't be decoded.
+func (b *Base) LoadState(v any) bool {
+	if b.stateStore == nil {
+		return false
+	}
+	data, ok := b.stateStore.LoadState(b.stateKey)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// SaveState saves the job state, v must be JSON serializable.
+// The state is saved in memory and flushed to disk periodically.
+func (b *Base) SaveState(v any) error {
+	if b.stateStore == nil {
+		return nil
+	}
+	if b.stateKey == "" {
+		return errors.New("state key is not set")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.stateStore.SaveState(b.stateKey, data)
+}
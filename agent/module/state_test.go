@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase_State(t *testing.T) {
+	type state struct {
+		Offset int64 `json:"offset"`
+	}
+
+	var b Base
+	var st state
+	assert.False(t, b.LoadState(&st))
+	assert.NoError(t, b.SaveState(state{Offset: 1}))
+
+	store := &mockStateStore{items: make(map[string][]byte)}
+	b.SetStateStore(store, "module_job")
+
+	assert.False(t, b.LoadState(&st))
+	require.NoError(t, b.SaveState(state{Offset: 10}))
+	assert.Equal(t, `{"offset":10}`, string(store.items["module_job"]))
+
+	require.True(t, b.LoadState(&st))
+	assert.Equal(t, int64(10), st.Offset)
+
+	store.items["module_job"] = []byte("not json")
+	assert.False(t, b.LoadState(&st))
+}
+
+type mockStateStore struct {
+	items map[string][]byte
+}
+
+func (m *mockStateStore) LoadState(key string) ([]byte, bool) {
+	v, ok := m.items[key]
+	return v, ok
+}
+
+func (m *mockStateStore) SaveState(key string, data []byte) error {
+	m.items[key] = data
+	return nil
+}
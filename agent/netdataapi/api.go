@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
 type (
@@ -169,6 +170,12 @@ func (a *API) HOST(guid string) error {
 	return err
 }
 
+// FUNCTION registers a function of the current chart, Netdata removes it along with the chart.
+func (a *API) FUNCTION(name string, timeout int, help string) error {
+	_, err := fmt.Fprintf(a, "FUNCTION \"%s\" %d \"%s\"\n", name, timeout, strings.ReplaceAll(help, "\"", "'"))
+	return err
+}
+
 func (a *API) DynCfgEnable(pluginName string) error {
 	_, err := a.Write([]byte("DYNCFG_ENABLE '" + pluginName + "'\n\n"))
 	return err
@@ -190,6 +197,8 @@ func (a *API) DynCfgRegisterJob(moduleName, jobName, jobType string) error {
 }
 
 func (a *API) DynCfgReportJobStatus(moduleName, jobName, status, reason string) error {
+	// the reason is a single quoted parameter, it can't contain quotes and line breaks
+	reason = strings.NewReplacer("'", "\"", "\n", " ").Replace(reason)
 	_, err := fmt.Fprintf(a, "REPORT_JOB_STATUS '%s' '%s' '%s' 0 '%s'\n\n", moduleName, jobName, status, reason)
 	return err
 }
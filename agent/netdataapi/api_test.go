@@ -312,6 +312,19 @@ func TestAPI_DynCfgRegisterJob(t *testing.T) {
 	)
 }
 
+func TestAPI_FUNCTION(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := API{Writer: buf}
+
+	_ = a.FUNCTION("job:function", 10, "shows \"something\"")
+
+	assert.Equal(
+		t,
+		"FUNCTION \"job:function\" 10 \"shows 'something'\"\n",
+		buf.String(),
+	)
+}
+
 func TestAPI_DynCfgReportJobStatus(t *testing.T) {
 	buf := &bytes.Buffer{}
 	a := API{Writer: buf}
@@ -323,6 +336,15 @@ func TestAPI_DynCfgReportJobStatus(t *testing.T) {
 		"REPORT_JOB_STATUS 'module' 'job' 'status' 0 'reason'\n\n",
 		buf.String(),
 	)
+
+	buf.Reset()
+	_ = a.DynCfgReportJobStatus("module", "job", "status", "check failed: can't connect\nto 'host'")
+
+	assert.Equal(
+		t,
+		"REPORT_JOB_STATUS 'module' 'job' 'status' 0 'check failed: can\"t connect to \"host\"'\n\n",
+		buf.String(),
+	)
 }
 
 func TestAPI_FunctionResultSuccess(t *testing.T) {
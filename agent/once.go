@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/jobmgr"
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+// RunOnce finds the RunModule job config (by name, the name can be omitted if the module has only one job),
+// creates the job the same way the jobs manager does, runs its Init, Check and Collect once
+// and writes the charts with the collected values to w in the given format ("json" or "table").
+func (a *Agent) RunOnce(w io.Writer, jobName, format string) bool {
+	if err := a.runOnce(w, jobName, format); err != nil {
+		a.Error(err)
+		return false
+	}
+	return true
+}
+
+func (a *Agent) runOnce(w io.Writer, jobName, format string) error {
+	if format != formatJSON && format != formatTable {
+		return fmt.Errorf("unknown format '%s' (expected '%s' or '%s')", format, formatJSON, formatTable)
+	}
+	if a.RunModule == "" || a.RunModule == "all" {
+		return errors.New("'--once' requires a module ('-m <module>')")
+	}
+	if _, ok := a.ModuleRegistry[a.RunModule]; !ok {
+		return fmt.Errorf("unknown module '%s'", a.RunModule)
+	}
+
+	cfg := a.loadPluginConfig()
+	enabled := a.loadEnabledModules(cfg)
+	discCfg := a.buildDiscoveryConf(enabled)
+
+	jobCfg, err := a.findJobConfig(discCfg.Registry, discCfg.File, discCfg.Dummy.Names, jobName)
+	if err != nil {
+		return err
+	}
+
+	mgr := jobmgr.NewManager()
+	mgr.PluginName = a.Name
+	mgr.Modules = enabled
+	mgr.ConfDir = a.ModulesConfDir
+	mgr.Out = io.Discard
+	if reg := a.setupVnodeRegistry(); reg != nil && reg.Len() > 0 {
+		mgr.Vnodes = reg
+	}
+
+	job, err := mgr.CreateJob(jobCfg)
+	if err != nil {
+		return fmt.Errorf("%s[%s]: create job: %v", jobCfg.Module(), jobCfg.Name(), err)
+	}
+	defer job.Cleanup()
+
+	if !job.AutoDetection() {
+		return fmt.Errorf("%s[%s]: check failed: %s", jobCfg.Module(), jobCfg.Name(), job.FailReason())
+	}
+
+	job.RunOnce()
+	charts := job.Snapshot()
+
+	if format == formatJSON {
+		return writeOnceJSON(w, jobCfg.FullName(), charts)
+	}
+	return writeOnceTable(w, charts)
+}
+
+func (a *Agent) findJobConfig(reg confgroup.Registry, files file.Config, dummy []string, jobName string) (confgroup.Config, error) {
+	var cfgs []confgroup.Config
+
+	paths := configFilePaths(files, func(pattern string, err error) {
+		a.Warningf("invalid path pattern '%s': %v", pattern, err)
+	})
+	for _, path := range paths {
+		group, err := file.Parse(reg, path)
+		if err != nil {
+			a.Warningf("parse '%s': %v", path, err)
+			continue
+		}
+		if group == nil {
+			continue
+		}
+		for _, cfg := range group.Configs {
+			if cfg.Module() == a.RunModule {
+				cfg.SetSource(group.Source)
+				cfg.SetProvider("file reader")
+				cfgs = append(cfgs, cfg)
+			}
+		}
+	}
+
+	// no config file, the module default config (see the dummy discovery)
+	if len(cfgs) == 0 {
+		for _, name := range dummy {
+			if def, ok := reg.Lookup(name); ok && name == a.RunModule {
+				cfg := confgroup.Config{}
+				cfg.SetModule(name)
+				cfg.SetSource(name)
+				cfg.SetProvider("dummy")
+				cfg.Apply(def)
+				cfgs = append(cfgs, cfg)
+			}
+		}
+	}
+
+	var names []string
+	for _, cfg := range cfgs {
+		if jobName == "" && len(cfgs) == 1 || cfg.Name() == jobName {
+			return cfg, nil
+		}
+		names = append(names, cfg.Name())
+	}
+
+	switch {
+	case len(cfgs) == 0:
+		return nil, fmt.Errorf("no '%s' job configs found", a.RunModule)
+	case jobName == "":
+		return nil, fmt.Errorf("'%s' has %d jobs, use '--job' to choose one: %s", a.RunModule, len(cfgs), strings.Join(names, ", "))
+	default:
+		return nil, fmt.Errorf("'%s' job '%s' not found, available jobs: %s", a.RunModule, jobName, strings.Join(names, ", "))
+	}
+}
+
+func writeOnceJSON(w io.Writer, job string, charts []module.ChartSnapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Job    string                 `json:"job"`
+		Charts []module.ChartSnapshot `json:"charts"`
+	}{
+		Job:    job,
+		Charts: charts,
+	})
+}
+
+func writeOnceTable(w io.Writer, charts []module.ChartSnapshot) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHART\tDIMENSION\tVALUE\tUNITS")
+	for _, chart := range charts {
+		for _, dim := range chart.Dims {
+			value := "-"
+			if dim.Value != nil {
+				value = strconv.FormatFloat(*dim.Value, 'f', -1, 64)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", chart.ID, dim.Name, value, chart.Units)
+		}
+	}
+	return tw.Flush()
+}
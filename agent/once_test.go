@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"bytes"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/pkg/multipath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgent_RunOnce(t *testing.T) {
+	tests := map[string]struct {
+		confDir    string
+		module     string
+		job        string
+		format     string
+		wantOK     bool
+		wantOutput string
+	}{
+		"table": {
+			confDir: "testdata/validate/valid",
+			module:  "module1",
+			job:     "job2",
+			format:  "table",
+			wantOK:  true,
+			wantOutput: `CHART            DIMENSION  VALUE  UNITS
+module1_job2.id  id1        1      units
+`,
+		},
+		"json": {
+			confDir: "testdata/validate/valid",
+			module:  "module1",
+			job:     "job1",
+			format:  "json",
+			wantOK:  true,
+			wantOutput: `{
+  "job": "module1_job1",
+  "charts": [
+    {
+      "id": "module1_job1.id",
+      "title": "title",
+      "units": "units",
+      "family": "",
+      "context": "module1_job1.id",
+      "labels": {
+        "_collect_job": "job1"
+      },
+      "dimensions": [
+        {
+          "id": "id1",
+          "name": "id1",
+          "algorithm": "absolute",
+          "value": 1
+        }
+      ]
+    }
+  ]
+}
+`,
+		},
+		"default config": {
+			module: "module1",
+			format: "table",
+			wantOK: true,
+			wantOutput: `CHART       DIMENSION  VALUE  UNITS
+module1.id  id1        1      units
+`,
+		},
+		"several jobs, no job name": {
+			confDir: "testdata/validate/valid",
+			module:  "module1",
+			format:  "table",
+		},
+		"unknown job": {
+			confDir: "testdata/validate/valid",
+			module:  "module1",
+			job:     "job3",
+			format:  "table",
+		},
+		"all modules": {
+			confDir: "testdata/validate/valid",
+			module:  "all",
+			format:  "table",
+		},
+		"unknown format": {
+			confDir: "testdata/validate/valid",
+			module:  "module1",
+			job:     "job1",
+			format:  "xml",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{Name: "go.d", RunModule: test.module}
+			if test.confDir != "" {
+				cfg.ConfDir = multipath.New(test.confDir)
+				cfg.ModulesConfDir = multipath.New(filepath.Join(test.confDir, "go.d"))
+			}
+			a := New(cfg)
+			var mux sync.Mutex
+			a.ModuleRegistry = prepareRegistry(&mux, map[string]int{}, "module1")
+
+			var buf bytes.Buffer
+			ok := a.RunOnce(&buf, test.job, test.format)
+
+			assert.Equal(t, test.wantOK, ok)
+			if test.wantOK {
+				assert.Equal(t, test.wantOutput, buf.String())
+			}
+		})
+	}
+}
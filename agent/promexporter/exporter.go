@@ -0,0 +1,309 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promexporter
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/netdata/go.d.plugin/logger"
+)
+
+// New creates a new Exporter.
+func New() *Exporter {
+	return &Exporter{
+		Logger: logger.New().With(
+			slog.String("component", "prometheus exporter"),
+		),
+		Prefix: "netdata",
+		hosts:  make(map[string]string),
+		charts: make(map[string]*chart),
+	}
+}
+
+// Exporter is an io.Writer that consumes the plugins.d text protocol
+// and keeps the last collected values to expose them in Prometheus/OpenMetrics exposition format.
+type Exporter struct {
+	*logger.Logger
+
+	Prefix string
+
+	mux     sync.Mutex
+	pending []byte
+
+	host   string            // current host guid (HOST)
+	hosts  map[string]string // [guid]hostname (HOST_DEFINE)
+	charts map[string]*chart // [host guid + type.id]
+
+	defining *chart // CHART ... DIMENSION
+	labels   []label
+	updating *chart // BEGIN ... END
+	inResult bool   // FUNCTION_RESULT_BEGIN ... FUNCTION_RESULT_END
+}
+
+type (
+	chart struct {
+		host   string
+		typeID string
+		title  string
+		units  string
+		family string
+		ctx    string
+		module string
+		labels []label
+		dims   []*dim
+	}
+	dim struct {
+		id       string
+		name     string
+		algo     string
+		mul      int64
+		div      int64
+		value    int64
+		hasValue bool
+	}
+	label struct {
+		key   string
+		value string
+	}
+)
+
+func (c *chart) getDim(id string) *dim {
+	for _, d := range c.dims {
+		if d.id == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (c *chart) removeDim(id string) {
+	for i, d := range c.dims {
+		if d.id == id {
+			c.dims = append(c.dims[:i], c.dims[i+1:]...)
+			return
+		}
+	}
+}
+
+// Write implements io.Writer. It expects complete protocol lines, incomplete lines are buffered.
+func (e *Exporter) Write(p []byte) (int, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.pending = append(e.pending, p...)
+
+	for {
+		i := bytes.IndexByte(e.pending, '\n')
+		if i == -1 {
+			break
+		}
+		e.processLine(string(e.pending[:i]))
+		e.pending = e.pending[i+1:]
+	}
+	if len(e.pending) == 0 {
+		e.pending = nil
+	}
+
+	return len(p), nil
+}
+
+func (e *Exporter) processLine(line string) {
+	if line == "" {
+		return
+	}
+
+	if e.inResult {
+		e.inResult = !strings.HasPrefix(line, "FUNCTION_RESULT_END")
+		return
+	}
+
+	words := splitWords(line)
+	if len(words) == 0 {
+		return
+	}
+
+	switch words[0] {
+	case "CHART":
+		e.processChart(words[1:])
+	case "CLABEL":
+		if e.defining != nil && len(words) >= 3 {
+			e.labels = append(e.labels, label{key: words[1], value: words[2]})
+		}
+	case "CLABEL_COMMIT":
+		if e.defining != nil {
+			e.defining.labels = e.labels
+			e.labels = nil
+		}
+	case "DIMENSION":
+		e.processDimension(words[1:])
+	case "BEGIN":
+		if len(words) >= 2 {
+			e.updating = e.charts[e.chartKey(words[1])]
+		}
+	case "SET":
+		e.processSet(words[1:])
+	case "END":
+		e.updating = nil
+	case "HOST_DEFINE":
+		if len(words) >= 3 {
+			e.hosts[words[1]] = words[2]
+		}
+	case "HOST":
+		if len(words) >= 2 {
+			e.host = words[1]
+		} else {
+			e.host = ""
+		}
+	case "FUNCTION_RESULT_BEGIN":
+		e.inResult = true
+	}
+}
+
+// CHART type.id name title units [family [context [charttype [priority [update_every [options [plugin [module]]]]]]]]
+func (e *Exporter) processChart(words []string) {
+	e.defining, e.labels, e.updating = nil, nil, nil
+
+	if len(words) < 4 {
+		return
+	}
+
+	key := e.chartKey(words[0])
+
+	if strings.Contains(wordAt(words, 9), "obsolete") {
+		delete(e.charts, key)
+		return
+	}
+
+	c, ok := e.charts[key]
+	if !ok {
+		c = &chart{host: e.host, typeID: words[0]}
+		e.charts[key] = c
+	}
+
+	c.title = words[2]
+	c.units = words[3]
+	c.family = wordAt(words, 4)
+	c.ctx = wordAt(words, 5)
+	c.module = wordAt(words, 11)
+	if c.ctx == "" {
+		c.ctx = c.typeID
+	}
+
+	e.defining = c
+}
+
+// DIMENSION id name algorithm multiplier divisor options
+func (e *Exporter) processDimension(words []string) {
+	if e.defining == nil || len(words) < 1 {
+		return
+	}
+
+	id := words[0]
+
+	if strings.Contains(wordAt(words, 5), "obsolete") {
+		e.defining.removeDim(id)
+		return
+	}
+
+	d := e.defining.getDim(id)
+	if d == nil {
+		d = &dim{id: id}
+		e.defining.dims = append(e.defining.dims, d)
+	}
+
+	d.name = firstNotEmpty(wordAt(words, 1), id)
+	d.algo = firstNotEmpty(wordAt(words, 2), "absolute")
+	d.mul = parseIntOr(wordAt(words, 3), 1)
+	d.div = parseIntOr(wordAt(words, 4), 1)
+}
+
+// SET id = value
+func (e *Exporter) processSet(words []string) {
+	if e.updating == nil || len(words) < 1 {
+		return
+	}
+
+	d := e.updating.getDim(words[0])
+	if d == nil {
+		return
+	}
+
+	v, err := strconv.ParseInt(wordAt(words, 2), 10, 64)
+	d.value, d.hasValue = v, err == nil
+}
+
+func (e *Exporter) chartKey(typeID string) string {
+	return e.host + "/" + typeID
+}
+
+// splitWords splits a plugins.d protocol line into words. Words can be quoted with single or double quotes.
+func splitWords(line string) []string {
+	var words []string
+	var quote byte
+	var inWord bool
+	var b strings.Builder
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			if inWord {
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			}
+			quote = c
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			}
+		default:
+			b.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord || quote != 0 {
+		words = append(words, b.String())
+	}
+
+	return words
+}
+
+func wordAt(words []string, i int) string {
+	if i < len(words) {
+		return words[i]
+	}
+	return ""
+}
+
+func parseIntOr(s string, def int64) int64 {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v == 0 {
+		return def
+	}
+	return v
+}
+
+func firstNotEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promexporter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChartsInput = `CHART 'job1.requests' '' 'Requests' 'requests/s' 'requests' 'web.requests' 'line' '70000' '1' '' 'go.d' 'web'
+CLABEL 'instance' 'localhost:80' '2'
+CLABEL '_collect_job' 'job1' '1'
+CLABEL_COMMIT
+DIMENSION 'requests' 'requests' 'incremental' '1' '1' ''
+DIMENSION 'failed' 'failed' 'incremental' '1' '1' ''
+
+CHART 'job1.latency' '' 'Latency' 'ms' 'latency' 'web.latency' 'line' '70001' '1' '' 'go.d' 'web'
+CLABEL_COMMIT
+DIMENSION 'avg' 'avg' 'absolute' '1' '1000' ''
+
+BEGIN 'job1.requests'
+SET 'requests' = 10
+SET 'failed' =
+END
+
+BEGIN 'job1.latency' 1000000
+SET 'avg' = 1500
+END
+
+`
+
+const testMixedChartInput = `CHART 'job1.traffic' '' 'Traffic' 'bytes' 'traffic' 'web.traffic' 'line' '70000' '1' '' 'go.d' 'web'
+CLABEL_COMMIT
+DIMENSION 'in' 'in' 'incremental' '1' '1' ''
+DIMENSION 'buffered' 'buffered' 'absolute' '1' '1' ''
+
+BEGIN 'job1.traffic'
+SET 'in' = 100
+SET 'buffered' = 5
+END
+
+`
+
+func TestExporter_Write(t *testing.T) {
+	tests := map[string]struct {
+		input       []string
+		openMetrics bool
+		wantOutput  string
+	}{
+		"charts and values": {
+			input: []string{testChartsInput},
+			wantOutput: `# HELP netdata_web_latency Latency (ms)
+# TYPE netdata_web_latency gauge
+netdata_web_latency{chart="job1.latency",dimension="avg",family="latency",module="web"} 1.5
+# HELP netdata_web_requests_total Requests (requests/s)
+# TYPE netdata_web_requests_total counter
+netdata_web_requests_total{chart="job1.requests",dimension="requests",family="requests",module="web",instance="localhost:80",collect_job="job1"} 10
+`,
+		},
+		"charts and values, openmetrics": {
+			input:       []string{testChartsInput},
+			openMetrics: true,
+			wantOutput: `# HELP netdata_web_latency Latency (ms)
+# TYPE netdata_web_latency gauge
+netdata_web_latency{chart="job1.latency",dimension="avg",family="latency",module="web"} 1.5
+# HELP netdata_web_requests_total Requests (requests/s)
+# TYPE netdata_web_requests_total counter
+netdata_web_requests_total{chart="job1.requests",dimension="requests",family="requests",module="web",instance="localhost:80",collect_job="job1"} 10
+# EOF
+`,
+		},
+		"absolute and incremental dimensions in a context": {
+			input: []string{testMixedChartInput},
+			wantOutput: `# HELP netdata_web_traffic Traffic (bytes)
+# TYPE netdata_web_traffic gauge
+netdata_web_traffic{chart="job1.traffic",dimension="buffered",family="traffic",module="web"} 5
+# HELP netdata_web_traffic_total Traffic (bytes)
+# TYPE netdata_web_traffic_total counter
+netdata_web_traffic_total{chart="job1.traffic",dimension="in",family="traffic",module="web"} 100
+`,
+		},
+		"absolute and incremental dimensions in a context, openmetrics": {
+			input:       []string{testMixedChartInput},
+			openMetrics: true,
+			wantOutput: `# HELP netdata_web_traffic Traffic (bytes)
+# TYPE netdata_web_traffic gauge
+netdata_web_traffic{chart="job1.traffic",dimension="buffered",family="traffic",module="web"} 5
+# HELP netdata_web_traffic_total Traffic (bytes)
+# TYPE netdata_web_traffic_total counter
+netdata_web_traffic_total{chart="job1.traffic",dimension="in",family="traffic",module="web"} 100
+# EOF
+`,
+		},
+		"input split across writes": {
+			input: []string{testChartsInput[:100], testChartsInput[100:333], testChartsInput[333:]},
+			wantOutput: `# HELP netdata_web_latency Latency (ms)
+# TYPE netdata_web_latency gauge
+netdata_web_latency{chart="job1.latency",dimension="avg",family="latency",module="web"} 1.5
+# HELP netdata_web_requests_total Requests (requests/s)
+# TYPE netdata_web_requests_total counter
+netdata_web_requests_total{chart="job1.requests",dimension="requests",family="requests",module="web",instance="localhost:80",collect_job="job1"} 10
+`,
+		},
+		"obsolete chart is removed": {
+			input: []string{
+				testChartsInput,
+				"CHART 'job1.requests' '' 'Requests' 'requests/s' 'requests' 'web.requests' 'line' '70000' '1' 'obsolete' 'go.d' 'web'\n\n",
+			},
+			wantOutput: `# HELP netdata_web_latency Latency (ms)
+# TYPE netdata_web_latency gauge
+netdata_web_latency{chart="job1.latency",dimension="avg",family="latency",module="web"} 1.5
+`,
+		},
+		"function results are ignored": {
+			input: []string{
+				"FUNCTION_RESULT_BEGIN uid 1 application/json 0\nSET 'x' = 1\nFUNCTION_RESULT_END\n\n",
+			},
+			wantOutput: "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+
+			for _, in := range test.input {
+				_, err := e.Write([]byte(in))
+				require.NoError(t, err)
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, e.WriteTo(&buf, test.openMetrics))
+
+			assert.Equal(t, test.wantOutput, buf.String())
+		})
+	}
+}
+
+func TestExporter_ServeHTTP(t *testing.T) {
+	e := New()
+	_, _ = e.Write([]byte(testChartsInput))
+
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	bs, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, contentTypeOpenMetrics, resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(bs), "# EOF\n")
+}
+
+func Test_splitWords(t *testing.T) {
+	tests := map[string][]string{
+		"CLABEL_COMMIT":                    {"CLABEL_COMMIT"},
+		"BEGIN 'type.id' 1000":             {"BEGIN", "type.id", "1000"},
+		"SET 'id' = 10":                    {"SET", "id", "=", "10"},
+		"SET 'id' = ":                      {"SET", "id", "="},
+		"CHART 'a.b' '' 'with spaces' 'u'": {"CHART", "a.b", "", "with spaces", "u"},
+	}
+
+	for line, want := range tests {
+		t.Run(line, func(t *testing.T) {
+			assert.Equal(t, want, splitWords(line))
+		})
+	}
+}
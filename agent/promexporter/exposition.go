@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package promexporter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Serve starts an HTTP server that exposes the collected metrics on the '/metrics' path.
+// It blocks until the context is done.
+func (e *Exporter) Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 5,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		e.Infof("serving metrics on '%s/metrics'", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		sctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		return srv.Shutdown(sctx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+
+	if openMetrics {
+		w.Header().Set("Content-Type", contentTypeOpenMetrics)
+	} else {
+		w.Header().Set("Content-Type", contentTypeText)
+	}
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if err := e.WriteTo(w, openMetrics); err != nil {
+		e.Warningf("writing metrics: %v", err)
+	}
+}
+
+type (
+	metricFamily struct {
+		name    string
+		help    string
+		counter bool
+		samples []sample
+	}
+	sample struct {
+		labels string
+		value  float64
+	}
+)
+
+// WriteTo writes all collected metrics in Prometheus text (or OpenMetrics if openMetrics is true) exposition format.
+func (e *Exporter) WriteTo(w io.Writer, openMetrics bool) error {
+	families := e.collectFamilies()
+
+	bw := bufio.NewWriter(w)
+
+	for _, mf := range families {
+		// the counter family has the "_total" suffix in both formats (allowed by OpenMetrics parsers),
+		// so it doesn't clash with the gauge family of the same context
+		name := mf.name
+		typ := "gauge"
+		if mf.counter {
+			name += "_total"
+			typ = "counter"
+		}
+
+		_, _ = fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(mf.help))
+		_, _ = fmt.Fprintf(bw, "# TYPE %s %s\n", name, typ)
+
+		for _, s := range mf.samples {
+			_, _ = fmt.Fprintf(bw, "%s{%s} %s\n", name, s.labels, formatValue(s.value))
+		}
+	}
+
+	if openMetrics {
+		_, _ = bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+func (e *Exporter) collectFamilies() []*metricFamily {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	set := make(map[string]*metricFamily)
+
+	for _, c := range e.charts {
+		for _, d := range c.dims {
+			if !d.hasValue {
+				continue
+			}
+
+			counter := d.algo == "incremental"
+			name := sanitizeName(e.Prefix + "_" + c.ctx)
+
+			key := name
+			if counter {
+				key += "_total"
+			}
+
+			mf, ok := set[key]
+			if !ok {
+				mf = &metricFamily{
+					name:    name,
+					help:    fmt.Sprintf("%s (%s)", c.title, c.units),
+					counter: counter,
+				}
+				set[key] = mf
+			}
+
+			mf.samples = append(mf.samples, sample{
+				labels: e.sampleLabels(c, d),
+				value:  float64(d.value) * float64(d.mul) / float64(d.div),
+			})
+		}
+	}
+
+	families := make([]*metricFamily, 0, len(set))
+	for _, mf := range set {
+		sort.Slice(mf.samples, func(i, j int) bool { return mf.samples[i].labels < mf.samples[j].labels })
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		if families[i].name == families[j].name {
+			return !families[i].counter
+		}
+		return families[i].name < families[j].name
+	})
+
+	return families
+}
+
+func (e *Exporter) sampleLabels(c *chart, d *dim) string {
+	var b strings.Builder
+
+	writeLabel := func(key, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(key)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(value))
+		b.WriteByte('"')
+	}
+
+	writeLabel("chart", c.typeID)
+	writeLabel("dimension", d.name)
+	if c.family != "" {
+		writeLabel("family", c.family)
+	}
+	if c.module != "" {
+		writeLabel("module", c.module)
+	}
+	if hostname := e.hosts[c.host]; hostname != "" {
+		writeLabel("vnode", hostname)
+	}
+
+	seen := map[string]bool{"chart": true, "dimension": true, "family": true, "module": true, "vnode": true}
+	for _, l := range c.labels {
+		key := sanitizeLabelName(l.key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		writeLabel(key, l.value)
+	}
+
+	return b.String()
+}
+
+func sanitizeName(name string) string {
+	var b strings.Builder
+	var prevUnderscore bool
+
+	for i, r := range name {
+		valid := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == ':' || (r >= '0' && r <= '9' && i > 0)
+		if !valid {
+			r = '_'
+		}
+		if r == '_' && prevUnderscore {
+			continue
+		}
+		prevUnderscore = r == '_'
+		b.WriteRune(r)
+	}
+
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+func sanitizeLabelName(name string) string {
+	name = strings.TrimLeft(sanitizeName(name), "_")
+	return strings.ReplaceAll(name, ":", "_")
+}
+
+func escapeHelp(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+func escapeLabelValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery"
+	"github.com/netdata/go.d.plugin/agent/jobmgr"
+	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/vnodes"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pluginConfigSettle is the time to wait after the last plugin config file change before reloading it,
+// editors often write a file in several steps.
+var pluginConfigSettle = time.Second
+
+type (
+	// reloadTargets are the components the reloaded configuration is applied to.
+	reloadTargets struct {
+		jobs   jobsReloader
+		disc   discoveryReloader
+		dyncfg dyncfgReloader // nil if dyncfg is not used
+	}
+	jobsReloader interface {
+		SetModules(module.Registry)
+		SetVnodes(jobmgr.Vnodes)
+	}
+	discoveryReloader interface {
+		Reload(discovery.Config) error
+	}
+	dyncfgReloader interface {
+		SetModules(module.Registry, confgroup.Registry)
+	}
+)
+
+func (a *Agent) triggerReload() {
+	select {
+	case a.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// reload reloads the plugin config, the virtual nodes, the modules configs and the service discovery configs:
+//   - the jobs of the disabled modules are stopped, the jobs of the enabled modules are started.
+//   - the jobs whose vnode has changed are restarted.
+//   - the file and dummy discoverers are restarted, only the jobs whose configs have changed are affected.
+//
+// The 'tick_spread' option requires a restart.
+func (a *Agent) reload(enabled module.Registry, t reloadTargets) module.Registry {
+	cfg, ok := a.reloadPluginConfig()
+	if !ok {
+		return enabled
+	}
+
+	newEnabled := module.Registry{}
+	if cfg.Enabled {
+		newEnabled = a.loadEnabledModules(cfg)
+	} else {
+		a.Info("plugin is disabled in the configuration file, stopping all jobs")
+	}
+
+	if added, removed := diffModules(enabled, newEnabled); len(added) == 0 && len(removed) == 0 {
+		a.Info("config reloaded, enabled modules are not changed")
+	} else {
+		a.Infof("config reloaded, enabled modules: %v, disabled modules: %v", added, removed)
+	}
+
+	// the jobs manager must know the enabled modules and vnodes before the configs are discovered
+	t.jobs.SetModules(newEnabled)
+	t.jobs.SetVnodes(a.reloadVnodes())
+
+	discCfg := a.buildDiscoveryConf(newEnabled)
+
+	if t.dyncfg != nil {
+		t.dyncfg.SetModules(newEnabled, discCfg.Registry)
+	}
+
+	if err := t.disc.Reload(discCfg); err != nil {
+		a.Errorf("couldn't reload the modules configs: %v", err)
+	}
+
+	return newEnabled
+}
+
+// reloadVnodes reads the vnodes configs, it returns nil if there are none.
+func (a *Agent) reloadVnodes() jobmgr.Vnodes {
+	reg := a.setupVnodeRegistry()
+	if reg == nil || reg.Len() == 0 {
+		return nil
+	}
+	if vnodes.Disabled {
+		a.Warning("vnodes were not configured on start, the plugin must be restarted to use them")
+		return nil
+	}
+	return reg
+}
+
+// reloadPluginConfig loads the plugin config, it returns false if the config file exists but can't be loaded.
+// Unlike on start, the defaults are not used in this case, they would enable the modules disabled in the config.
+func (a *Agent) reloadPluginConfig() (config, bool) {
+	if path, err := a.ConfDir.Find(a.Name + ".conf"); err == nil && path != "" {
+		var cfg config
+		if err := loadYAML(&cfg, path); err != nil {
+			a.Warningf("couldn't reload config '%s': %v, keeping the current configuration", path, err)
+			return cfg, false
+		}
+	}
+	return a.loadPluginConfig(), true
+}
+
+// watchPluginConfig triggers a reload when the plugin config file is created, changed or removed.
+func (a *Agent) watchPluginConfig(ctx context.Context) {
+	if len(a.ConfDir) == 0 {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		a.Warningf("couldn't watch the config file: %v", err)
+		return
+	}
+	defer func() { _ = watcher.Close() }()
+
+	paths := make(map[string]bool)
+	for _, dir := range a.ConfDir {
+		// the directory is watched, editors often replace the file
+		if err := watcher.Add(dir); err != nil {
+			a.Debugf("couldn't watch '%s': %v", dir, err)
+			continue
+		}
+		paths[filepath.Join(dir, a.Name+".conf")] = true
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	settle := time.NewTimer(pluginConfigSettle)
+	settle.Stop()
+	defer settle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if paths[filepath.Clean(event.Name)] && event.Op != fsnotify.Chmod {
+				settle.Reset(pluginConfigSettle)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			a.Warningf("watch the config file: %v", err)
+		case <-settle.C:
+			a.Info("config file changed, reloading it")
+			a.triggerReload()
+		}
+	}
+}
+
+func diffModules(before, after module.Registry) (added, removed []string) {
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
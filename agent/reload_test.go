@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/discovery"
+	"github.com/netdata/go.d.plugin/agent/jobmgr"
+	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/vnodes"
+	"github.com/netdata/go.d.plugin/pkg/multipath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent_reload(t *testing.T) {
+	defer func(v bool) { vnodes.Disabled = v }(vnodes.Disabled)
+	vnodes.Disabled = false
+
+	tests := map[string]struct {
+		config      string
+		wantEnabled []string
+		wantReload  bool
+	}{
+		"module disabled": {
+			config:      "modules:\n  module2: no\n",
+			wantEnabled: []string{"module1"},
+			wantReload:  true,
+		},
+		"module enabled": {
+			config:      "modules:\n  module3: yes\n",
+			wantEnabled: []string{"module1", "module2", "module3"},
+			wantReload:  true,
+		},
+		"modules not changed": {
+			config:      "modules:\n  module1: yes\n",
+			wantEnabled: []string{"module1", "module2"},
+			wantReload:  true,
+		},
+		"plugin disabled": {
+			config:     "enabled: no\n",
+			wantReload: true,
+		},
+		"invalid config": {
+			config:      "modules: [\n",
+			wantEnabled: []string{"module1", "module2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "go.d.conf"), []byte(test.config), 0644))
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "vnodes"), 0755))
+			vnode := "- hostname: vnode1\n  guid: 4ea21e84-93b4-418b-b83e-79397610cd6e\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "vnodes", "vnodes.conf"), []byte(vnode), 0644))
+
+			a := New(Config{Name: "go.d", ConfDir: multipath.New(dir), VnodesConfDir: multipath.New(dir)})
+			var mux sync.Mutex
+			a.ModuleRegistry = prepareRegistry(&mux, map[string]int{}, "module1", "module2", "module3")
+			creator := a.ModuleRegistry["module3"]
+			creator.Disabled = true
+			a.ModuleRegistry["module3"] = creator
+
+			enabled := module.Registry{"module1": a.ModuleRegistry["module1"], "module2": a.ModuleRegistry["module2"]}
+			jobs, disc, dyncfg := &mockJobsReloader{}, &mockDiscoveryReloader{}, &mockDyncfgReloader{}
+
+			enabled = a.reload(enabled, reloadTargets{jobs: jobs, disc: disc, dyncfg: dyncfg})
+
+			assert.ElementsMatch(t, test.wantEnabled, registryNames(enabled))
+			if test.wantReload {
+				require.NotNil(t, jobs.modules)
+				assert.ElementsMatch(t, test.wantEnabled, registryNames(jobs.modules))
+				require.NotNil(t, jobs.vnodes)
+				_, ok := jobs.vnodes.Lookup("vnode1")
+				assert.True(t, ok)
+				require.NotNil(t, disc.cfg)
+				assert.ElementsMatch(t, test.wantEnabled, disc.cfg.Dummy.Names)
+				require.NotNil(t, dyncfg.modules)
+				assert.ElementsMatch(t, test.wantEnabled, registryNames(dyncfg.modules))
+			} else {
+				assert.Nil(t, jobs.modules)
+				assert.Nil(t, jobs.vnodes)
+				assert.Nil(t, disc.cfg)
+				assert.Nil(t, dyncfg.modules)
+			}
+		})
+	}
+}
+
+func TestAgent_watchPluginConfig(t *testing.T) {
+	defer func(v time.Duration) { pluginConfigSettle = v }(pluginConfigSettle)
+	pluginConfigSettle = time.Millisecond * 50
+
+	dir := t.TempDir()
+	a := New(Config{Name: "go.d", ConfDir: multipath.New(dir)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { defer wg.Done(); a.watchPluginConfig(ctx) }()
+	defer func() { cancel(); wg.Wait() }()
+
+	time.Sleep(time.Millisecond * 100)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.conf"), []byte("enabled: yes\n"), 0644))
+
+	select {
+	case <-a.reloadCh:
+		t.Fatal("reload triggered by other file change")
+	case <-time.After(time.Millisecond * 300):
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.d.conf"), []byte("enabled: yes\n"), 0644))
+
+	select {
+	case <-a.reloadCh:
+	case <-time.After(time.Second * 5):
+		t.Fatal("reload is not triggered by the config file change")
+	}
+}
+
+type mockJobsReloader struct {
+	modules module.Registry
+	vnodes  jobmgr.Vnodes
+}
+
+func (m *mockJobsReloader) SetModules(reg module.Registry) { m.modules = reg }
+func (m *mockJobsReloader) SetVnodes(vnodes jobmgr.Vnodes) { m.vnodes = vnodes }
+
+type mockDyncfgReloader struct{ modules module.Registry }
+
+func (m *mockDyncfgReloader) SetModules(reg module.Registry, _ confgroup.Registry) { m.modules = reg }
+
+type mockDiscoveryReloader struct{ cfg *discovery.Config }
+
+func (m *mockDiscoveryReloader) Reload(cfg discovery.Config) error { m.cfg = &cfg; return nil }
+
+func registryNames(reg module.Registry) []string {
+	var names []string
+	for name := range reg {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,335 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package secrets resolves secret references in job configurations:
+//
+//	${env:NAME}                   the NAME environment variable
+//	${file:/path/to/file}         the file content
+//	${cmd:/path/to/cmd arg1 arg2} the command output (the arguments are split on whitespace, no shell is used)
+//
+// Trailing whitespace is trimmed from the file content and the command output.
+// The resolved values are replaced with their references in the logs (see Redact).
+//
+// The file and cmd references give access to the host, the callers allow them only in the trusted configs
+// (see ResolveConfigKinds).
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheTTL   = time.Minute * 5
+	defaultCmdTimeout = time.Second * 10
+
+	// minRedactLength is the shortest secret value that is redacted,
+	// replacing the shorter ones (e.g. "1", "yes") would mangle the unrelated text.
+	minRedactLength = 6
+)
+
+// Kind is the secret reference kind.
+type Kind string
+
+const (
+	Env  Kind = "env"
+	File Kind = "file"
+	Cmd  Kind = "cmd"
+)
+
+// AllKinds are all the secret reference kinds.
+var AllKinds = []Kind{Env, File, Cmd}
+
+// Default is the resolver used by the package level functions.
+var Default = New()
+
+// Resolve resolves the secret references in the string using the Default resolver.
+func Resolve(s string) (string, error) { return Default.Resolve(s) }
+
+// ResolveConfig resolves the secret references in the config values using the Default resolver.
+func ResolveConfig(cfg map[string]any) error { return Default.ResolveConfig(cfg) }
+
+// ResolveConfigKinds resolves the secret references of the allowed kinds in the config values using the Default resolver.
+func ResolveConfigKinds(cfg map[string]any, allowed ...Kind) error {
+	return Default.ResolveConfigKinds(cfg, allowed...)
+}
+
+// Redact replaces the secrets resolved by the Default resolver with their references.
+func Redact(s string) string { return Default.Redact(s) }
+
+var reRef = regexp.MustCompile(`\$\{(env|file|cmd):([^}]*)}`)
+
+func New() *Resolver {
+	return &Resolver{
+		CacheTTL:   defaultCacheTTL,
+		CmdTimeout: defaultCmdTimeout,
+		mux:        &sync.Mutex{},
+		cache:      make(map[string]cacheEntry),
+		refs:       make(map[string]string),
+		lookupEnv:  os.LookupEnv,
+		readFile:   os.ReadFile,
+		runCmd: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return exec.CommandContext(ctx, name, args...).Output()
+		},
+		now: time.Now,
+	}
+}
+
+// Resolver resolves and caches secret references. It remembers the resolved values to redact them.
+type Resolver struct {
+	// CacheTTL is how long the file and cmd secrets are cached.
+	CacheTTL time.Duration
+	// CmdTimeout is the cmd secrets execution timeout.
+	CmdTimeout time.Duration
+
+	mux      *sync.Mutex
+	cache    map[string]cacheEntry
+	refs     map[string]string // resolved value => reference
+	redactor *strings.Replacer // built from refs, reset when a new value is remembered
+
+	lookupEnv func(string) (string, bool)
+	readFile  func(string) ([]byte, error)
+	runCmd    func(ctx context.Context, name string, args ...string) ([]byte, error)
+	now       func() time.Time
+}
+
+type cacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+// References returns the secret references in the string.
+func References(s string) []string {
+	if !strings.Contains(s, "${") {
+		return nil
+	}
+	return reRef.FindAllString(s, -1)
+}
+
+// ConfigReferences returns the secret references in the config values, including the nested ones.
+func ConfigReferences(cfg map[string]any) []string {
+	var refs []string
+	for _, v := range cfg {
+		refs = appendReferences(refs, v)
+	}
+	return refs
+}
+
+func appendReferences(refs []string, value any) []string {
+	switch v := value.(type) {
+	case string:
+		refs = append(refs, References(v)...)
+	case map[string]any:
+		refs = append(refs, ConfigReferences(v)...)
+	case map[any]any:
+		for _, val := range v {
+			refs = appendReferences(refs, val)
+		}
+	case []any:
+		for _, val := range v {
+			refs = appendReferences(refs, val)
+		}
+	}
+	return refs
+}
+
+// Resolve replaces all secret references in the string with their values.
+func (r *Resolver) Resolve(s string) (string, error) {
+	return r.resolve(s, AllKinds)
+}
+
+func (r *Resolver) resolve(s string, allowed []Kind) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var err error
+	res := reRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		m := reRef.FindStringSubmatch(ref)
+		if !slices.Contains(allowed, Kind(m[1])) {
+			err = fmt.Errorf("secret '%s': '%s' references are not allowed in this config", ref, m[1])
+			return ref
+		}
+		var v string
+		if v, err = r.lookup(m[1], strings.TrimSpace(m[2])); err != nil {
+			err = fmt.Errorf("secret '%s': %v", ref, err)
+			return ref
+		}
+		r.remember(v, ref)
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return res, nil
+}
+
+// ResolveConfig resolves the secret references in all string values of the config, including the nested ones.
+// The internal keys ("__key__") are left as is.
+func (r *Resolver) ResolveConfig(cfg map[string]any) error {
+	return r.ResolveConfigKinds(cfg, AllKinds...)
+}
+
+// ResolveConfigKinds is like ResolveConfig, but the references of the not allowed kinds are an error.
+// With no allowed kinds, any secret reference is an error.
+func (r *Resolver) ResolveConfigKinds(cfg map[string]any, allowed ...Kind) error {
+	for k, v := range cfg {
+		if strings.HasPrefix(k, "__") && strings.HasSuffix(k, "__") {
+			continue
+		}
+		res, err := r.resolveValue(v, allowed)
+		if err != nil {
+			return fmt.Errorf("'%s': %v", k, err)
+		}
+		cfg[k] = res
+	}
+	return nil
+}
+
+func (r *Resolver) resolveValue(value any, allowed []Kind) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return r.resolve(v, allowed)
+	case map[string]any:
+		if err := r.ResolveConfigKinds(v, allowed...); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case map[any]any:
+		for k, val := range v {
+			res, err := r.resolveValue(val, allowed)
+			if err != nil {
+				return nil, fmt.Errorf("'%v': %v", k, err)
+			}
+			v[k] = res
+		}
+		return v, nil
+	case []any:
+		for i, val := range v {
+			res, err := r.resolveValue(val, allowed)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %v", i, err)
+			}
+			v[i] = res
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// Redact replaces the resolved secret values in the string with their references.
+// The values shorter than 6 characters are not redacted.
+func (r *Resolver) Redact(s string) string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if len(r.refs) == 0 {
+		return s
+	}
+
+	if r.redactor == nil {
+		values := make([]string, 0, len(r.refs))
+		for v := range r.refs {
+			values = append(values, v)
+		}
+		// the longest first, a secret can contain another one
+		sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+		oldnew := make([]string, 0, len(values)*2)
+		for _, v := range values {
+			oldnew = append(oldnew, v, r.refs[v])
+		}
+		r.redactor = strings.NewReplacer(oldnew...)
+	}
+	return r.redactor.Replace(s)
+}
+
+func (r *Resolver) lookup(kind, arg string) (string, error) {
+	if arg == "" {
+		return "", errors.New("empty reference")
+	}
+
+	if kind == "env" {
+		v, ok := r.lookupEnv(arg)
+		if !ok {
+			return "", errors.New("environment variable is not set")
+		}
+		return v, nil
+	}
+
+	key := kind + ":" + arg
+
+	r.mux.Lock()
+	e, ok := r.cache[key]
+	r.mux.Unlock()
+	if ok && r.now().Before(e.expires) {
+		return e.value, nil
+	}
+
+	var v string
+	var err error
+	switch kind {
+	case "file":
+		v, err = r.lookupFile(arg)
+	case "cmd":
+		v, err = r.lookupCmd(arg)
+	default:
+		err = fmt.Errorf("unknown secret kind '%s'", kind)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	r.mux.Lock()
+	r.cache[key] = cacheEntry{value: v, expires: r.now().Add(r.CacheTTL)}
+	r.mux.Unlock()
+
+	return v, nil
+}
+
+func (r *Resolver) lookupFile(path string) (string, error) {
+	bs, err := r.readFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bs), " \t\r\n"), nil
+}
+
+func (r *Resolver) lookupCmd(cmdLine string) (string, error) {
+	args := strings.Fields(cmdLine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.CmdTimeout)
+	defer cancel()
+
+	bs, err := r.runCmd(ctx, args[0], args[1:]...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bs), " \t\r\n"), nil
+}
+
+func (r *Resolver) remember(value, ref string) {
+	if len(value) < minRedactLength {
+		return
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, ok := r.refs[value]; !ok {
+		r.refs[value] = ref
+		r.redactor = nil
+	}
+}
@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		"no references": {
+			input: "plain value",
+			want:  "plain value",
+		},
+		"not a secret reference": {
+			input: "${1}:${name}",
+			want:  "${1}:${name}",
+		},
+		"env": {
+			input: "${env:PASSWORD}",
+			want:  "env-secret",
+		},
+		"file": {
+			input: "${file:/etc/secret}",
+			want:  "file-secret",
+		},
+		"cmd": {
+			input: "${cmd:/usr/bin/get-secret mysql}",
+			want:  "cmd-secret-mysql",
+		},
+		"multiple references in a value": {
+			input: "user:${env:PASSWORD}@tcp(${file:/etc/secret})/",
+			want:  "user:env-secret@tcp(file-secret)/",
+		},
+		"env not set": {
+			input:   "${env:NOT_SET}",
+			wantErr: true,
+		},
+		"file not found": {
+			input:   "${file:/not/exists}",
+			wantErr: true,
+		},
+		"cmd failed": {
+			input:   "${cmd:/usr/bin/false}",
+			wantErr: true,
+		},
+		"empty reference": {
+			input:   "${env:}",
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := prepareResolver()
+
+			v, err := r.Resolve(test.input)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.want, v)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveConfig(t *testing.T) {
+	r := prepareResolver()
+
+	cfg := map[string]any{
+		"name":       "${env:PASSWORD}",
+		"__source__": "${env:PASSWORD}",
+		"port":       3306,
+		"headers":    map[any]any{"X-Api-Key": "${file:/etc/secret}"},
+		"servers":    []any{map[any]any{"password": "${env:PASSWORD}"}},
+	}
+
+	require.NoError(t, r.ResolveConfig(cfg))
+
+	assert.Equal(t, map[string]any{
+		"name":       "env-secret",
+		"__source__": "${env:PASSWORD}",
+		"port":       3306,
+		"headers":    map[any]any{"X-Api-Key": "file-secret"},
+		"servers":    []any{map[any]any{"password": "env-secret"}},
+	}, cfg)
+
+	err := r.ResolveConfig(map[string]any{"servers": []any{"${env:NOT_SET}"}})
+	assert.ErrorContains(t, err, "'servers': [0]: secret '${env:NOT_SET}'")
+}
+
+func TestResolver_ResolveConfigKinds(t *testing.T) {
+	tests := map[string]struct {
+		allowed []Kind
+		value   string
+		want    string
+		wantErr bool
+	}{
+		"env allowed":          {allowed: []Kind{Env}, value: "${env:PASSWORD}", want: "env-secret"},
+		"file not allowed":     {allowed: []Kind{Env}, value: "${file:/etc/secret}", wantErr: true},
+		"cmd not allowed":      {allowed: []Kind{Env}, value: "${cmd:/usr/bin/get-secret mysql}", wantErr: true},
+		"none allowed":         {value: "${env:PASSWORD}", wantErr: true},
+		"none allowed no refs": {value: "plain value", want: "plain value"},
+		"all allowed":          {allowed: AllKinds, value: "${cmd:/usr/bin/get-secret mysql}", want: "cmd-secret-mysql"},
+		"one not allowed":      {allowed: []Kind{Env}, value: "${env:PASSWORD}:${file:/etc/secret}", wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := prepareResolver()
+			cfg := map[string]any{"servers": []any{map[any]any{"password": test.value}}}
+
+			err := r.ResolveConfigKinds(cfg, test.allowed...)
+
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"servers": []any{map[any]any{"password": test.want}}}, cfg)
+		})
+	}
+}
+
+func TestConfigReferences(t *testing.T) {
+	cfg := map[string]any{
+		"name":    "job",
+		"port":    3306,
+		"dsn":     "user:${env:PASSWORD}@tcp(127.0.0.1:3306)/",
+		"headers": map[any]any{"X-Api-Key": "${file:/etc/secret}"},
+		"servers": []any{map[string]any{"password": "${cmd:/usr/bin/get-secret}"}, "${1}"},
+	}
+
+	assert.ElementsMatch(t,
+		[]string{"${env:PASSWORD}", "${file:/etc/secret}", "${cmd:/usr/bin/get-secret}"},
+		ConfigReferences(cfg),
+	)
+	assert.Empty(t, ConfigReferences(map[string]any{"name": "job"}))
+}
+
+func TestResolver_Resolve_Cache(t *testing.T) {
+	r := prepareResolver()
+	var calls int
+	r.runCmd = func(context.Context, string, ...string) ([]byte, error) {
+		calls++
+		return []byte("secret\n"), nil
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		v, err := r.Resolve("${cmd:/usr/bin/get-secret}")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", v)
+	}
+	assert.Equal(t, 1, calls)
+
+	now = now.Add(r.CacheTTL + time.Second)
+	_, err := r.Resolve("${cmd:/usr/bin/get-secret}")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestResolver_Redact(t *testing.T) {
+	r := prepareResolver()
+
+	assert.Equal(t, "password: env-secret", r.Redact("password: env-secret"))
+
+	_, err := r.Resolve("user:${env:PASSWORD}@tcp(127.0.0.1:3306)/")
+	require.NoError(t, err)
+
+	assert.Equal(t, "dsn: user:${env:PASSWORD}@tcp(127.0.0.1:3306)/", r.Redact("dsn: user:env-secret@tcp(127.0.0.1:3306)/"))
+}
+
+func TestResolver_Redact_SkipsShortValues(t *testing.T) {
+	r := prepareResolver()
+	r.lookupEnv = func(string) (string, bool) { return "1", true }
+
+	_, err := r.Resolve("${env:DB_INDEX}")
+	require.NoError(t, err)
+
+	assert.Equal(t, "port: 1111, db: 1", r.Redact("port: 1111, db: 1"))
+}
+
+func TestResolver_Resolve_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("file-secret\n"), 0600))
+
+	v, err := New().Resolve("${file:" + path + "}")
+	require.NoError(t, err)
+	assert.Equal(t, "file-secret", v)
+}
+
+func prepareResolver() *Resolver {
+	r := New()
+	r.lookupEnv = func(name string) (string, bool) {
+		if name == "PASSWORD" {
+			return "env-secret", true
+		}
+		return "", false
+	}
+	r.readFile = func(path string) ([]byte, error) {
+		if path == "/etc/secret" {
+			return []byte("file-secret\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	r.runCmd = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if name == "/usr/bin/get-secret" {
+			return []byte("cmd-secret-" + strings.Join(args, "-") + "\n"), nil
+		}
+		return nil, errors.New("exit status 1")
+	}
+	return r
+}
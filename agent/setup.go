@@ -10,6 +10,7 @@ import (
 	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/discovery"
 	"github.com/netdata/go.d.plugin/agent/discovery/dummy"
+	"github.com/netdata/go.d.plugin/agent/discovery/dyncfg"
 	"github.com/netdata/go.d.plugin/agent/discovery/file"
 	"github.com/netdata/go.d.plugin/agent/hostinfo"
 	"github.com/netdata/go.d.plugin/agent/module"
@@ -42,9 +43,36 @@ func (a *Agent) loadPluginConfig() config {
 		return defaultConfig()
 	}
 	a.Info("config successfully loaded")
+
+	a.applyDyncfgPluginConfig(&cfg)
+
 	return cfg
 }
 
+// applyDyncfgPluginConfig applies the plugin configuration changes made via dyncfg on top of the config file.
+func (a *Agent) applyDyncfgPluginConfig(cfg *config) {
+	bs, err := a.dyncfgStore().PluginConfig()
+	if err != nil {
+		a.Warningf("couldn't load dyncfg plugin config: %v", err)
+		return
+	}
+	if len(bs) == 0 {
+		return
+	}
+
+	tmp := *cfg
+	if err := yaml.Unmarshal(bs, &tmp); err != nil {
+		a.Warningf("couldn't apply dyncfg plugin config: %v", err)
+		return
+	}
+	a.Info("applied dyncfg plugin config")
+	*cfg = tmp
+}
+
+func (a *Agent) dyncfgStore() *dyncfg.Store {
+	return dyncfg.NewStore(a.DyncfgDir)
+}
+
 func (a *Agent) loadEnabledModules(cfg config) module.Registry {
 	a.Info("loading modules")
 
@@ -87,6 +115,17 @@ func (a *Agent) buildDiscoveryConf(enabled module.Registry) discovery.Config {
 		})
 	}
 
+	if defaults, err := a.dyncfgStore().ModuleDefaults(); err != nil {
+		a.Warningf("couldn't load dyncfg modules defaults: %v", err)
+	} else {
+		for name, def := range defaults {
+			if _, ok := reg.Lookup(name); ok {
+				def.MinUpdateEvery = a.MinUpdateEvery
+				reg.Register(name, def)
+			}
+		}
+	}
+
 	var readPaths, dummyPaths []string
 
 	if len(a.ModulesConfDir) == 0 {
@@ -5,6 +5,7 @@ package agent
 import (
 	"testing"
 
+	"github.com/netdata/go.d.plugin/agent/confgroup"
 	"github.com/netdata/go.d.plugin/agent/module"
 
 	"github.com/stretchr/testify/assert"
@@ -28,6 +29,17 @@ func TestConfig_UnmarshalYAML(t *testing.T) {
 				},
 			},
 		},
+		"valid configuration with tick spread": {
+			input: "enabled: yes\ndefault_run: yes\ntick_spread: yes\nmodules:\n  module1: yes",
+			wantCfg: config{
+				Enabled:    true,
+				DefaultRun: true,
+				TickSpread: true,
+				Modules: map[string]bool{
+					"module1": true,
+				},
+			},
+		},
 		"valid configuration with broken modules section": {
 			input: "enabled: yes\ndefault_run: yes\nmodules:\nmodule1: yes\nmodule2: yes",
 			wantCfg: config{
@@ -71,6 +83,23 @@ func TestAgent_loadConfig(t *testing.T) {
 				},
 			},
 		},
+		"valid config file with dyncfg changes": {
+			agent: Agent{
+				Name:      "agent-valid",
+				ConfDir:   []string{"testdata"},
+				DyncfgDir: "testdata/dyncfg",
+			},
+			wantCfg: config{
+				Enabled:    true,
+				DefaultRun: true,
+				MaxProcs:   2,
+				Modules: map[string]bool{
+					"module1": true,
+					"module2": true,
+					"module3": true,
+				},
+			},
+		},
 		"no config path provided": {
 			agent:   Agent{},
 			wantCfg: defaultConfig(),
@@ -207,3 +236,21 @@ func TestAgent_loadEnabledModules(t *testing.T) {
 func TestAgent_buildDiscoveryConf(t *testing.T) {
 
 }
+
+func TestAgent_buildDiscoveryConf_DyncfgModuleDefaults(t *testing.T) {
+	a := Agent{
+		DyncfgDir:      "testdata/dyncfg",
+		MinUpdateEvery: 2,
+	}
+	enabled := module.Registry{
+		"module1": module.Creator{Defaults: module.Defaults{UpdateEvery: 1}},
+		"module2": module.Creator{Defaults: module.Defaults{UpdateEvery: 1}},
+	}
+
+	cfg := a.buildDiscoveryConf(enabled)
+
+	assert.Equal(t, confgroup.Registry{
+		"module1": confgroup.Default{MinUpdateEvery: 2, UpdateEvery: 5, AutoDetectionRetry: 30, Priority: 1000},
+		"module2": confgroup.Default{MinUpdateEvery: 2, UpdateEvery: 1},
+	}, cfg.Registry)
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/netdata/go.d.plugin/agent/discovery/file"
+	"github.com/netdata/go.d.plugin/agent/validate"
+
+	"gopkg.in/yaml.v2"
+)
+
+// staticConfigKeys are the allowed top level keys of a module configuration file in static format.
+var staticConfigKeys = map[string]bool{
+	"jobs":                true,
+	"update_every":        true,
+	"autodetection_retry": true,
+	"priority":            true,
+}
+
+// Validate loads the plugin configuration, the modules configurations and the sd configurations the same way Run does
+// and validates every job configuration against the module job configuration schema.
+// It writes per-file, per-job problems to w and returns false if any problem is found.
+func (a *Agent) Validate(w io.Writer) bool {
+	var files, jobs, problems int
+
+	report := func(path string, errs []string) {
+		if len(errs) == 0 {
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s:\n", path)
+		for _, e := range errs {
+			_, _ = fmt.Fprintf(w, "  - %s\n", e)
+		}
+		problems += len(errs)
+	}
+
+	cfg := a.loadPluginConfig()
+
+	if path, errs := a.validatePluginConfig(); path != "" {
+		files++
+		report(path, errs)
+	}
+
+	enabled := a.loadEnabledModules(cfg)
+	discCfg := a.buildDiscoveryConf(enabled)
+
+	v := validate.New(a.ModuleRegistry)
+
+	paths := configFilePaths(discCfg.File, func(pattern string, err error) {
+		report(pattern, []string{fmt.Sprintf("invalid path pattern: %v", err)})
+	})
+
+	for _, path := range paths {
+		files++
+
+		errs := validateStaticConfigKeys(path)
+
+		group, err := file.Parse(discCfg.Registry, path)
+		if err != nil {
+			report(path, append(errs, fmt.Sprintf("parse: %v", err)))
+			continue
+		}
+
+		if group != nil {
+			for _, jobCfg := range group.Configs {
+				jobs++
+				for _, e := range v.ValidateJob(jobCfg) {
+					errs = append(errs, fmt.Sprintf("%s[%s]: %s", jobCfg.Module(), jobCfg.Name(), e))
+				}
+			}
+		}
+
+		report(path, errs)
+	}
+
+	_, _ = fmt.Fprintf(w, "validated %d files, %d jobs: %d problems found\n", files, jobs, problems)
+
+	return problems == 0
+}
+
+// configFilePaths returns the files read by the file discovery: the read paths and the files matching the watch patterns.
+func configFilePaths(cfg file.Config, onInvalidPattern func(pattern string, err error)) []string {
+	var paths []string
+	paths = append(paths, cfg.Read...)
+	for _, pattern := range cfg.Watch {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			onInvalidPattern(pattern, err)
+			continue
+		}
+		for _, path := range matches {
+			if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+func (a *Agent) validatePluginConfig() (string, []string) {
+	if len(a.ConfDir) == 0 {
+		return "", nil
+	}
+
+	path, err := a.ConfDir.Find(a.Name + ".conf")
+	if err != nil || path == "" {
+		return "", nil
+	}
+
+	var cfg config
+	if err := loadYAML(&cfg, path); err != nil {
+		return path, []string{fmt.Sprintf("parse: %v", err)}
+	}
+
+	var errs []string
+	var names []string
+	for name := range cfg.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := a.ModuleRegistry[name]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown module '%s'", name))
+		}
+	}
+
+	return path, errs
+}
+
+func validateStaticConfigKeys(path string) []string {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var m map[string]any
+	if err := yaml.Unmarshal(bs, &m); err != nil {
+		// not a static format config or invalid yaml, parse reports it
+		return nil
+	}
+
+	var errs []string
+	for key := range m {
+		if !staticConfigKeys[key] {
+			errs = append(errs, fmt.Sprintf("unknown key '%s'", key))
+		}
+	}
+	sort.Strings(errs)
+
+	return errs
+}
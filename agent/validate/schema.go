@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const schemaURL = "config_schema.json"
+
+// schema is a compiled module job configuration JSON schema.
+// Schemas without the "$schema" keyword are treated as draft-07, the draft the modules schemas are written in.
+type schema struct {
+	*jsonschema.Schema
+}
+
+func parseSchema(s string) (*schema, error) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft7
+
+	if err := c.AddResource(schemaURL, strings.NewReader(s)); err != nil {
+		return nil, err
+	}
+	sc, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	return &schema{Schema: sc}, nil
+}
+
+// validate validates the value (decoded from YAML or JSON) against the schema.
+// The returned errors are sorted and formatted as "<path>: <message>".
+func (s *schema) validate(value any) []string {
+	v, err := toJSONValue(value)
+	if err != nil {
+		return []string{fmt.Sprintf("config: %v", err)}
+	}
+
+	err = s.Validate(v)
+	if err == nil {
+		return nil
+	}
+
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return []string{fmt.Sprintf("config: %v", err)}
+	}
+
+	var errs []string
+	collectErrors(ve, &errs)
+	sort.Strings(errs)
+
+	return errs
+}
+
+// collectErrors collects the leaf validation errors, they are the ones that describe the actual problem.
+func collectErrors(ve *jsonschema.ValidationError, errs *[]string) {
+	if len(ve.Causes) == 0 {
+		*errs = append(*errs, fmt.Sprintf("%s: %s", instancePath(ve.InstanceLocation), ve.Message))
+		return
+	}
+	for _, cause := range ve.Causes {
+		collectErrors(cause, errs)
+	}
+}
+
+// instancePath converts the JSON pointer to the dotted path, e.g. "/auth/tags/2" => "auth.tags[2]".
+func instancePath(location string) string {
+	var sb strings.Builder
+
+	for _, token := range strings.Split(strings.TrimPrefix(location, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if _, err := strconv.Atoi(token); err == nil && sb.Len() > 0 {
+			sb.WriteString("[" + token + "]")
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(token)
+	}
+
+	if sb.Len() == 0 {
+		return "config"
+	}
+	return sb.String()
+}
+
+// toJSONValue converts the value to the form produced by encoding/json, the form the validator works with.
+func toJSONValue(value any) (any, error) {
+	bs, err := json.Marshal(normalize(value))
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// normalize converts the YAML decoded maps (map[any]any) to map[string]any.
+func normalize(v any) any {
+	switch x := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(x))
+		for k, v := range x {
+			m[fmt.Sprint(k)] = normalize(v)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, v := range x {
+			m[k] = normalize(v)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, v := range x {
+			s[i] = normalize(v)
+		}
+		return s
+	}
+	return v
+}
+
+// ValidateSchema validates the value (decoded from YAML or JSON) against the JSON schema.
+func ValidateSchema(schemaJSON string, value any) ([]string, error) {
+	sc, err := parseSchema(schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+	return sc.validate(value), nil
+}
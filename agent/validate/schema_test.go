@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "url": {"type": "string", "pattern": "^https?://"},
+    "timeout": {"type": ["string", "integer"]},
+    "method": {"enum": ["GET", "POST"]},
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535},
+    "headers": {"type": "object", "additionalProperties": {"type": "string"}},
+    "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+    "auth": {
+      "type": "object",
+      "properties": {"username": {"type": "string"}, "password": {"type": "string"}},
+      "additionalProperties": false,
+      "required": ["username"]
+    }
+  },
+  "required": ["name", "url"]
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	tests := map[string]struct {
+		config   map[any]any
+		wantErrs []string
+	}{
+		"valid": {
+			config: map[any]any{
+				"name":    "job",
+				"url":     "http://127.0.0.1",
+				"timeout": 1,
+				"method":  "GET",
+				"port":    80,
+				"headers": map[any]any{"X-Key": "value"},
+				"tags":    []any{"a", "b"},
+				"auth":    map[any]any{"username": "user"},
+			},
+		},
+		"missing required": {
+			config:   map[any]any{"name": "job"},
+			wantErrs: []string{"config: missing properties: 'url'"},
+		},
+		"wrong types": {
+			config: map[any]any{
+				"name":    "job",
+				"url":     "http://127.0.0.1",
+				"timeout": true,
+				"port":    "80",
+				"headers": map[any]any{"X-Key": 1},
+			},
+			wantErrs: []string{
+				"headers.X-Key: expected string, but got number",
+				"port: expected integer, but got string",
+				"timeout: expected string or integer, but got boolean",
+			},
+		},
+		"constraints": {
+			config: map[any]any{
+				"name":   "",
+				"url":    "ftp://127.0.0.1",
+				"method": "PUT",
+				"port":   0,
+				"tags":   []any{"a", "b", 1},
+			},
+			wantErrs: []string{
+				`method: value must be one of "GET", "POST"`,
+				"name: length must be >= 1, but got 0",
+				"port: must be >= 1 but found 0",
+				"tags: maximum 2 items required, but found 3 items",
+				"tags[2]: expected string, but got number",
+				"url: does not match pattern '^https?://'",
+			},
+		},
+		"nested object": {
+			config: map[any]any{
+				"name": "job",
+				"url":  "http://127.0.0.1",
+				"auth": map[any]any{"password": "pass", "token": "token"},
+			},
+			wantErrs: []string{
+				"auth: additionalProperties 'token' not allowed",
+				"auth: missing properties: 'username'",
+			},
+		},
+	}
+
+	sc, err := parseSchema(testSchema)
+	require.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := sc.validate(test.config)
+
+			assert.Equal(t, test.wantErrs, errs)
+		})
+	}
+}
+
+func TestSchema_Validate_Combinators(t *testing.T) {
+	tests := map[string]struct {
+		schema  string
+		value   any
+		wantErr bool
+	}{
+		"oneOf matches one": {
+			schema: `{"oneOf": [{"type": "string"}, {"type": "integer"}]}`,
+			value:  1,
+		},
+		"oneOf matches none": {
+			schema:  `{"oneOf": [{"type": "string"}, {"type": "integer"}]}`,
+			value:   true,
+			wantErr: true,
+		},
+		"oneOf matches both": {
+			schema:  `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`,
+			value:   1,
+			wantErr: true,
+		},
+		"anyOf matches": {
+			schema: `{"anyOf": [{"type": "number"}, {"type": "integer"}]}`,
+			value:  1,
+		},
+		"anyOf matches none": {
+			schema:  `{"anyOf": [{"type": "string"}, {"type": "boolean"}]}`,
+			value:   1,
+			wantErr: true,
+		},
+		"allOf fails": {
+			schema:  `{"allOf": [{"type": "integer"}, {"minimum": 10}]}`,
+			value:   1,
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc, err := parseSchema(test.schema)
+			require.NoError(t, err)
+
+			if test.wantErr {
+				assert.NotEmpty(t, sc.validate(test.value))
+			} else {
+				assert.Empty(t, sc.validate(test.value))
+			}
+		})
+	}
+}
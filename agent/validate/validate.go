@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"gopkg.in/yaml.v2"
+)
+
+// New creates a new Validator.
+func New(modules module.Registry) *Validator {
+	return &Validator{
+		Modules: modules,
+		schemas: make(map[string]*schema),
+	}
+}
+
+// Validator validates jobs configurations against the modules job configuration schemas
+// and reports keys that are unknown to the modules.
+type Validator struct {
+	Modules module.Registry
+
+	schemas map[string]*schema
+}
+
+// ValidateJob returns all problems found in the job configuration.
+func (v *Validator) ValidateJob(cfg confgroup.Config) []string {
+	creator, ok := v.Modules[cfg.Module()]
+	if !ok {
+		return []string{fmt.Sprintf("unknown module '%s'", cfg.Module())}
+	}
+
+	var errs []string
+
+	errs = append(errs, validateJobKeys(cfg)...)
+
+	sc, err := v.schema(cfg.Module(), creator)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("couldn't parse '%s' module job configuration schema: %v", cfg.Module(), err))
+	} else if sc != nil {
+		errs = append(errs, sc.validate(schemaConfig(cfg))...)
+	}
+
+	errs = append(errs, validateModuleConfig(creator, cfg)...)
+
+	return errs
+}
+
+func (v *Validator) schema(name string, creator module.Creator) (*schema, error) {
+	if creator.JobConfigSchema == "" {
+		return nil, nil
+	}
+	if sc, ok := v.schemas[name]; ok {
+		return sc, nil
+	}
+	sc, err := parseSchema(creator.JobConfigSchema)
+	if err != nil {
+		return nil, err
+	}
+	v.schemas[name] = sc
+	return sc, nil
+}
+
+func validateJobKeys(cfg confgroup.Config) []string {
+	var errs []string
+
+	if v, ok := cfg["retry_policy"]; ok {
+		var policy module.RetryPolicy
+		if err := strictUnmarshal(v, &policy); err != nil {
+			errs = append(errs, prefixAll("retry_policy: ", yamlErrors(err))...)
+		} else if err := policy.Validate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if v, ok := cfg["schedule"]; ok {
+		if s, ok := v.(string); !ok {
+			errs = append(errs, fmt.Sprintf("schedule: expected string, got '%v'", v))
+		} else if _, err := module.ParseSchedule(s); err != nil {
+			errs = append(errs, fmt.Sprintf("schedule: %v", err))
+		}
+	}
+
+	if v, ok := cfg["chart_rules"]; ok {
+		var rules module.ChartRules
+		if err := strictUnmarshal(v, &rules); err != nil {
+			errs = append(errs, prefixAll("chart_rules: ", yamlErrors(err))...)
+		} else if err := rules.Init(); err != nil {
+			errs = append(errs, fmt.Sprintf("chart_rules: %v", err))
+		}
+	}
+
+	if v, ok := cfg["derived_charts"]; ok {
+		var charts module.DerivedCharts
+		if err := strictUnmarshal(v, &charts); err != nil {
+			errs = append(errs, prefixAll("derived_charts: ", yamlErrors(err))...)
+		} else if err := charts.Init(); err != nil {
+			errs = append(errs, fmt.Sprintf("derived_charts: %v", err))
+		}
+	}
+
+	if v, ok := cfg["limits"]; ok {
+		var limits module.Limits
+		if err := strictUnmarshal(v, &limits); err != nil {
+			errs = append(errs, prefixAll("limits: ", yamlErrors(err))...)
+		} else if err := limits.Validate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if _, err := cfg.CollectTimeout(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	for _, key := range confgroup.IntJobKeys {
+		if v, ok := cfg[key]; ok {
+			if _, ok := v.(int); !ok {
+				errs = append(errs, fmt.Sprintf("%s: expected integer, got '%v'", key, v))
+			}
+		}
+	}
+
+	if v, ok := cfg["health_charts"]; ok {
+		if _, ok := v.(bool); !ok {
+			errs = append(errs, fmt.Sprintf("health_charts: expected boolean, got '%v'", v))
+		}
+	}
+
+	if v, ok := cfg["obsolete_after"].(int); ok && v < 0 {
+		errs = append(errs, fmt.Sprintf("obsolete_after: must be >= 0, got %d", v))
+	}
+
+	return errs
+}
+
+// validateModuleConfig unmarshals the job configuration into a new module instance in strict mode,
+// it reports unknown keys and type errors that are silently ignored by the job manager.
+func validateModuleConfig(creator module.Creator, cfg confgroup.Config) []string {
+	if creator.Create == nil {
+		return nil
+	}
+
+	modCfg := make(map[string]any)
+	for k, v := range cfg {
+		if k == "name" || confgroup.IsJobKey(k) || isInternalKey(k) {
+			continue
+		}
+		modCfg[k] = v
+	}
+
+	if err := strictUnmarshal(modCfg, creator.Create()); err != nil {
+		return yamlErrors(err)
+	}
+	return nil
+}
+
+func schemaConfig(cfg confgroup.Config) map[string]any {
+	m := make(map[string]any)
+	for k, v := range cfg {
+		if confgroup.IsJobKey(k) || isInternalKey(k) {
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+func strictUnmarshal(in any, out any) error {
+	bs, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(bs, out)
+}
+
+var (
+	reYAMLLine     = regexp.MustCompile(`^line \d+: `)
+	reYAMLNotFound = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+func yamlErrors(err error) []string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []string{err.Error()}
+	}
+
+	var errs []string
+	for _, e := range typeErr.Errors {
+		// line numbers refer to the re-marshalled config, they are useless
+		e = reYAMLLine.ReplaceAllString(e, "")
+		if m := reYAMLNotFound.FindStringSubmatch(e); m != nil {
+			e = fmt.Sprintf("unknown key '%s'", m[1])
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+func prefixAll(prefix string, values []string) []string {
+	for i, v := range values {
+		values[i] = prefix + v
+	}
+	return values
+}
+
+func isInternalKey(key string) bool {
+	return strings.HasPrefix(key, "__") && strings.HasSuffix(key, "__")
+}
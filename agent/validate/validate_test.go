@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/netdata/go.d.plugin/agent/confgroup"
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testModule struct {
+	module.Base `yaml:",inline"`
+	URL         string `yaml:"url"`
+	Timeout     int    `yaml:"timeout"`
+}
+
+func (testModule) Init() bool                { return true }
+func (testModule) Check() bool               { return true }
+func (testModule) Charts() *module.Charts    { return nil }
+func (testModule) Collect() map[string]int64 { return nil }
+func (testModule) Cleanup()                  {}
+
+func TestValidator_ValidateJob(t *testing.T) {
+	reg := module.Registry{
+		"module": module.Creator{
+			JobConfigSchema: `{"type": "object", "properties": {"url": {"type": "string"}}, "required": ["url"]}`,
+			Create:          func() module.Module { return &testModule{} },
+		},
+		"noschema": module.Creator{
+			Create: func() module.Module { return &testModule{} },
+		},
+	}
+
+	tests := map[string]struct {
+		config   confgroup.Config
+		wantErrs []string
+	}{
+		"valid": {
+			config: confgroup.Config{
+				"module":          "module",
+				"name":            "job",
+				"url":             "http://127.0.0.1",
+				"update_every":    1,
+				"collect_timeout": 2.5,
+				"schedule":        "@hourly",
+				"retry_policy":    map[any]any{"backoff": "exponential", "max_penalty": 60},
+				"labels":          map[any]any{"key": "value"},
+				"__source__":      "/etc/netdata/go.d/module.conf",
+			},
+		},
+		"unknown module": {
+			config:   confgroup.Config{"module": "unknown", "name": "job"},
+			wantErrs: []string{"unknown module 'unknown'"},
+		},
+		"schema error": {
+			config:   confgroup.Config{"module": "module", "name": "job"},
+			wantErrs: []string{"config: missing properties: 'url'"},
+		},
+		"unknown key": {
+			config:   confgroup.Config{"module": "noschema", "name": "job", "ur": "http://127.0.0.1"},
+			wantErrs: []string{"unknown key 'ur'"},
+		},
+		"module type error": {
+			config:   confgroup.Config{"module": "noschema", "name": "job", "timeout": "1s"},
+			wantErrs: []string{"cannot unmarshal !!str `1s` into int"},
+		},
+		"job keys errors": {
+			config: confgroup.Config{
+				"module":          "noschema",
+				"name":            "job",
+				"update_every":    "1",
+				"schedule":        "* * *",
+				"retry_policy":    map[any]any{"backof": "linear"},
+				"health_charts":   "yes please",
+				"collect_timeout": "5x",
+			},
+			wantErrs: []string{
+				"retry_policy: unknown key 'backof'",
+				"schedule: invalid schedule '* * *': expected 5 fields (minute hour day-of-month month day-of-week), got 3",
+				"collect_timeout: invalid duration '5x'",
+				"update_every: expected integer, got '1'",
+				"health_charts: expected boolean, got 'yes please'",
+			},
+		},
+		"valid chart rules, derived charts and limits": {
+			config: confgroup.Config{
+				"module":         "noschema",
+				"name":           "job",
+				"chart_rules":    []any{map[any]any{"chart": "* dropped", "drop": true}},
+				"derived_charts": []any{map[any]any{"id": "ratio", "units": "ratio", "dimensions": []any{map[any]any{"name": "ratio", "expr": "a / b"}}}},
+				"limits":         map[any]any{"max_charts": 10, "max_dims": 100},
+				"obsolete_after": 5,
+				"health_charts":  true,
+			},
+		},
+		"invalid chart rules, derived charts and limits": {
+			config: confgroup.Config{
+				"module":         "noschema",
+				"name":           "job",
+				"chart_rules":    []any{map[any]any{"chart": "* dropped"}},
+				"derived_charts": []any{map[any]any{"id": "ratio"}},
+				"limits":         map[any]any{"max_chart": 10},
+				"obsolete_after": -1,
+			},
+			wantErrs: []string{
+				"chart_rules: chart rule #1: no action defined (expected 'drop', 'hide', 'rename' or 'labels')",
+				"derived_charts: derived chart 'ratio': 'units' not set",
+				"limits: unknown key 'max_chart'",
+				"obsolete_after: must be >= 0, got -1",
+			},
+		},
+		"invalid retry policy": {
+			config:   confgroup.Config{"module": "noschema", "name": "job", "retry_policy": map[any]any{"backoff": "fibonacci"}},
+			wantErrs: []string{"retry policy: unknown backoff 'fibonacci' (expected 'linear' or 'exponential')"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := New(reg).ValidateJob(test.config)
+
+			assert.Equal(t, test.wantErrs, errs)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package agent
+
+import (
+	"bytes"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/netdata/go.d.plugin/pkg/multipath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgent_Validate(t *testing.T) {
+	tests := map[string]struct {
+		confDir  string
+		modules  []string
+		wantOK   bool
+		wantErrs []string
+	}{
+		"valid": {
+			confDir: "testdata/validate/valid",
+			modules: []string{"module1"},
+			wantOK:  true,
+		},
+		"invalid": {
+			confDir: "testdata/validate/invalid",
+			modules: []string{"module1", "module2"},
+			wantOK:  false,
+			wantErrs: []string{
+				"unknown module 'module3'",
+				"unknown key 'job'",
+				"module2[job1]: unknown key 'url'",
+				"module2[job2]: schedule: invalid schedule '* *'",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := New(Config{
+				Name:           "go.d",
+				ConfDir:        multipath.New(test.confDir),
+				ModulesConfDir: multipath.New(filepath.Join(test.confDir, "go.d")),
+			})
+			var mux sync.Mutex
+			a.ModuleRegistry = prepareRegistry(&mux, map[string]int{}, test.modules...)
+
+			var buf bytes.Buffer
+			ok := a.Validate(&buf)
+
+			assert.Equal(t, test.wantOK, ok, buf.String())
+			for _, e := range test.wantErrs {
+				assert.Contains(t, buf.String(), e)
+			}
+		})
+	}
+}
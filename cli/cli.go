@@ -15,6 +15,13 @@ type Option struct {
 	ConfDir     []string `short:"c" long:"config-dir" description:"config dir to read"`
 	WatchPath   []string `short:"w" long:"watch-path" description:"config path to watch"`
 	Debug       bool     `short:"d" long:"debug" description:"debug mode"`
+	Prometheus  string   `short:"p" long:"prometheus" description:"serve collected metrics in Prometheus format on the given address (e.g. ':9911') instead of writing them to stdout"`
+	Validate    bool     `long:"validate" description:"validate the plugin, modules and sd configuration files and exit"`
+	Record      string   `long:"record" description:"record the modules upstream I/O (HTTP responses, socket exchanges, commands output) to the given bundle directory"`
+	Replay      string   `long:"replay" description:"run the modules against the upstream I/O recorded in the given bundle directory instead of the real upstreams"`
+	Once        bool     `long:"once" description:"run the module job once (Init, Check, Collect), print the charts with the collected values and exit"`
+	Job         string   `long:"job" description:"job name to run with '--once', can be omitted if the module has only one job"`
+	Format      string   `long:"format" description:"'--once' output format" choice:"table" choice:"json" default:"table"`
 	Version     bool     `short:"v" long:"version" description:"display the version and exit"`
 }
 
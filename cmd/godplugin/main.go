@@ -13,9 +13,11 @@ import (
 
 	"github.com/netdata/go.d.plugin/agent"
 	"github.com/netdata/go.d.plugin/agent/executable"
+	"github.com/netdata/go.d.plugin/agent/secrets"
 	"github.com/netdata/go.d.plugin/cli"
 	"github.com/netdata/go.d.plugin/logger"
 	"github.com/netdata/go.d.plugin/pkg/multipath"
+	"github.com/netdata/go.d.plugin/pkg/recorder"
 
 	"github.com/jessevdk/go-flags"
 	"golang.org/x/net/http/httpproxy"
@@ -97,6 +99,20 @@ func stateFile() string {
 	return filepath.Join(varLibDir, "god-jobs-statuses.json")
 }
 
+func modulesStateFile() string {
+	if varLibDir == "" {
+		return ""
+	}
+	return filepath.Join(varLibDir, "god-modules-state.json")
+}
+
+func dyncfgDir() string {
+	if varLibDir == "" {
+		return ""
+	}
+	return filepath.Join(varLibDir, "god-dyncfg")
+}
+
 func init() {
 	// https://github.com/netdata/netdata/issues/8949#issuecomment-638294959
 	if v := os.Getenv("TZ"); strings.HasPrefix(v, ":") {
@@ -118,6 +134,8 @@ func main() {
 
 	if opts.Debug {
 		logger.Level.Set(slog.LevelDebug)
+	} else if opts.Validate || opts.Once {
+		logger.Level.Set(slog.LevelWarn)
 	}
 
 	a := agent.New(agent.Config{
@@ -127,11 +145,33 @@ func main() {
 		ModulesSDConfPath: watchPaths(opts),
 		VnodesConfDir:     confDir(opts),
 		StateFile:         stateFile(),
+		ModulesStateFile:  modulesStateFile(),
+		DyncfgDir:         dyncfgDir(),
 		LockDir:           lockDir,
 		RunModule:         opts.Module,
 		MinUpdateEvery:    opts.UpdateEvery,
+		PrometheusAddr:    opts.Prometheus,
 	})
 
+	if opts.Validate {
+		if !a.Validate(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := enableRecorder(opts); err != nil {
+		a.Error(err)
+		os.Exit(1)
+	}
+
+	if opts.Once {
+		if !a.RunOnce(os.Stdout, opts.Job, opts.Format) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	a.Debugf("plugin: name=%s, version=%s", a.Name, version)
 	if u, err := user.Current(); err == nil {
 		a.Debugf("current user: name=%s, uid=%s", u.Username, u.Uid)
@@ -143,6 +183,20 @@ func main() {
 	a.Run()
 }
 
+func enableRecorder(opts *cli.Option) error {
+	switch {
+	case opts.Record != "" && opts.Replay != "":
+		return errors.New("'--record' and '--replay' can not be used together")
+	case opts.Record != "":
+		recorder.Default.Redact = secrets.Redact
+		return recorder.Default.Enable(recorder.Record, opts.Record)
+	case opts.Replay != "":
+		recorder.Default.Redact = secrets.Redact
+		return recorder.Default.Enable(recorder.Replay, opts.Replay)
+	}
+	return nil
+}
+
 func parseCLI() *cli.Option {
 	opt, err := cli.Parse(os.Args)
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 	"syscall"
 
 	"github.com/netdata/go.d.plugin/agent/executable"
+	"github.com/netdata/go.d.plugin/agent/secrets"
 
 	"github.com/mattn/go-isatty"
 )
@@ -32,8 +33,9 @@ func New() *Logger {
 }
 
 type Logger struct {
-	muted atomic.Bool
-	sl    *slog.Logger
+	muted   atomic.Bool
+	lastErr atomic.Pointer[string]
+	sl      *slog.Logger
 }
 
 func (l *Logger) Error(a ...any)                   { l.log(slog.LevelError, fmt.Sprint(a...)) }
@@ -47,6 +49,17 @@ func (l *Logger) Debugf(format string, a ...any)   { l.log(slog.LevelDebug, fmt.
 func (l *Logger) Mute()                            { l.mute(true) }
 func (l *Logger) Unmute()                          { l.mute(false) }
 
+// LastError returns the last message logged at the error level, muted messages included.
+func (l *Logger) LastError() string {
+	if l.isNil() {
+		return ""
+	}
+	if v := l.lastErr.Load(); v != nil {
+		return *v
+	}
+	return ""
+}
+
 func (l *Logger) With(args ...any) *Logger {
 	if l.isNil() {
 		return &Logger{sl: New().sl.With(args...)}
@@ -59,11 +72,18 @@ func (l *Logger) With(args ...any) *Logger {
 }
 
 func (l *Logger) log(level slog.Level, msg string) {
+	// the module errors can contain the resolved secrets (e.g. a DSN)
+	msg = secrets.Redact(msg)
+
 	if l.isNil() {
 		nilLogger.sl.Log(context.Background(), level, msg)
 		return
 	}
 
+	if level >= slog.LevelError {
+		l.lastErr.Store(&msg)
+	}
+
 	if !l.muted.Load() {
 		l.sl.Log(context.Background(), level, msg)
 	}
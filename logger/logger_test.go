@@ -1,9 +1,14 @@
 package logger
 
 import (
+	"bytes"
+	"log/slog"
 	"testing"
 
+	"github.com/netdata/go.d.plugin/agent/secrets"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -19,3 +24,34 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestLogger_LastError(t *testing.T) {
+	l := New()
+	l.Mute()
+
+	assert.Equal(t, "", l.LastError())
+
+	l.Info("info")
+	l.Errorf("error %d", 1)
+	l.Warning("warning")
+	assert.Equal(t, "error 1", l.LastError())
+
+	var nilLogger *Logger
+	assert.Equal(t, "", nilLogger.LastError())
+}
+
+func TestLogger_RedactsSecrets(t *testing.T) {
+	t.Setenv("TEST_LOGGER_PASSWORD", "logger-secret")
+	v, err := secrets.Resolve("${env:TEST_LOGGER_PASSWORD}")
+	require.NoError(t, err)
+	require.Equal(t, "logger-secret", v)
+
+	var buf bytes.Buffer
+	l := &Logger{sl: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	l.Errorf("connect to 'netdata:%s@tcp(127.0.0.1:3306)/': access denied", v)
+
+	assert.NotContains(t, buf.String(), "logger-secret")
+	assert.Contains(t, buf.String(), "netdata:${env:TEST_LOGGER_PASSWORD}@tcp")
+	assert.NotContains(t, l.LastError(), "logger-secret")
+}
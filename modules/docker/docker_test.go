@@ -826,3 +826,26 @@ func (m *mockClient) Close() error {
 	m.closeCalled = true
 	return nil
 }
+
+func TestDocker_Functions_Containers(t *testing.T) {
+	d := New()
+	m := &mockClient{}
+	d.newClient = prepareNewClientFunc(m)
+
+	fn, ok := d.Functions()["containers"]
+	require.True(t, ok)
+
+	tbl, err := fn.Handler(context.Background(), nil)
+	require.NoError(t, err)
+
+	assert.True(t, m.closeCalled)
+	require.Len(t, tbl.Rows, 16)
+	// sorted by name
+	assert.Equal(t, []any{"", "container1", "example/example:v1", "created", types.Healthy, "", int64(0)}, tbl.Rows[0])
+	assert.Equal(t, "container10", tbl.Rows[1][1])
+	assert.Equal(t, types.Unhealthy, tbl.Rows[1][4])
+
+	d.newClient = prepareNewClientFunc(&mockClient{errOnContainerList: true})
+	_, err = fn.Handler(context.Background(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package docker
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func (d *Docker) Functions() map[string]module.Function {
+	return map[string]module.Function{
+		"containers": {
+			Help:    "List of Docker containers with their state and health status.",
+			Handler: d.funcContainers,
+		},
+	}
+}
+
+func (d *Docker) funcContainers(ctx context.Context, _ []string) (*module.FunctionTable, error) {
+	client, err := d.newClient(d.Config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = client.Close() }()
+
+	client.NegotiateAPIVersion(ctx)
+
+	tbl := &module.FunctionTable{
+		Help: "Docker containers",
+		Columns: []module.FunctionColumn{
+			{ID: "id", Name: "ID", Type: module.ColumnString, UniqueKey: true, Hidden: true},
+			{ID: "name", Name: "Name", Type: module.ColumnString},
+			{ID: "image", Name: "Image", Type: module.ColumnString},
+			{ID: "state", Name: "State", Type: module.ColumnString},
+			{ID: "health", Name: "Health", Type: module.ColumnString},
+			{ID: "status", Name: "Status", Type: module.ColumnString},
+			{ID: "created", Name: "Created", Type: module.ColumnTimestamp},
+		},
+	}
+
+	type row struct {
+		cntr   types.Container
+		health string
+	}
+	var rows []row
+
+	for _, status := range containerHealthStatuses {
+		containers, err := client.ContainerList(ctx, types.ContainerListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.KeyValuePair{Key: "health", Value: status}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, cntr := range containers {
+			rows = append(rows, row{cntr: cntr, health: status})
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return containerName(rows[i].cntr) < containerName(rows[j].cntr) })
+
+	for _, r := range rows {
+		tbl.AddRow(r.cntr.ID, containerName(r.cntr), r.cntr.Image, r.cntr.State, r.health, r.cntr.Status, r.cntr.Created)
+	}
+
+	return tbl, nil
+}
+
+func containerName(cntr types.Container) string {
+	if len(cntr.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(cntr.Names[0], "/")
+}
@@ -90,7 +90,7 @@ func (m *MySQL) collect() (map[string]int64, error) {
 }
 
 func (m *MySQL) openConnection() error {
-	db, err := sql.Open("mysql", m.DSN)
+	db, err := m.sqlOpen("mysql", m.DSN)
 	if err != nil {
 		return fmt.Errorf("error on opening a connection with the mysql database [%s]: %v", m.safeDSN, err)
 	}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+// Performance Schema statement digests
+// (MySQL) https://dev.mysql.com/doc/refman/8.0/en/performance-schema-statement-summary-tables.html
+// (MariaDB) https://mariadb.com/kb/en/performance-schema-events_statements_summary_by_digest-table/
+// Timer values are in picoseconds.
+const (
+	queryTopQueries = `
+SELECT 
+  DIGEST, 
+  DIGEST_TEXT, 
+  SCHEMA_NAME, 
+  COUNT_STAR, 
+  ROUND(SUM_TIMER_WAIT / 1000000000, 3), 
+  ROUND(AVG_TIMER_WAIT / 1000000000, 3), 
+  ROUND(MAX_TIMER_WAIT / 1000000000, 3), 
+  SUM_ROWS_SENT, 
+  SUM_ROWS_EXAMINED, 
+  SUM_ERRORS 
+FROM 
+  performance_schema.events_statements_summary_by_digest 
+WHERE 
+  DIGEST_TEXT IS NOT NULL 
+ORDER BY 
+  SUM_TIMER_WAIT DESC 
+LIMIT 500;`
+)
+
+func (m *MySQL) Functions() map[string]module.Function {
+	return map[string]module.Function{
+		"top-queries": {
+			Help:    "Top SQL queries by total execution time (requires performance_schema).",
+			Handler: m.funcTopQueries,
+		},
+	}
+}
+
+func (m *MySQL) funcTopQueries(ctx context.Context, _ []string) (*module.FunctionTable, error) {
+	db, err := m.sqlOpen("mysql", m.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("error on opening a connection with the mysql database [%s]: %v", m.safeDSN, err)
+	}
+	defer func() { _ = db.Close() }()
+
+	m.Debugf("executing query: '%s'", queryTopQueries)
+
+	rows, err := db.QueryContext(ctx, queryTopQueries)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tbl := &module.FunctionTable{
+		Help: "Top SQL queries from performance_schema.events_statements_summary_by_digest",
+		Columns: []module.FunctionColumn{
+			{ID: "digest", Name: "Digest", Type: module.ColumnString, UniqueKey: true, Hidden: true},
+			{ID: "query", Name: "Query", Type: module.ColumnString},
+			{ID: "schema", Name: "Schema", Type: module.ColumnString},
+			{ID: "calls", Name: "Calls", Type: module.ColumnInteger},
+			{ID: "total_time", Name: "Total Time", Type: module.ColumnDuration, Units: "milliseconds"},
+			{ID: "avg_time", Name: "Avg Time", Type: module.ColumnDuration, Units: "milliseconds"},
+			{ID: "max_time", Name: "Max Time", Type: module.ColumnDuration, Units: "milliseconds"},
+			{ID: "rows_sent", Name: "Rows Sent", Type: module.ColumnInteger},
+			{ID: "rows_examined", Name: "Rows Examined", Type: module.ColumnInteger},
+			{ID: "errors", Name: "Errors", Type: module.ColumnInteger},
+		},
+	}
+
+	for rows.Next() {
+		var (
+			digest, query, schema                 sql.NullString
+			calls, rowsSent, rowsExamined, errors int64
+			totalTime, avgTime, maxTime           float64
+		)
+		if err := rows.Scan(&digest, &query, &schema, &calls, &totalTime, &avgTime, &maxTime, &rowsSent, &rowsExamined, &errors); err != nil {
+			return nil, err
+		}
+		tbl.AddRow(digest.String, query.String, schema.String, calls, totalTime, avgTime, maxTime, rowsSent, rowsExamined, errors)
+	}
+
+	return tbl, rows.Err()
+}
@@ -34,6 +34,7 @@ func New() *MySQL {
 			Timeout: web.Duration{Duration: time.Second},
 		},
 
+		sqlOpen:                        sql.Open,
 		charts:                         baseCharts.Copy(),
 		addInnoDBOSLogOnce:             &sync.Once{},
 		addBinlogOnce:                  &sync.Once{},
@@ -63,6 +64,7 @@ type MySQL struct {
 	Config `yaml:",inline"`
 
 	db        *sql.DB
+	sqlOpen   func(driverName, dsn string) (*sql.DB, error)
 	safeDSN   string
 	version   *semver.Version
 	isMariaDB bool
@@ -5,6 +5,8 @@ package mysql
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
@@ -1620,6 +1622,61 @@ func TestMySQL_Collect(t *testing.T) {
 	}
 }
 
+func TestMySQL_Functions_TopQueries(t *testing.T) {
+	tests := map[string]struct {
+		prepareMock func(m sqlmock.Sqlmock)
+		wantRows    [][]any
+		wantErr     bool
+	}{
+		"success": {
+			prepareMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(queryTopQueries).WillReturnRows(
+					sqlmock.NewRows([]string{"DIGEST", "DIGEST_TEXT", "SCHEMA_NAME", "COUNT_STAR", "SUM", "AVG", "MAX",
+						"SUM_ROWS_SENT", "SUM_ROWS_EXAMINED", "SUM_ERRORS"}).
+						AddRow("d1", "SELECT * FROM `t1`", "db1", 10, "150.500", "15.050", "40.000", 100, 1000, 0).
+						AddRow("d2", "SELECT ?", nil, 3, "1.000", "0.333", "0.500", 3, 0, 1),
+				).RowsWillBeClosed()
+				m.ExpectClose()
+			},
+			wantRows: [][]any{
+				{"d1", "SELECT * FROM `t1`", "db1", int64(10), 150.5, 15.05, 40.0, int64(100), int64(1000), int64(0)},
+				{"d2", "SELECT ?", "", int64(3), 1.0, 0.333, 0.5, int64(3), int64(0), int64(1)},
+			},
+		},
+		"query error": {
+			prepareMock: func(m sqlmock.Sqlmock) {
+				mockExpectErr(m, queryTopQueries)
+				m.ExpectClose()
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(
+				sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+			)
+			require.NoError(t, err)
+			my := New()
+			my.sqlOpen = func(string, string) (*sql.DB, error) { return db, nil }
+			require.True(t, my.Init())
+
+			test.prepareMock(mock)
+
+			tbl, err := my.Functions()["top-queries"].Handler(context.Background(), nil)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantRows, tbl.Rows)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func ensureCollectedHasAllChartsDimsVarsIDs(t *testing.T, mySQL *MySQL, collected map[string]int64) {
 	for _, chart := range *mySQL.Charts() {
 		if mySQL.isMariaDB {
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/module/moduletest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -85,6 +86,22 @@ func TestNginx_Collect(t *testing.T) {
 	assert.Equal(t, expected, job.Collect())
 }
 
+func TestNginx_Golden(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(testStatusData)
+			}))
+	defer ts.Close()
+
+	moduletest.Run(t, moduletest.Case{
+		Module:     New(),
+		Config:     "url: " + ts.URL,
+		ModuleName: "nginx",
+		Ticks:      2,
+	})
+}
+
 func TestNginx_CollectTengine(t *testing.T) {
 	ts := httptest.NewServer(
 		http.HandlerFunc(
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/netdata/go.d.plugin/logger"
+	"github.com/netdata/go.d.plugin/pkg/recorder"
 )
 
 func newNvidiaSMIExec(path string, cfg Config, log *logger.Logger) (*nvidiaSMIExec, error) {
@@ -34,7 +35,7 @@ func (e *nvidiaSMIExec) queryGPUInfoXML() ([]byte, error) {
 	cmd := exec.CommandContext(ctx, e.binPath, "-q", "-x")
 
 	e.Debugf("executing '%s'", cmd)
-	bs, err := cmd.Output()
+	bs, err := recorder.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("error on '%s': %v", cmd, err)
 	}
@@ -54,7 +55,7 @@ func (e *nvidiaSMIExec) queryGPUInfoCSV(properties []string) ([]byte, error) {
 
 	e.Debugf("executing '%s'", cmd)
 
-	bs, err := cmd.Output()
+	bs, err := recorder.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("error on '%s': %v", cmd, err)
 	}
@@ -69,7 +70,7 @@ func (e *nvidiaSMIExec) queryHelpQueryGPU() ([]byte, error) {
 	cmd := exec.CommandContext(ctx, e.binPath, "--help-query-gpu")
 
 	e.Debugf("executing '%s'", cmd)
-	bs, err := cmd.Output()
+	bs, err := recorder.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("error on '%s': %v", cmd, err)
 	}
@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"os/exec"
 	"time"
+
+	"github.com/netdata/go.d.plugin/pkg/recorder"
 )
 
 type nvmeDeviceList struct {
@@ -108,15 +110,15 @@ func (n *nvmeCLIExec) execute(arg ...string) ([]byte, error) {
 
 	if n.sudoPath != "" {
 		args := append([]string{"-n", n.nvmePath}, arg...)
-		return exec.CommandContext(ctx, n.sudoPath, args...).Output()
+		return recorder.Output(exec.CommandContext(ctx, n.sudoPath, args...))
 	}
 
-	return exec.CommandContext(ctx, n.nvmePath, arg...).Output()
+	return recorder.Output(exec.CommandContext(ctx, n.nvmePath, arg...))
 }
 
 func (n *nvmeCLIExec) executeNdSudo(arg ...string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
 	defer cancel()
 
-	return exec.CommandContext(ctx, n.ndsudoPath, arg...).Output()
+	return recorder.Output(exec.CommandContext(ctx, n.ndsudoPath, arg...))
 }
@@ -17,6 +17,7 @@ const (
 	pgVersion94 = 9_04_00
 	pgVersion10 = 10_00_00
 	pgVersion11 = 11_00_00
+	pgVersion13 = 13_00_00
 )
 
 func (p *Postgres) collect() (map[string]int64, error) {
@@ -123,7 +124,7 @@ func (p *Postgres) collect() (map[string]int64, error) {
 }
 
 func (p *Postgres) openPrimaryConnection() (*sql.DB, error) {
-	db, err := sql.Open("pgx", p.DSN)
+	db, err := p.sqlOpen("pgx", p.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("error on opening a connection with the Postgres database [%s]: %v", p.DSN, err)
 	}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+)
+
+// https://www.postgresql.org/docs/current/pgstatstatements.html
+// The *_time columns were renamed to *_exec_time in PostgreSQL 13.
+func queryTopQueries(version int) string {
+	prefix := "exec_"
+	if version < pgVersion13 {
+		prefix = ""
+	}
+	return fmt.Sprintf(`
+SELECT
+    s.queryid,
+    s.query,
+    d.datname,
+    u.usename,
+    s.calls,
+    ROUND(s.total_%[1]stime::numeric, 3),
+    ROUND(s.mean_%[1]stime::numeric, 3),
+    ROUND(s.max_%[1]stime::numeric, 3),
+    s.rows
+FROM
+    pg_stat_statements s
+    LEFT JOIN pg_database d ON d.oid = s.dbid
+    LEFT JOIN pg_user u ON u.usesysid = s.userid
+ORDER BY
+    s.total_%[1]stime DESC
+LIMIT 500;
+`, prefix)
+}
+
+func (p *Postgres) Functions() map[string]module.Function {
+	return map[string]module.Function{
+		"top-queries": {
+			Help:    "Top SQL queries by total execution time (requires the pg_stat_statements extension).",
+			Handler: p.funcTopQueries,
+		},
+	}
+}
+
+func (p *Postgres) funcTopQueries(ctx context.Context, _ []string) (*module.FunctionTable, error) {
+	db, err := p.sqlOpen("pgx", p.DSN)
+	if err != nil {
+		// the DSN may contain the password, the error is sent to Netdata
+		p.Errorf("error on opening a connection with the Postgres database: %v", err)
+		return nil, errors.New("error on opening a connection with the Postgres database")
+	}
+	defer func() { _ = db.Close() }()
+
+	var s string
+	if err := db.QueryRowContext(ctx, queryServerVersion()).Scan(&s); err != nil {
+		return nil, err
+	}
+	version, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := queryTopQueries(version)
+	p.Debugf("executing query: '%s'", q)
+
+	rows, err := db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tbl := &module.FunctionTable{
+		Help: "Top SQL queries from pg_stat_statements",
+		Columns: []module.FunctionColumn{
+			{ID: "queryid", Name: "Query ID", Type: module.ColumnString, UniqueKey: true, Hidden: true},
+			{ID: "query", Name: "Query", Type: module.ColumnString},
+			{ID: "database", Name: "Database", Type: module.ColumnString},
+			{ID: "user", Name: "User", Type: module.ColumnString},
+			{ID: "calls", Name: "Calls", Type: module.ColumnInteger},
+			{ID: "total_time", Name: "Total Time", Type: module.ColumnDuration, Units: "milliseconds"},
+			{ID: "mean_time", Name: "Mean Time", Type: module.ColumnDuration, Units: "milliseconds"},
+			{ID: "max_time", Name: "Max Time", Type: module.ColumnDuration, Units: "milliseconds"},
+			{ID: "rows", Name: "Rows", Type: module.ColumnInteger},
+		},
+	}
+
+	for rows.Next() {
+		var (
+			queryID, query, database, user sql.NullString
+			calls, nrows                   int64
+			totalTime, meanTime, maxTime   float64
+		)
+		if err := rows.Scan(&queryID, &query, &database, &user, &calls, &totalTime, &meanTime, &maxTime, &nrows); err != nil {
+			return nil, err
+		}
+		tbl.AddRow(queryID.String, query.String, database.String, user.String, calls, totalTime, meanTime, maxTime, nrows)
+	}
+
+	return tbl, rows.Err()
+}
@@ -41,6 +41,7 @@ func New() *Postgres {
 		},
 		charts:  baseCharts.Copy(),
 		dbConns: make(map[string]*dbConn),
+		sqlOpen: sql.Open,
 		mx: &pgMetrics{
 			dbs:       make(map[string]*dbMetrics),
 			indexes:   make(map[string]*indexMetrics),
@@ -74,6 +75,7 @@ type (
 
 		db      *sql.DB
 		dbConns map[string]*dbConn
+		sqlOpen func(driverName, dsn string) (*sql.DB, error)
 
 		superUser      *bool
 		pgIsInRecovery *bool
@@ -5,6 +5,8 @@ package postgres
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
@@ -682,6 +684,67 @@ func TestPostgres_Collect(t *testing.T) {
 	}
 }
 
+func TestPostgres_Functions_TopQueries(t *testing.T) {
+	tests := map[string]struct {
+		prepareMock func(t *testing.T, m sqlmock.Sqlmock)
+		wantRows    [][]any
+		wantErr     bool
+	}{
+		"success on v14.4": {
+			prepareMock: func(t *testing.T, m sqlmock.Sqlmock) {
+				mockExpect(t, m, queryServerVersion(), dataV140004ServerVersionNum)
+				m.ExpectQuery(queryTopQueries(140004)).WillReturnRows(
+					sqlmock.NewRows([]string{"queryid", "query", "datname", "usename", "calls", "total", "mean", "max", "rows"}).
+						AddRow("-123", "SELECT $1", "postgres", "postgres", 10, "150.500", "15.050", "40.000", 10).
+						AddRow("456", "UPDATE t SET a = $1", nil, nil, 2, "3.000", "1.500", "2.000", 4),
+				).RowsWillBeClosed()
+				m.ExpectClose()
+			},
+			wantRows: [][]any{
+				{"-123", "SELECT $1", "postgres", "postgres", int64(10), 150.5, 15.05, 40.0, int64(10)},
+				{"456", "UPDATE t SET a = $1", "", "", int64(2), 3.0, 1.5, 2.0, int64(4)},
+			},
+		},
+		"fail when pg_stat_statements query fails": {
+			prepareMock: func(t *testing.T, m sqlmock.Sqlmock) {
+				mockExpect(t, m, queryServerVersion(), dataV140004ServerVersionNum)
+				mockExpectErr(m, queryTopQueries(140004))
+				m.ExpectClose()
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(
+				sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+			)
+			require.NoError(t, err)
+			pg := New()
+			pg.sqlOpen = func(string, string) (*sql.DB, error) { return db, nil }
+			require.True(t, pg.Init())
+
+			test.prepareMock(t, mock)
+
+			tbl, err := pg.Functions()["top-queries"].Handler(context.Background(), nil)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantRows, tbl.Rows)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_queryTopQueries(t *testing.T) {
+	assert.Contains(t, queryTopQueries(pgVersion13), "total_exec_time")
+	assert.NotContains(t, queryTopQueries(pgVersion13-1), "exec_time")
+}
+
 func mockExpect(t *testing.T, mock sqlmock.Sqlmock, query string, rows []byte) {
 	mock.ExpectQuery(query).WillReturnRows(mustMockRows(t, rows)).RowsWillBeClosed()
 }
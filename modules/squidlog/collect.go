@@ -30,6 +30,17 @@ func (s SquidLog) logPanicStackIfAny() {
 	panic(err)
 }
 
+// savePosition saves the log file read position, it is used to resume reading after restart (reload).
+func (s *SquidLog) savePosition() {
+	pos, err := s.file.Position(s.parser)
+	if err != nil {
+		return
+	}
+	if err := s.SaveState(pos); err != nil {
+		s.Debugf("saving log file position: %v", err)
+	}
+}
+
 func (s *SquidLog) collect() (map[string]int64, error) {
 	defer s.logPanicStackIfAny()
 	s.mx.reset()
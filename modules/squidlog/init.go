@@ -21,9 +21,26 @@ func (s *SquidLog) createLogReader() error {
 
 	s.Debugf("created log reader, current file '%s'", reader.CurrentFilename())
 	s.file = reader
+	s.restorePosition()
 	return nil
 }
 
+// restorePosition resumes reading the log file from the position saved before the restart (reload).
+func (s *SquidLog) restorePosition() {
+	var pos logs.Position
+	if !s.LoadState(&pos) {
+		return
+	}
+	ok, err := s.file.Restore(pos)
+	if err != nil {
+		s.Warningf("restoring log file position: %v", err)
+		return
+	}
+	if ok {
+		s.Debugf("resuming reading '%s' from offset %d", pos.Path, pos.Offset)
+	}
+}
+
 func (s *SquidLog) createParser() error {
 	s.Debug("starting parser creating")
 	lastLine, err := logs.ReadLastLine(s.file.CurrentFilename(), 0)
@@ -92,6 +92,8 @@ func (s *SquidLog) Collect() map[string]int64 {
 	mx, err := s.collect()
 	if err != nil {
 		s.Error(err)
+	} else {
+		s.savePosition()
 	}
 
 	if len(mx) == 0 {
@@ -5,12 +5,14 @@ package squidlog
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/netdata/go.d.plugin/pkg/logs"
 	"github.com/netdata/go.d.plugin/pkg/metrics"
 
 	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/module/moduletest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -333,3 +335,37 @@ func prepareSquidCollect(t *testing.T) *SquidLog {
 //	}
 //	return nil
 //}
+
+func TestSquidLog_Collect_ResumesFromSavedPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, append(bytes.TrimSpace(nativeFormatAccessLog), '\n'), 0644))
+	store := &moduletest.StateStore{}
+
+	squid := New()
+	squid.Path = path
+	squid.SetStateStore(store, "squidlog_test")
+	require.True(t, squid.Init())
+	require.True(t, squid.Check())
+	_ = squid.Collect()
+	squid.Cleanup()
+	_, ok := store.LoadState("squidlog_test")
+	require.True(t, ok)
+
+	// lines written while the job is stopped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.Write(append(bytes.TrimSpace(nativeFormatAccessLog), '\n'))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	squid = New()
+	squid.Path = path
+	squid.SetStateStore(store, "squidlog_test")
+	require.True(t, squid.Init())
+	require.True(t, squid.Check())
+	defer squid.Cleanup()
+
+	mx := squid.Collect()
+
+	assert.Equal(t, int64(500), mx["requests"])
+}
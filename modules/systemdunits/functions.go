@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+//go:build linux
+// +build linux
+
+package systemdunits
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/netdata/go.d.plugin/agent/module"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+)
+
+func (s *SystemdUnits) Functions() map[string]module.Function {
+	return map[string]module.Function{
+		"failed-units": {
+			Help:    "List of systemd units in the failed state.",
+			Handler: s.funcFailedUnits,
+		},
+	}
+}
+
+func (s *SystemdUnits) funcFailedUnits(ctx context.Context, _ []string) (*module.FunctionTable, error) {
+	conn, err := s.client.connect()
+	if err != nil {
+		return nil, fmt.Errorf("error on creating a connection: %v", err)
+	}
+	defer conn.Close()
+
+	ver, err := s.getSystemdVersion(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var units []dbus.UnitStatus
+	if ver >= 230 {
+		units, err = conn.ListUnitsByPatternsContext(ctx, []string{unitStateFailed}, s.Include)
+	} else {
+		units, err = conn.ListUnitsContext(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on listing units: %v", err)
+	}
+
+	tbl := &module.FunctionTable{
+		Help: "Failed systemd units",
+		Columns: []module.FunctionColumn{
+			{ID: "unit", Name: "Unit", Type: module.ColumnString, UniqueKey: true},
+			{ID: "type", Name: "Type", Type: module.ColumnString},
+			{ID: "load_state", Name: "Load State", Type: module.ColumnString},
+			{ID: "active_state", Name: "Active State", Type: module.ColumnString},
+			{ID: "sub_state", Name: "Sub State", Type: module.ColumnString},
+			{ID: "description", Name: "Description", Type: module.ColumnString},
+		},
+	}
+
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+
+	for _, unit := range units {
+		if unit.ActiveState != unitStateFailed || (s.sr != nil && !s.sr.MatchString(unit.Name)) {
+			continue
+		}
+		_, typ := extractUnitNameType(cleanUnitName(unit.Name))
+		tbl.AddRow(cleanUnitName(unit.Name), typ, unit.LoadState, unit.ActiveState, unit.SubState, unit.Description)
+	}
+
+	return tbl, nil
+}
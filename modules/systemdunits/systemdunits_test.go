@@ -713,6 +713,54 @@ func TestSystemdUnits_connectionReuse(t *testing.T) {
 	assert.Equal(t, 1, client.connectCalls)
 }
 
+func TestSystemdUnits_Functions_FailedUnits(t *testing.T) {
+	tests := map[string]struct {
+		version int
+	}{
+		"systemd >= 230": {version: 230},
+		"systemd < 230":  {version: 229},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			systemd := New()
+			systemd.Include = []string{"*"}
+			conn := &mockConn{
+				version: test.version,
+				units: append([]dbus.UnitStatus{
+					{Name: `nginx.service`, LoadState: "loaded", ActiveState: "failed", SubState: "failed", Description: "nginx"},
+					{Name: `dev-disk-by\x2duuid-DE44\x2dCEE0.mount`, LoadState: "loaded", ActiveState: "failed", SubState: "failed"},
+				}, mockSystemdUnits...),
+			}
+			client := &mockClient{conn: conn}
+			systemd.client = client
+			require.True(t, systemd.Init())
+
+			fn, ok := systemd.Functions()["failed-units"]
+			require.True(t, ok)
+
+			tbl, err := fn.Handler(context.Background(), nil)
+			require.NoError(t, err)
+
+			assert.True(t, conn.closeCalled)
+			assert.Nil(t, systemd.conn)
+			assert.Equal(t, [][]any{
+				{"dev-disk-by-uuid-DE44-CEE0.mount", "mount", "loaded", "failed", "failed", ""},
+				{"nginx.service", "service", "loaded", "failed", "failed", "nginx"},
+			}, tbl.Rows)
+		})
+	}
+}
+
+func TestSystemdUnits_Functions_FailedUnits_Error(t *testing.T) {
+	systemd := New()
+	systemd.client = prepareClientErrOnConnect()
+	require.True(t, systemd.Init())
+
+	_, err := systemd.Functions()["failed-units"].Handler(context.Background(), nil)
+	assert.Error(t, err)
+}
+
 func ensureCollectedHasAllChartsDimsVarsIDs(t *testing.T, sd *SystemdUnits, collected map[string]int64) {
 	for _, chart := range *sd.Charts() {
 		if chart.Obsolete {
@@ -31,6 +31,17 @@ func (w *WebLog) logPanicStackIfAny() {
 	panic(err)
 }
 
+// savePosition saves the log file read position, it is used to resume reading after restart (reload).
+func (w *WebLog) savePosition() {
+	pos, err := w.file.Position(w.parser)
+	if err != nil {
+		return
+	}
+	if err := w.SaveState(pos); err != nil {
+		w.Debugf("saving log file position: %v", err)
+	}
+}
+
 func (w *WebLog) collect() (map[string]int64, error) {
 	defer w.logPanicStackIfAny()
 	w.mx.reset()
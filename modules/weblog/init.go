@@ -146,10 +146,27 @@ func (w *WebLog) createLogReader() error {
 
 	w.Debugf("created log reader, current file '%s'", reader.CurrentFilename())
 	w.file = reader
+	w.restorePosition()
 
 	return nil
 }
 
+// restorePosition resumes reading the log file from the position saved before the restart (reload).
+func (w *WebLog) restorePosition() {
+	var pos logs.Position
+	if !w.LoadState(&pos) {
+		return
+	}
+	ok, err := w.file.Restore(pos)
+	if err != nil {
+		w.Warningf("restoring log file position: %v", err)
+		return
+	}
+	if ok {
+		w.Debugf("resuming reading '%s' from offset %d", pos.Path, pos.Offset)
+	}
+}
+
 func (w *WebLog) createParser() error {
 	w.Debug("starting parser creating")
 
@@ -145,6 +145,8 @@ func (w *WebLog) Collect() map[string]int64 {
 	mx, err := w.collect()
 	if err != nil {
 		w.Error(err)
+	} else {
+		w.savePosition()
 	}
 
 	if len(mx) == 0 {
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/netdata/go.d.plugin/pkg/metrics"
 
 	"github.com/netdata/go.d.plugin/agent/module"
+	"github.com/netdata/go.d.plugin/agent/module/moduletest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -1491,3 +1493,37 @@ func prepareWebLogCollectIISFields(t *testing.T) *WebLog {
 //func randFromString(s []string) string { return s[r.Intn(len(s))] }
 //func randFromInt(s []int) int          { return s[r.Intn(len(s))] }
 //func randInt(min, max int) int         { return r.Intn(max-min) + min }
+
+func TestWebLog_Collect_ResumesFromSavedPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, append(bytes.TrimSpace(testCommonLog), '\n'), 0644))
+	store := &moduletest.StateStore{}
+
+	weblog := New()
+	weblog.Path = path
+	weblog.SetStateStore(store, "weblog_test")
+	require.True(t, weblog.Init())
+	require.True(t, weblog.Check())
+	_ = weblog.Collect()
+	weblog.Cleanup()
+	_, ok := store.LoadState("weblog_test")
+	require.True(t, ok)
+
+	// lines written while the job is stopped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.Write(append(bytes.TrimSpace(testCommonLog), '\n'))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	weblog = New()
+	weblog.Path = path
+	weblog.SetStateStore(store, "weblog_test")
+	require.True(t, weblog.Init())
+	require.True(t, weblog.Check())
+	defer weblog.Cleanup()
+
+	mx := weblog.Collect()
+
+	assert.Equal(t, int64(500), mx["requests"]+mx["unmatched"])
+}
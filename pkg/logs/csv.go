@@ -3,6 +3,7 @@
 package logs
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/csv"
 	"errors"
@@ -23,6 +24,7 @@ type (
 
 	CSVParser struct {
 		Config CSVConfig
+		buf    *bufio.Reader
 		reader *csv.Reader
 		format *csvFormat
 	}
@@ -49,14 +51,23 @@ func NewCSVParser(config CSVConfig, in io.Reader) (*CSVParser, error) {
 		return nil, fmt.Errorf("bad csv format '%s': %v", config.Format, err)
 	}
 
+	// csv.Reader uses the bufio.Reader as is, its Buffered is the read but not parsed data
+	buf := bufio.NewReader(in)
+
 	p := &CSVParser{
 		Config: config,
-		reader: newCSVReader(in, config),
+		buf:    buf,
+		reader: newCSVReader(buf, config),
 		format: format,
 	}
 	return p, nil
 }
 
+// Buffered returns the number of bytes read from the input but not parsed yet.
+func (p *CSVParser) Buffered() int {
+	return p.buf.Buffered()
+}
+
 func (p *CSVParser) ReadLine(line LogLine) error {
 	record, err := p.reader.Read()
 	if err != nil {
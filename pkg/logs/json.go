@@ -31,6 +31,11 @@ func NewJSONParser(config JSONConfig, in io.Reader) (*JSONParser, error) {
 	return parser, nil
 }
 
+// Buffered returns the number of bytes read from the input but not parsed yet.
+func (p *JSONParser) Buffered() int {
+	return p.reader.Buffered()
+}
+
 func (p *JSONParser) ReadLine(line LogLine) error {
 	row, err := p.reader.ReadSlice('\n')
 	if err != nil && len(row) == 0 {
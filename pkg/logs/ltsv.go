@@ -51,6 +51,11 @@ func NewLTSVParser(config LTSVConfig, in io.Reader) (*LTSVParser, error) {
 	return parser, nil
 }
 
+// Buffered returns the number of bytes read from the input but not parsed yet.
+func (p *LTSVParser) Buffered() int {
+	return p.r.Buffered()
+}
+
 func (p *LTSVParser) ReadLine(line LogLine) error {
 	row, err := p.r.ReadSlice('\n')
 	if err != nil && len(row) == 0 {
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"syscall"
 
 	"github.com/netdata/go.d.plugin/logger"
 )
@@ -26,6 +27,7 @@ var (
 // TODO: handle truncate
 type Reader struct {
 	file          *os.File
+	offset        int64 // the file offset, the end of the data returned by Read
 	path          string
 	excludePath   string
 	eofCounter    int
@@ -64,6 +66,74 @@ func (r *Reader) CurrentFilename() string {
 	return r.file.Name()
 }
 
+// Position is a log file read position, it is saved to resume reading after restart.
+// The file is identified by the path and the device and inode numbers, a rotated file
+// can be replaced with a new one with the same name.
+type Position struct {
+	Path   string `json:"path"`
+	Dev    uint64 `json:"dev"`
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// Position returns the read position of the data consumed by the parser reading from the Reader.
+// The data the parser has read but not parsed yet (buffered) is read again after Restore.
+func (r *Reader) Position(p Parser) (Position, error) {
+	if r == nil || r.file == nil {
+		return Position{}, os.ErrInvalid
+	}
+	offset := r.offset
+	if v, ok := p.(interface{ Buffered() int }); ok {
+		offset -= int64(v.Buffered())
+	}
+	// the buffered data can be from the previous file after reopen
+	if offset < 0 {
+		offset = 0
+	}
+	stat, err := r.file.Stat()
+	if err != nil {
+		return Position{}, err
+	}
+	dev, inode, _ := fileID(stat)
+	return Position{Path: r.file.Name(), Dev: dev, Inode: inode, Offset: offset}, nil
+}
+
+// Restore seeks to the saved position if it is in the currently opened file.
+// It returns false if the file has changed: it's another file (e.g. rotated and re-created with the same name)
+// or it was truncated.
+func (r *Reader) Restore(pos Position) (bool, error) {
+	if r == nil || r.file == nil {
+		return false, os.ErrInvalid
+	}
+	if pos.Path != r.file.Name() || pos.Offset < 0 {
+		return false, nil
+	}
+	stat, err := r.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if dev, inode, ok := fileID(stat); !ok || dev != pos.Dev || inode != pos.Inode {
+		return false, nil
+	}
+	if stat.Size() < pos.Offset {
+		return false, nil
+	}
+	if _, err := r.file.Seek(pos.Offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	r.offset = pos.Offset
+	return true, nil
+}
+
+// fileID returns the file device and inode numbers.
+func fileID(fi os.FileInfo) (dev, inode uint64, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Dev), st.Ino, true
+}
+
 func (r *Reader) open() error {
 	path := r.findFile()
 	if path == "" {
@@ -83,11 +153,13 @@ func (r *Reader) open() error {
 		return err
 	}
 	r.file = file
+	r.offset = stat.Size()
 	return nil
 }
 
 func (r *Reader) Read(p []byte) (n int, err error) {
 	n, err = r.file.Read(p)
+	r.offset += int64(n)
 	if err != nil {
 		switch err {
 		case io.EOF:
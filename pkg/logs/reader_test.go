@@ -170,6 +170,93 @@ func TestReader_CurrentFilename(t *testing.T) {
 	assert.Equal(t, reader.file.Name(), reader.CurrentFilename())
 }
 
+func TestReader_PositionRestore(t *testing.T) {
+	reader, teardown := prepareTestReader(t)
+	defer teardown()
+
+	filename := reader.CurrentFilename()
+	appendLogs(t, filename, 0, 5)
+
+	parser, err := NewRegExpParser(RegExpConfig{Pattern: `^line (?P<num>\d+)`}, reader)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, parser.ReadLine(newLogLine()))
+	}
+
+	// the parser has read the whole file, the position is after the parsed lines
+	pos, err := reader.Position(parser)
+	require.NoError(t, err)
+	assert.Equal(t, filename, pos.Path)
+	assert.Equal(t, int64(2*len(fmt.Sprintln("line", 0, "filename", filepath.Base(filename)))), pos.Offset)
+
+	// lines written while the reader is stopped are read after restore
+	appendLogs(t, filename, 0, 3)
+
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	stat, err := f.Stat()
+	require.NoError(t, err)
+	_, err = f.Seek(stat.Size(), io.SeekStart)
+	require.NoError(t, err)
+	restored := &Reader{file: f, path: filename}
+	defer func() { _ = restored.Close() }()
+
+	ok, err := restored.Restore(pos)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	r := testReader{bufio.NewReader(restored)}
+	n, err := r.readUntilEOF()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 3+3, n)
+
+	// another file
+	ok, err = restored.Restore(Position{Path: filename + ".1", Dev: pos.Dev, Inode: pos.Inode, Offset: 1})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// truncated file
+	ok, err = restored.Restore(Position{Path: filename, Dev: pos.Dev, Inode: pos.Inode, Offset: stat.Size() * 10})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestReader_Restore_RotatedFile(t *testing.T) {
+	reader, teardown := prepareTestReader(t)
+	defer teardown()
+
+	filename := reader.CurrentFilename()
+	appendLogs(t, filename, 0, 2)
+
+	parser, err := NewRegExpParser(RegExpConfig{Pattern: `^line (?P<num>\d+)`}, reader)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, parser.ReadLine(newLogLine()))
+	}
+	pos, err := reader.Position(parser)
+	require.NoError(t, err)
+	assert.NotZero(t, pos.Inode)
+
+	// logrotate: the file is renamed and a new one is created, it grows past the saved offset
+	require.NoError(t, os.Rename(filename, filename+".1"))
+	require.NoError(t, os.WriteFile(filename, nil, 0644))
+	appendLogs(t, filename, 0, 5)
+
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	restored := &Reader{file: f, path: filename}
+	defer func() { _ = restored.Close() }()
+
+	stat, err := f.Stat()
+	require.NoError(t, err)
+	require.Greater(t, stat.Size(), pos.Offset)
+
+	ok, err := restored.Restore(pos)
+	assert.NoError(t, err)
+	assert.False(t, ok, "the position is restored in the new file")
+}
+
 type testReader struct {
 	*bufio.Reader
 }
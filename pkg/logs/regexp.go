@@ -42,6 +42,11 @@ func NewRegExpParser(config RegExpConfig, in io.Reader) (*RegExpParser, error) {
 	return p, nil
 }
 
+// Buffered returns the number of bytes read from the input but not parsed yet.
+func (p *RegExpParser) Buffered() int {
+	return p.r.Buffered()
+}
+
 func (p *RegExpParser) ReadLine(line LogLine) error {
 	row, err := p.r.ReadSlice('\n')
 	if err != nil && len(row) == 0 {
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WrapTransport returns the transport that records or replays the HTTP responses depending on the Default recorder mode.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return Default.WrapTransport(rt)
+}
+
+// WrapTransport returns the transport that records or replays the HTTP responses, rt is returned as is if the recorder is off.
+func (r *Recorder) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	switch r.Mode() {
+	case Record:
+		return &recordTransport{r: r, rt: rt}
+	case Replay:
+		return &replayTransport{r: r, rt: rt}
+	}
+	return rt
+}
+
+// Unwrap returns the transport wrapped by WrapTransport. In replay mode it is not used for the requests.
+func Unwrap(rt http.RoundTripper) http.RoundTripper {
+	switch t := rt.(type) {
+	case *recordTransport:
+		return t.rt
+	case *replayTransport:
+		return t.rt
+	}
+	return rt
+}
+
+type recordTransport struct {
+	r  *Recorder
+	rt http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := httpKey(req)
+	if err != nil {
+		return nil, err
+	}
+	t.r.AddSecrets(requestSecrets(req)...)
+
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		t.r.record(&entry{Kind: KindHTTP, Key: key, Error: err.Error()}, nil)
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	e := &entry{Kind: KindHTTP, Key: key, Status: resp.StatusCode}
+	if v := resp.Header.Get("Content-Type"); v != "" {
+		e.Header = map[string][]string{"Content-Type": {v}}
+	}
+	t.r.record(e, body)
+
+	return resp, nil
+}
+
+type replayTransport struct {
+	r  *Recorder
+	rt http.RoundTripper
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := httpKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{key}
+	if i := strings.IndexByte(key, '?'); i > 0 {
+		keys = append(keys, key[:i])
+	}
+
+	e, err := t.r.replay(KindHTTP, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(e.Header),
+		Body:          io.NopCloser(bytes.NewReader(e.data)),
+		ContentLength: int64(len(e.data)),
+		Request:       req,
+	}, nil
+}
+
+// httpKey is the request method and URL (without the user info and the sensitive query parameters values),
+// plus the body hash if the request has a body.
+func httpKey(req *http.Request) (string, error) {
+	u := *req.URL
+	u.User = nil
+	redactQuery(&u)
+	key := req.Method + " " + u.String()
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return key, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		return key, nil
+	}
+
+	sum := sha256.Sum256(body)
+	return key + " " + hex.EncodeToString(sum[:8]), nil
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Output runs the command and returns its standard output (see exec.Cmd.Output).
+// The output is recorded or replayed (the command is not run) depending on the Default recorder mode.
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	return Default.Output(cmd)
+}
+
+func (r *Recorder) Output(cmd *exec.Cmd) ([]byte, error) {
+	key := strings.Join(cmd.Args, " ")
+
+	switch r.Mode() {
+	case Record:
+		bs, err := cmd.Output()
+		e := &entry{Kind: KindExec, Key: key}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		r.record(e, bs)
+		return bs, err
+	case Replay:
+		e, err := r.replay(KindExec, key)
+		if err != nil {
+			return nil, err
+		}
+		if e.Error != "" {
+			return e.data, errors.New(e.Error)
+		}
+		return e.data, nil
+	}
+	return cmd.Output()
+}
+
+// RecordSocket records the response lines of a socket command. The credentials of the commands like "PASSWORD <password>" are redacted.
+func (r *Recorder) RecordSocket(address, command string, lines [][]byte, err error) {
+	e := &entry{Kind: KindSocket, Key: address + " " + redactCommand(command)}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.record(e, bytes.Join(lines, []byte("\n")))
+}
+
+// ReplaySocket returns the recorded response lines of a socket command.
+func (r *Recorder) ReplaySocket(address, command string) ([][]byte, error) {
+	e, err := r.replay(KindSocket, address+" "+redactCommand(command))
+	if err != nil {
+		return nil, err
+	}
+	var lines [][]byte
+	if len(e.data) > 0 {
+		lines = bytes.Split(e.data, []byte("\n"))
+	}
+	if e.Error != "" {
+		return lines, errors.New(e.Error)
+	}
+	return lines, nil
+}
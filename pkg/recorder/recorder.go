@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package recorder records the modules upstream I/O (HTTP responses, socket exchanges and commands output)
+// to a bundle directory and replays it offline.
+//
+// Every exchange is written as two files: "<seq>-<kind>.json" (the request key and the response metadata)
+// and "<seq>-<kind>.data" (the raw response), so the recorded responses can be used as testdata fixtures.
+// In replay mode the exchanges with the same key are served in the recorded order, the last one is repeated.
+//
+// The credentials are redacted in everything written to the bundle: the resolved secrets (see Recorder.Redact),
+// the configured passwords, tokens and keys (see AddConfigSecrets), the request credentials (user info,
+// authorization headers and sensitive query parameters) and the arguments of the socket commands like "PASSWORD".
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Mode int
+
+const (
+	Off Mode = iota
+	Record
+	Replay
+)
+
+const (
+	KindHTTP   = "http"
+	KindSocket = "socket"
+	KindExec   = "exec"
+)
+
+// Default is the recorder used by pkg/web, pkg/socket and Output. It is off unless enabled.
+var Default = New()
+
+func New() *Recorder {
+	return &Recorder{
+		Redact:  func(s string) string { return s },
+		mux:     &sync.Mutex{},
+		secrets: make(map[string]bool),
+		entries: make(map[string]*replayEntries),
+	}
+}
+
+// Recorder records and replays the upstream I/O.
+type Recorder struct {
+	// Redact is applied to everything written to the bundle, before the plaintext secrets (see AddSecrets) are redacted.
+	Redact func(string) string
+
+	mode Mode
+	dir  string
+
+	mux     *sync.Mutex
+	seq     int
+	secrets map[string]bool           // the plaintext secrets
+	entries map[string]*replayEntries // kind + key => the recorded entries
+}
+
+type entry struct {
+	Kind   string              `json:"kind"`
+	Key    string              `json:"key"`
+	Status int                 `json:"status,omitempty"`
+	Header map[string][]string `json:"header,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	Data   string              `json:"data"`
+
+	data []byte
+}
+
+type replayEntries struct {
+	items []*entry
+	next  int
+}
+
+// Enable switches the recorder into the record or replay mode.
+// In record mode the bundle directory is created, in replay mode the bundle is loaded.
+func (r *Recorder) Enable(mode Mode, dir string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	switch mode {
+	case Record:
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create bundle dir: %v", err)
+		}
+	case Replay:
+		if err := r.load(dir); err != nil {
+			return fmt.Errorf("load bundle '%s': %v", dir, err)
+		}
+	}
+	r.mode, r.dir = mode, dir
+	return nil
+}
+
+func (r *Recorder) Mode() Mode {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.mode
+}
+
+func (r *Recorder) record(e *entry, data []byte) {
+	r.mux.Lock()
+	r.seq++
+	name := fmt.Sprintf("%06d-%s", r.seq, e.Kind)
+	dir := r.dir
+	r.mux.Unlock()
+
+	e.Key = r.redact(e.Key)
+	e.Error = r.redact(e.Error)
+	e.Data = name + ".data"
+
+	bs, err := json.MarshalIndent(e, "", " ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(dir, e.Data), []byte(r.redact(string(data))), 0644)
+	_ = os.WriteFile(filepath.Join(dir, name+".json"), bs, 0644)
+}
+
+func (r *Recorder) replay(kind string, keys ...string) (*entry, error) {
+	redacted := make([]string, len(keys))
+	for i, key := range keys {
+		redacted[i] = r.redact(key)
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, key := range redacted {
+		es, ok := r.entries[kind+" "+key]
+		if !ok || len(es.items) == 0 {
+			continue
+		}
+		e := es.items[min(es.next, len(es.items)-1)]
+		es.next++
+		return e, nil
+	}
+	return nil, fmt.Errorf("no recorded %s response for '%s'", kind, keys[0])
+}
+
+func (r *Recorder) load(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no recorded exchanges")
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var e entry
+		if err := json.Unmarshal(bs, &e); err != nil {
+			return fmt.Errorf("'%s': %v", file, err)
+		}
+		if e.data, err = os.ReadFile(filepath.Join(dir, filepath.Base(e.Data))); err != nil {
+			return err
+		}
+
+		r.addEntry(e.Kind+" "+e.Key, &e)
+		// HTTP requests often have time dependent query parameters, they are also matched without the query
+		if e.Kind == KindHTTP {
+			if i := strings.IndexByte(e.Key, '?'); i > 0 {
+				r.addEntry(e.Kind+" "+e.Key[:i], &e)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) addEntry(key string, e *entry) {
+	es, ok := r.entries[key]
+	if !ok {
+		es = &replayEntries{}
+		r.entries[key] = es
+	}
+	es.items = append(es.items, e)
+}
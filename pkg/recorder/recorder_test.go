@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package recorder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_HTTP(t *testing.T) {
+	dir := t.TempDir()
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = io.WriteString(w, "token=s3cret response "+strings.Repeat("x", calls))
+	}))
+	defer srv.Close()
+
+	rec := New()
+	rec.Redact = func(s string) string { return strings.ReplaceAll(s, "s3cret", "${env:TOKEN}") }
+	require.NoError(t, rec.Enable(Record, dir))
+	client := &http.Client{Transport: rec.WrapTransport(http.DefaultTransport)}
+
+	var recorded []string
+	for _, path := range []string{"/status?ts=1", "/status?ts=2", "/missing"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		bs, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		recorded = append(recorded, string(bs))
+	}
+	assert.Equal(t, "token=s3cret response x", recorded[0])
+
+	data, err := os.ReadFile(filepath.Join(dir, "000001-http.data"))
+	require.NoError(t, err)
+	assert.Equal(t, "token=${env:TOKEN} response x", string(data), "secrets are redacted")
+
+	rep := New()
+	require.NoError(t, rep.Enable(Replay, dir))
+	client = &http.Client{Transport: rep.WrapTransport(http.DefaultTransport)}
+	srv.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/status?ts=1", wantStatus: 200, wantBody: "token=${env:TOKEN} response x"},
+		{path: "/status?ts=2", wantStatus: 200, wantBody: "token=${env:TOKEN} response xx"},
+		{path: "/status?ts=3", wantStatus: 200, wantBody: "token=${env:TOKEN} response x"}, // matched without the query
+		{path: "/missing", wantStatus: 404, wantBody: "token=${env:TOKEN} response xxx"},
+		{path: "/missing", wantStatus: 404, wantBody: "token=${env:TOKEN} response xxx"},
+	}
+	for _, test := range tests {
+		resp, err := client.Get(srv.URL + test.path)
+		require.NoError(t, err, test.path)
+		bs, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, test.wantStatus, resp.StatusCode, test.path)
+		assert.Equal(t, test.wantBody, string(bs), test.path)
+		assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	}
+
+	_, err = client.Get(srv.URL + "/not_recorded")
+	assert.Error(t, err)
+}
+
+func TestRecorder_Output(t *testing.T) {
+	dir := t.TempDir()
+
+	rec := New()
+	require.NoError(t, rec.Enable(Record, dir))
+	bs, err := rec.Output(exec.Command("echo", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(bs))
+	_, err = rec.Output(exec.Command("false"))
+	require.Error(t, err)
+
+	rep := New()
+	require.NoError(t, rep.Enable(Replay, dir))
+	bs, err = rep.Output(exec.Command("echo", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(bs))
+	_, err = rep.Output(exec.Command("false"))
+	assert.Error(t, err)
+	_, err = rep.Output(exec.Command("echo", "not recorded"))
+	assert.Error(t, err)
+}
+
+func TestRecorder_Enable_ReplayEmptyBundle(t *testing.T) {
+	assert.Error(t, New().Enable(Replay, t.TempDir()))
+}
@@ -8,6 +8,8 @@ import (
 	"errors"
 	"net"
 	"time"
+
+	"github.com/netdata/go.d.plugin/pkg/recorder"
 )
 
 // New returns a new pointer to a socket client given the socket
@@ -24,7 +26,8 @@ func New(config Config) *Socket {
 // Socket is the implementation of a socket client.
 type Socket struct {
 	Config
-	conn net.Conn
+	conn      net.Conn
+	replaying bool
 }
 
 // Connect connects to the Socket address on the named network.
@@ -32,6 +35,11 @@ type Socket struct {
 // Address like :80 will attempt to connect to the localhost.
 // The config timeout and TLS config will be used.
 func (s *Socket) Connect() (err error) {
+	if recorder.Default.Mode() == recorder.Replay {
+		s.replaying = true
+		return nil
+	}
+
 	network, address := networkType(s.Address)
 	if s.TLSConf == nil {
 		s.conn, err = net.DialTimeout(network, address, s.ConnectTimeout)
@@ -46,6 +54,7 @@ func (s *Socket) Connect() (err error) {
 // Disconnect closes the connection.
 // Any in-flight commands will be cancelled and return errors.
 func (s *Socket) Disconnect() (err error) {
+	s.replaying = false
 	if s.conn != nil {
 		err = s.conn.Close()
 		s.conn = nil
@@ -60,15 +69,47 @@ func (s *Socket) Disconnect() (err error) {
 // of the responses this function will stop processing and return a
 // timeout error.
 func (s *Socket) Command(command string, process Processor) error {
+	if s.replaying {
+		return s.replayCommand(command, process)
+	}
 	if s.conn == nil {
 		return errors.New("cannot send command on nil connection")
 	}
 	if err := write(command, s.conn, s.WriteTimeout); err != nil {
 		return err
 	}
+	if recorder.Default.Mode() == recorder.Record {
+		return s.recordCommand(command, process)
+	}
 	return read(s.conn, process, s.ReadTimeout)
 }
 
+func (s *Socket) recordCommand(command string, process Processor) error {
+	if process == nil {
+		return errors.New("process func is nil")
+	}
+	var lines [][]byte
+	err := read(s.conn, func(bs []byte) bool {
+		lines = append(lines, append([]byte(nil), bs...))
+		return process(bs)
+	}, s.ReadTimeout)
+	recorder.Default.RecordSocket(s.Address, command, lines, err)
+	return err
+}
+
+func (s *Socket) replayCommand(command string, process Processor) error {
+	if process == nil {
+		return errors.New("process func is nil")
+	}
+	lines, err := recorder.Default.ReplaySocket(s.Address, command)
+	for _, line := range lines {
+		if !process(line) {
+			break
+		}
+	}
+	return err
+}
+
 func write(command string, writer net.Conn, timeout time.Duration) error {
 	if writer == nil {
 		return errors.New("attempt to write on nil connection")
@@ -4,9 +4,12 @@ package socket
 
 import (
 	"crypto/tls"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/netdata/go.d.plugin/pkg/recorder"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -161,3 +164,35 @@ func Test_clientEmptyProcessFunc(t *testing.T) {
 	err := sock.Command("ping\n", nil)
 	require.Error(t, err, "nil process func should return an error")
 }
+
+func Test_clientRecordReplay(t *testing.T) {
+	defer func(r *recorder.Recorder) { recorder.Default = r }(recorder.Default)
+	dir := t.TempDir()
+
+	srv := &unixServer{addr: filepath.Join(dir, "sock"), rowsNumResp: 2}
+	go func() { _ = srv.Run() }()
+	time.Sleep(time.Millisecond * 200)
+
+	cfg := unixConfig
+	cfg.Address = srv.addr
+
+	recorder.Default = recorder.New()
+	require.NoError(t, recorder.Default.Enable(recorder.Record, dir))
+	sock := New(cfg)
+	require.NoError(t, sock.Connect())
+	var recorded []string
+	require.NoError(t, sock.Command("ping\n", func(bs []byte) bool { recorded = append(recorded, string(bs)); return len(recorded) < 2 }))
+	require.NoError(t, sock.Disconnect())
+	_ = srv.conn.Close()
+
+	recorder.Default = recorder.New()
+	require.NoError(t, recorder.Default.Enable(recorder.Replay, dir))
+	sock = New(cfg)
+	require.NoError(t, sock.Connect())
+	var replayed []string
+	require.NoError(t, sock.Command("ping\n", func(bs []byte) bool { replayed = append(replayed, string(bs)); return len(replayed) < 2 }))
+	require.NoError(t, sock.Disconnect())
+
+	assert.Equal(t, []string{"pong", "pong"}, recorded)
+	assert.Equal(t, recorded, replayed)
+}
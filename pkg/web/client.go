@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/netdata/go.d.plugin/pkg/recorder"
 	"github.com/netdata/go.d.plugin/pkg/tlscfg"
 )
 
@@ -59,7 +60,7 @@ func NewHTTPClient(cfg Client) (*http.Client, error) {
 
 	return &http.Client{
 		Timeout:       cfg.Timeout.Duration,
-		Transport:     transport,
+		Transport:     recorder.WrapTransport(transport),
 		CheckRedirect: redirectFunc(cfg.NotFollowRedirect),
 	}, nil
 }